@@ -0,0 +1,26 @@
+package constants
+
+// Domain events achievements are evaluated on.
+const (
+	EventQuizCompleted   = "quiz_completed"
+	EventProblemAnswered = "problem_answered"
+)
+
+// Metrics an achievement rule can compare against its threshold.
+const (
+	MetricQuizScore        = "quiz_score"
+	MetricQuizzesCompleted = "quizzes_completed"
+	MetricCorrectStreak    = "correct_streak"
+	MetricCorrectAnswers   = "correct_answers"
+	MetricProblemsSolved   = "problems_solved"
+	MetricStudyStreak      = "study_streak"
+)
+
+func IsValidAchievementMetric(metric string) bool {
+	switch metric {
+	case MetricQuizScore, MetricQuizzesCompleted, MetricCorrectStreak,
+		MetricCorrectAnswers, MetricProblemsSolved, MetricStudyStreak:
+		return true
+	}
+	return false
+}
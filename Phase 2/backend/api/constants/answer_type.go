@@ -0,0 +1,7 @@
+package constants
+
+const (
+	AnswerTypeExact   = "exact"
+	AnswerTypeNumeric = "numeric"
+	AnswerTypeAI      = "ai"
+)
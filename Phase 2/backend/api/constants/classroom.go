@@ -0,0 +1,13 @@
+package constants
+
+const (
+	ClassTeacher = "teacher"
+	ClassStudent = "student"
+)
+
+// Where a student stands on an assignment.
+const (
+	AssignmentAssigned  = "assigned"
+	AssignmentCompleted = "completed"
+	AssignmentOverdue   = "overdue"
+)
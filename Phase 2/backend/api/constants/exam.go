@@ -0,0 +1,47 @@
+package constants
+
+const (
+	TierFoundation = "foundation"
+	TierHigher     = "higher"
+)
+
+const (
+	PaperCalculator    = "calculator"
+	PaperNonCalculator = "non-calculator"
+)
+
+// Assessment objectives from the GCSE maths specification.
+const (
+	AO1 = "AO1" // Use and apply standard techniques
+	AO2 = "AO2" // Reason, interpret and communicate mathematically
+	AO3 = "AO3" // Solve problems within mathematics and in other contexts
+)
+
+// TierGrades lists the grades that can be awarded on each tier, highest
+// first. Grade 3 is the allowed grade on Higher.
+var TierGrades = map[string][]int{
+	TierFoundation: {5, 4, 3, 2, 1},
+	TierHigher:     {9, 8, 7, 6, 5, 4, 3},
+}
+
+// TopicShare is the share of marks each topic carries on a tier, from the
+// GCSE maths subject content weightings. Statistics and probability share a
+// single weighting in the specification and are split evenly here.
+var TopicShare = map[string]map[TopicEnum]float64{
+	TierFoundation: {
+		Number:                          0.25,
+		Algebra:                         0.20,
+		RatioProportionAndRatesOfChange: 0.25,
+		GeometryAndMeasures:             0.15,
+		Probability:                     0.075,
+		Statistics:                      0.075,
+	},
+	TierHigher: {
+		Number:                          0.15,
+		Algebra:                         0.30,
+		RatioProportionAndRatesOfChange: 0.20,
+		GeometryAndMeasures:             0.20,
+		Probability:                     0.075,
+		Statistics:                      0.075,
+	},
+}
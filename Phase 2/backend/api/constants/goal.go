@@ -0,0 +1,8 @@
+package constants
+
+const (
+	GoalQuestions = "questions"
+	GoalMinutes   = "minutes"
+)
+
+const NotificationStreakAtRisk = "streak_at_risk"
@@ -5,3 +5,7 @@ const (
 	LevelIntermediate = "intermediate"
 	LevelAdvanced     = "advanced"
 )
+
+func IsValidLevel(level string) bool {
+	return level == LevelBeginner || level == LevelIntermediate || level == LevelAdvanced
+}
@@ -0,0 +1,12 @@
+package constants
+
+// Outcomes recorded in the sign-in audit log. A locked attempt was refused
+// without checking the password; a challenged one passed the password and was
+// asked for a second factor.
+const (
+	LoginSuccess    = "success"
+	LoginFailed     = "failed"
+	LoginLocked     = "locked"
+	LoginReset      = "reset"
+	LoginChallenged = "challenged"
+)
@@ -0,0 +1,7 @@
+package constants
+
+// Purposes of the single-use tokens sent by email.
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
@@ -10,3 +10,21 @@ const (
 	Probability                     TopicEnum = "Probability"
 	Statistics                      TopicEnum = "Statistics"
 )
+
+var Topics = []TopicEnum{
+	Number,
+	Algebra,
+	RatioProportionAndRatesOfChange,
+	GeometryAndMeasures,
+	Probability,
+	Statistics,
+}
+
+func IsValidTopic(topic string) bool {
+	for _, t := range Topics {
+		if string(t) == topic {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,20 @@
+package dto
+
+import "time"
+
+// AchievementEvent is a domain event achievements are evaluated against.
+// Score is set for completed quizzes.
+type AchievementEvent struct {
+	Type   string
+	UserID uint
+	Score  int
+}
+
+type Achievement struct {
+	Code        string     `json:"code"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	Icon        string     `json:"icon"`
+	Earned      bool       `json:"earned"`
+	AwardedAt   *time.Time `json:"awarded_at"`
+}
@@ -0,0 +1,96 @@
+package dto
+
+import "time"
+
+type QuizAssignment struct {
+	ID            uint      `json:"id"`
+	ClassroomID   uint      `json:"classroom_id"`
+	ClassroomName string    `json:"classroom_name"`
+	QuizID        uint      `json:"quiz_id"`
+	QuizTitle     string    `json:"quiz_title"`
+	AssignedBy    uint      `json:"assigned_by"`
+	OpenAt        time.Time `json:"open_at"`
+	DueAt         time.Time `json:"due_at"`
+	MaxAttempts   *int      `json:"max_attempts"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AssignmentStatus is one student's standing on an assignment, derived from
+// their quiz submissions since it opened. Late is set when the first of
+// them came after the due date.
+type AssignmentStatus struct {
+	Status           string     `json:"status"`
+	Late             bool       `json:"late"`
+	Attempts         int        `json:"attempts"`
+	AttemptsLeft     *int       `json:"attempts_left"`
+	BestScore        *int       `json:"best_score"`
+	FirstSubmittedAt *time.Time `json:"first_submitted_at"`
+	LastSubmittedAt  *time.Time `json:"last_submitted_at"`
+}
+
+// AssignmentProgress summarises a class's standing on an assignment for its
+// teachers.
+type AssignmentProgress struct {
+	Students  int `json:"students"`
+	Completed int `json:"completed"`
+	Late      int `json:"late"`
+	Overdue   int `json:"overdue"`
+}
+
+// ClassAssignment is an assignment as listed for a class member: teachers see
+// the class's progress, students their own status.
+type ClassAssignment struct {
+	QuizAssignment
+	Progress *AssignmentProgress `json:"progress,omitempty"`
+	Status   *AssignmentStatus   `json:"status,omitempty"`
+}
+
+type AssignmentQuestion struct {
+	QuestionID uint   `json:"question_id"`
+	Question   string `json:"question"`
+	Topic      string `json:"topic"`
+	Answered   int    `json:"answered"`
+	Correct    int    `json:"correct"`
+	// Difficulty is the percentage of answering students who got the
+	// question wrong, null until someone has answered it.
+	Difficulty *float64 `json:"difficulty"`
+}
+
+// AssignmentResultRow is one student's row of the results matrix. Answers
+// holds their latest answer to each question, in the order of the
+// assignment's questions: true if correct, false if wrong, null if not
+// answered.
+type AssignmentResultRow struct {
+	UserID uint   `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	AssignmentStatus
+	Answers []*bool `json:"answers"`
+}
+
+type AssignmentResults struct {
+	Assignment QuizAssignment        `json:"assignment"`
+	Progress   AssignmentProgress    `json:"progress"`
+	Questions  []AssignmentQuestion  `json:"questions"`
+	Students   []AssignmentResultRow `json:"students"`
+}
+
+// AssignmentSubmission is a student's submissions on an assignment, as read
+// from quiz_log.
+type AssignmentSubmission struct {
+	UserID           uint
+	Name             string
+	Email            string
+	Attempts         int
+	BestScore        *int
+	FirstSubmittedAt *time.Time
+	LastSubmittedAt  *time.Time
+}
+
+// AssignmentAnswer is a student's latest answer to one of an assignment's
+// questions.
+type AssignmentAnswer struct {
+	UserID     uint
+	QuestionID uint
+	Correct    bool
+}
@@ -0,0 +1,39 @@
+package dto
+
+import "time"
+
+// ClassroomSummary is a class as seen by one of its members. The join code is
+// only shown to the class's teachers.
+type ClassroomSummary struct {
+	ID           uint      `json:"id"`
+	Name         string    `json:"name"`
+	Description  string    `json:"description"`
+	OwnerID      uint      `json:"owner_id"`
+	JoinCode     string    `json:"join_code,omitempty"`
+	Role         string    `json:"role"`
+	StudentCount int       `json:"student_count"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ClassroomTeacher struct {
+	UserID   uint      `json:"user_id"`
+	Name     string    `json:"name"`
+	Email    string    `json:"email"`
+	Owner    bool      `json:"owner"`
+	JoinedAt time.Time `json:"joined_at"`
+}
+
+type ClassroomDetail struct {
+	ClassroomSummary
+	Teachers []ClassroomTeacher `json:"teachers"`
+}
+
+// ClassroomStudent is one row of a class roster, with the student's dashboard
+// stats.
+type ClassroomStudent struct {
+	UserID   uint           `json:"user_id"`
+	Name     string         `json:"name"`
+	Email    string         `json:"email"`
+	JoinedAt time.Time      `json:"joined_at"`
+	Stats    DashboardStats `gorm:"-" json:"stats"`
+}
@@ -0,0 +1,49 @@
+package dto
+
+import "time"
+
+type DailyChallenge struct {
+	Date       string      `json:"date"`
+	Level      string      `json:"level"`
+	ProblemID  uint        `json:"problem_id"`
+	Topic      string      `json:"topic"`
+	Title      string      `json:"title"`
+	Question   string      `json:"question"`
+	AnswerType string      `json:"answer_type"`
+	Attempts   int64       `json:"attempts"`
+	Solved     bool        `json:"solved"`
+	Streak     DailyStreak `json:"streak"`
+}
+
+type DailyChallengeAttemptResult struct {
+	Correct  bool        `json:"correct"`
+	Answer   string      `json:"answer,omitempty"`
+	Solution string      `json:"solution,omitempty"`
+	Feedback string      `json:"feedback,omitempty"`
+	Streak   DailyStreak `json:"streak"`
+}
+
+type DailyStreak struct {
+	Current         int        `json:"current"`
+	Longest         int        `json:"longest"`
+	LastAttemptDate *time.Time `json:"last_attempt_date"`
+}
+
+type DailyLeaderboardEntry struct {
+	Rank     int        `json:"rank"`
+	UserID   uint       `json:"user_id"`
+	Name     string     `json:"name"`
+	Attempts int64      `json:"attempts"`
+	Solved   bool       `json:"solved"`
+	SolvedAt *time.Time `json:"solved_at"`
+}
+
+type AIProblem struct {
+	Title      string  `json:"title"`
+	Question   string  `json:"question"`
+	Answer     string  `json:"answer"`
+	AnswerType string  `json:"answer_type"`
+	Tolerance  float64 `json:"tolerance"`
+	Topic      string  `json:"topic"`
+	Solution   string  `json:"solution"`
+}
@@ -2,10 +2,13 @@ package dto
 
 import "time"
 
+// DashboardStats leaves AccuracyRate and MostChallengingTopic null until the
+// user has answered enough to report them.
 type DashboardStats struct {
-	QuizzesCompleted     int64   `json:"quizzes_completed"`
-	AccuracyRate         float64 `json:"accuracy_rate"`
-	MostChallengingTopic string  `json:"most_challenging_topic"`
+	QuizzesCompleted     int64    `json:"quizzes_completed"`
+	Attempts             int64    `json:"attempts"`
+	AccuracyRate         *float64 `json:"accuracy_rate"`
+	MostChallengingTopic *string  `json:"most_challenging_topic"`
 }
 
 type RecentActivity struct {
@@ -19,6 +22,9 @@ type TopicProficiency struct {
 	Correct    int64   `json:"correct"`
 	Total      int64   `json:"total"`
 	Percentage float64 `json:"percentage"`
+	Attempts   int64   `json:"attempts"`
+	EnoughData bool    `json:"enough_data"`
+	Mastery    float64 `json:"mastery"`
 }
 
 type ChallengingTopic struct {
@@ -26,4 +32,111 @@ type ChallengingTopic struct {
 	Wrong      int64   `json:"wrong"`
 	Total      int64   `json:"total"`
 	Percentage float64 `json:"percentage"`
+	Attempts   int64   `json:"attempts"`
+	EnoughData bool    `json:"enough_data"`
+}
+
+type GradeTopicImpact struct {
+	Topic           string  `json:"topic"`
+	Mastery         float64 `json:"mastery"`
+	Share           float64 `json:"share"`
+	Attempts        int     `json:"attempts"`
+	PotentialGain   float64 `json:"potential_gain"`
+	GradeIfImproved int     `json:"grade_if_improved"`
+}
+
+// PredictedGrade is the grade a user is on track for. The prediction is left
+// empty until the user has answered something, and EnoughData stays false
+// until every topic on the tier has enough answers to trust.
+type PredictedGrade struct {
+	EnoughData    bool               `json:"enough_data"`
+	Tier          string             `json:"tier"`
+	Grade         int                `json:"grade"`
+	GradeLow      int                `json:"grade_low"`
+	GradeHigh     int                `json:"grade_high"`
+	Score         float64            `json:"score"`
+	Attempts      int                `json:"attempts"`
+	TopicsToFocus []GradeTopicImpact `json:"topics_to_focus"`
+}
+
+// ProgressRow is one bucket of raw counts from the progress queries: the
+// number of answers, and marks awarded out of marks available. Topic is empty
+// for series that are not split by topic.
+type ProgressRow struct {
+	Bucket   time.Time `json:"bucket"`
+	Topic    string    `json:"topic"`
+	Answered int64     `json:"answered"`
+	Correct  int64     `json:"correct"`
+	Total    int64     `json:"total"`
+}
+
+type ProgressPoint struct {
+	Start                    time.Time `json:"start"`
+	QuestionsAnswered        int64     `json:"questions_answered"`
+	Correct                  int64     `json:"correct"`
+	Accuracy                 *float64  `json:"accuracy"`
+	QuizzesCompleted         int64     `json:"quizzes_completed"`
+	RollingAccuracy          *float64  `json:"rolling_accuracy"`
+	RollingQuestionsAnswered float64   `json:"rolling_questions_answered"`
+	RollingQuizzesCompleted  float64   `json:"rolling_quizzes_completed"`
+}
+
+type ProgressSeries struct {
+	Bucket string          `json:"bucket"`
+	From   string          `json:"from"`
+	To     string          `json:"to"`
+	Window int             `json:"window"`
+	Points []ProgressPoint `json:"points"`
+}
+
+type TopicProgressPoint struct {
+	Start           time.Time `json:"start"`
+	Correct         int64     `json:"correct"`
+	Total           int64     `json:"total"`
+	Accuracy        *float64  `json:"accuracy"`
+	RollingAccuracy *float64  `json:"rolling_accuracy"`
+}
+
+type TopicProgressSeries struct {
+	Topic  string               `json:"topic"`
+	Points []TopicProgressPoint `json:"points"`
+}
+
+type TopicProgress struct {
+	Bucket string                `json:"bucket"`
+	From   string                `json:"from"`
+	To     string                `json:"to"`
+	Window int                   `json:"window"`
+	Topics []TopicProgressSeries `json:"topics"`
+}
+
+// StudyDay is a user's activity on one local date. Minutes are estimated from
+// the gaps between answers, so an idle tab does not count as practice.
+type StudyDay struct {
+	Day       time.Time `json:"day"`
+	Questions int       `json:"questions"`
+	Quizzes   int       `json:"quizzes"`
+	Minutes   float64   `json:"minutes"`
+}
+
+type StudyStreak struct {
+	Timezone     string   `json:"timezone"`
+	GoalType     string   `json:"goal_type"`
+	GoalTarget   int      `json:"goal_target"`
+	Today        string   `json:"today"`
+	TodayValue   int      `json:"today_value"`
+	GoalMet      bool     `json:"goal_met"`
+	Current      int      `json:"current"`
+	Longest      int      `json:"longest"`
+	FreezeTokens int      `json:"freeze_tokens"`
+	FrozenDays   []string `json:"frozen_days"`
+	AtRisk       bool     `json:"at_risk"`
+}
+
+// StreakCandidate is a recently active user the reminder job should check.
+type StreakCandidate struct {
+	UserID          uint   `json:"user_id"`
+	Timezone        string `json:"timezone"`
+	DailyGoalType   string `json:"daily_goal_type"`
+	DailyGoalTarget int    `json:"daily_goal_target"`
 }
@@ -0,0 +1,82 @@
+package dto
+
+import "time"
+
+type MockExamSummary struct {
+	ID               uint      `json:"id"`
+	Title            string    `json:"title"`
+	Description      string    `json:"description"`
+	Tier             string    `json:"tier"`
+	Paper            string    `json:"paper"`
+	TimeLimitMinutes int       `json:"time_limit_minutes"`
+	QuestionCount    int       `json:"question_count"`
+	TotalMarks       int       `json:"total_marks"`
+	CreatedAt        time.Time `json:"created_at"`
+	CreatedBy        *uint     `json:"created_by"`
+	Attempts         int       `json:"attempts"`
+	BestGrade        *int      `json:"best_grade"`
+}
+
+type MockExamQuestionView struct {
+	ID                  uint   `json:"id"`
+	Position            int    `json:"position"`
+	Question            string `json:"question"`
+	Marks               int    `json:"marks"`
+	AssessmentObjective string `json:"assessment_objective"`
+	Topic               string `json:"topic"`
+}
+
+type GradeBoundary struct {
+	Grade   int `json:"grade"`
+	MinMark int `json:"min_mark"`
+}
+
+type MockExamDetail struct {
+	MockExamSummary
+	Questions  []MockExamQuestionView `json:"questions"`
+	Boundaries []GradeBoundary        `json:"boundaries"`
+}
+
+type MockExamAttempt struct {
+	ID          uint                   `json:"id"`
+	ExamID      uint                   `json:"exam_id"`
+	StartedAt   time.Time              `json:"started_at"`
+	Deadline    time.Time              `json:"deadline"`
+	SubmittedAt *time.Time             `json:"submitted_at"`
+	Questions   []MockExamQuestionView `json:"questions"`
+}
+
+type ObjectiveBreakdown struct {
+	Objective  string  `json:"objective"`
+	Awarded    int     `json:"awarded"`
+	Available  int     `json:"available"`
+	Percentage float64 `json:"percentage"`
+}
+
+type MockExamQuestionResult struct {
+	QuestionID          uint   `json:"question_id"`
+	Position            int    `json:"position"`
+	AssessmentObjective string `json:"assessment_objective"`
+	Topic               string `json:"topic"`
+	Answer              string `json:"answer"`
+	ExpectedAnswer      string `json:"expected_answer"`
+	MarksAwarded        int    `json:"marks_awarded"`
+	Marks               int    `json:"marks"`
+	Feedback            string `json:"feedback,omitempty"`
+}
+
+type MockExamResult struct {
+	AttemptID   uint                     `json:"attempt_id"`
+	ExamID      uint                     `json:"exam_id"`
+	Tier        string                   `json:"tier"`
+	Paper       string                   `json:"paper"`
+	SubmittedAt *time.Time               `json:"submitted_at"`
+	OverTime    bool                     `json:"over_time"`
+	RawMark     int                      `json:"raw_mark"`
+	TotalMarks  int                      `json:"total_marks"`
+	Percentage  float64                  `json:"percentage"`
+	Grade       int                      `json:"grade"`
+	GradeLabel  string                   `json:"grade_label"`
+	Objectives  []ObjectiveBreakdown     `json:"objectives"`
+	Questions   []MockExamQuestionResult `json:"questions"`
+}
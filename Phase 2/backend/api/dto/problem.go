@@ -0,0 +1,26 @@
+package dto
+
+import "time"
+
+type ProblemWithStats struct {
+	ID            uint       `json:"id"`
+	Topic         string     `json:"topic"`
+	Level         string     `json:"level"`
+	Title         string     `json:"title"`
+	Question      string     `json:"question"`
+	AnswerType    string     `json:"answer_type"`
+	CreatedAt     time.Time  `json:"created_at"`
+	Attempts      int64      `json:"attempts"`
+	Solved        bool       `json:"solved"`
+	LastAttemptAt *time.Time `json:"last_attempt_at"`
+	GaveUp        bool       `json:"gave_up"`
+}
+
+// ProblemAnswerResult grades a submission. The answer and solution are only
+// filled in once the problem is solved or given up on.
+type ProblemAnswerResult struct {
+	Correct  bool   `json:"correct"`
+	Answer   string `json:"answer,omitempty"`
+	Solution string `json:"solution,omitempty"`
+	Feedback string `json:"feedback,omitempty"`
+}
@@ -16,7 +16,11 @@ type QuizWithStats struct {
 	QuestionCount int              `json:"question_count"`
 	Score         int              `json:"score"`
 	CompletedAt   *time.Time       `json:"completed_at"`
-	Questions     []model.Question `json:"questions" gorm:"-"`
+	CreatedBy     *uint            `json:"created_by"`
+	AssignmentID  *uint            `json:"assignment_id,omitempty"`
+	DueAt         *time.Time       `json:"due_at,omitempty"`
+	Questions     []model.Question `json:"questions,omitempty" gorm:"-"`
+	Rank          float32          `json:"-"`
 }
 
 type QuizSubmission struct {
@@ -43,13 +47,9 @@ type AIQuizQuestion struct {
 	AnswerC  string `json:"answer_c"`
 	AnswerD  string `json:"answer_d"`
 	Topic    string `json:"topic"`
+	Solution string `json:"solution"`
 }
 
 type AIQuizResponse struct {
 	Questions []AIQuizQuestion `json:"questions"`
 }
-
-type TopicProficiencyFinal struct {
-	Topic string  `json:"topic"`
-	Score float64 `json:"score"`
-}
@@ -0,0 +1,20 @@
+package dto
+
+import "M-AI/api/model"
+
+type RecommendedResource struct {
+	model.Resource
+	Score   float64  `json:"score"`
+	Opened  bool     `json:"opened"`
+	Reasons []string `json:"reasons"`
+}
+
+type ResourceImportResult struct {
+	Created int      `json:"created"`
+	Skipped []string `json:"skipped"`
+}
+
+type LinkCheckSummary struct {
+	Checked int `json:"checked"`
+	Broken  int `json:"broken"`
+}
@@ -0,0 +1,21 @@
+package dto
+
+// SearchResult is a single hit. QuizID is set on questions so the client can
+// link to the quiz they belong to. Highlight and Snippet are HTML-escaped,
+// with matched terms wrapped in <mark> tags.
+type SearchResult struct {
+	Type      string  `json:"type"`
+	ID        uint    `json:"id"`
+	QuizID    *uint   `json:"quiz_id,omitempty"`
+	Title     string  `json:"title"`
+	Highlight string  `json:"highlight"`
+	Snippet   string  `json:"snippet"`
+	Rank      float64 `json:"rank"`
+	Total     int64   `json:"-"`
+}
+
+type SearchResults struct {
+	Query   string         `json:"query"`
+	Total   int64          `json:"total"`
+	Results []SearchResult `json:"results"`
+}
@@ -1,7 +1,109 @@
 package dto
 
+import "time"
+
 type AuthUserDTO struct {
 	ID    uint   `json:"id"`
 	Name  string `json:"name"`
 	Email string `json:"email"`
+	Role  string `json:"role"`
+}
+
+// SessionTokens are the credentials issued when a session starts or is
+// refreshed. The tokens travel in cookies, not in the response body.
+type SessionTokens struct {
+	SessionID        uint      `json:"session_id"`
+	AccessToken      string    `json:"-"`
+	AccessExpiresAt  time.Time `json:"access_expires_at"`
+	RefreshToken     string    `json:"-"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at"`
+	// RecoveryCodes are set when the user set up two-factor authentication
+	// while signing in, the only time the codes are shown.
+	RecoveryCodes []string `json:"recovery_codes,omitempty"`
+}
+
+// BearerTokens is SessionTokens with the tokens in the body, for clients that
+// keep their own tokens rather than cookies.
+type BearerTokens struct {
+	SessionTokens
+	TokenType    string `json:"token_type"`
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+}
+
+type Session struct {
+	ID         uint      `json:"id"`
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	Current    bool      `json:"current"`
+}
+
+type LoginAttempt struct {
+	ID        uint      `json:"id"`
+	Email     string    `json:"email"`
+	UserID    *uint     `json:"user_id"`
+	IP        string    `json:"ip"`
+	UserAgent string    `json:"user_agent"`
+	Outcome   string    `json:"outcome"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type APIKey struct {
+	ID         uint       `json:"id"`
+	Name       string     `json:"name"`
+	Prefix     string     `json:"prefix"`
+	Scopes     []string   `json:"scopes"`
+	CreatedAt  time.Time  `json:"created_at"`
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+}
+
+// CreatedAPIKey is returned once, when a key is created. The key itself
+// cannot be recovered later.
+type CreatedAPIKey struct {
+	APIKey
+	Key string `json:"key"`
+}
+
+type SSOProvider struct {
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+}
+
+// MFAChallenge is returned by a login that needs a second factor instead of
+// a session. The client sends MFAToken back with a code, or first enrols an
+// authenticator if SetupRequired is set.
+type MFAChallenge struct {
+	MFARequired   bool      `json:"mfa_required"`
+	SetupRequired bool      `json:"setup_required"`
+	MFAToken      string    `json:"mfa_token"`
+	ExpiresAt     time.Time `json:"expires_at"`
+}
+
+// TOTPEnrolment is what an authenticator app needs: the otpauth:// URI, as a
+// QR code image or typed in by hand as the secret.
+type TOTPEnrolment struct {
+	Secret     string `json:"secret"`
+	OTPAuthURL string `json:"otpauth_url"`
+	QRCode     string `json:"qr_code"`
+}
+
+type TwoFactorStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Required          bool       `json:"required"`
+	EnabledAt         *time.Time `json:"enabled_at"`
+	RecoveryCodesLeft int64      `json:"recovery_codes_left"`
+}
+
+type RecoveryCodes struct {
+	Codes []string `json:"recovery_codes"`
+}
+
+type TwoFactorPolicy struct {
+	Role      string    `json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -4,9 +4,16 @@ import (
 	"M-AI/api/repository"
 	"M-AI/api/router"
 	"M-AI/api/service"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/linkcheck"
+	"M-AI/pkg/mailer"
+	"M-AI/pkg/ratelimit"
+	"M-AI/pkg/scheduler"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
+	"log"
 	"time"
 )
 
@@ -18,23 +25,85 @@ func InitRouter(db *gorm.DB) *gin.Engine {
 	quizzesRepo := &repository.QuizRepository{}
 	quizLogRepo := &repository.QuizLogRepository{}
 	userLogRepo := &repository.UserLogRepository{}
+	masteryRepo := &repository.MasteryRepository{}
 	questionRepo := &repository.QuestionRepository{}
+	dailyRepo := &repository.DailyChallengeRepository{}
+	mockExamRepo := &repository.MockExamRepository{}
+	streakRepo := &repository.StudyStreakRepository{}
+	notificationRepo := &repository.NotificationRepository{}
+	achievementRepo := &repository.AchievementRepository{}
+	searchRepo := &repository.SearchRepository{}
+	classroomRepo := &repository.ClassroomRepository{}
+	assignmentRepo := &repository.AssignmentRepository{}
+	sessionRepo := &repository.SessionRepository{}
+	tokenRepo := &repository.UserTokenRepository{}
+	loginRepo := &repository.LoginAttemptRepository{}
+	apiKeyRepo := &repository.APIKeyRepository{}
+	identityRepo := &repository.UserIdentityRepository{}
+	twoFactorRepo := &repository.TwoFactorRepository{}
 
-	authService := service.NewAuthService(db, authRepo)
-	resourceService := service.NewResourceService(db, resourceRepo)
-	problemService := service.NewProblemService(db, problemRepo)
-	dashboardService := service.NewDashboardService(db, dashboardRepo)
+	mail, err := mailer.New(mailer.Config{
+		Driver:   config.AppConfig.Mail.Driver,
+		From:     config.AppConfig.Mail.From,
+		Host:     config.AppConfig.Mail.Host,
+		Port:     config.AppConfig.Mail.Port,
+		Username: config.AppConfig.Mail.Username,
+		Password: config.AppConfig.Mail.Password,
+		File:     config.AppConfig.Mail.File,
+	})
+	if err != nil {
+		log.Fatalf("Failed to set up mailer: %v", err)
+	}
+
+	authService := service.NewAuthService(db, authRepo, sessionRepo, tokenRepo, loginRepo, apiKeyRepo, twoFactorRepo, mail)
+	auth.UseAPIKeys(authService)
+	ssoService := service.NewSSOService(db, authRepo, identityRepo, authService, config.AppConfig.OIDC)
+	resourceService := service.NewResourceService(db, resourceRepo, masteryRepo, linkcheck.New(10*time.Second))
 	aiService := service.NewOpenAIService()
-	quizzesService := service.NewQuizService(db, quizzesRepo, quizLogRepo, userLogRepo, questionRepo, aiService)
+	streakService := service.NewStudyStreakService(db, streakRepo, authRepo, notificationRepo)
+	achievementService := service.NewAchievementService(db, achievementRepo, streakService)
+	// aiLimiter throttles each user's OpenAI calls: generating problems and
+	// quizzes, and grading answers the local checks cannot.
+	aiLimiter := ratelimit.New(10, time.Minute, 5)
+	problemService := service.NewProblemService(db, problemRepo, userLogRepo, aiService, aiLimiter, achievementService)
+	dashboardService := service.NewDashboardService(db, dashboardRepo, masteryRepo)
+	quizzesService := service.NewQuizService(db, quizzesRepo, quizLogRepo, userLogRepo, questionRepo, masteryRepo, assignmentRepo, aiService, achievementService)
+	dailyService := service.NewDailyChallengeService(db, dailyRepo, problemRepo, userLogRepo, problemService, aiService, achievementService)
+	mockExamService := service.NewMockExamService(db, mockExamRepo, aiService, aiLimiter)
+	searchService := service.NewSearchService(db, searchRepo)
+	classroomService := service.NewClassroomService(db, classroomRepo, dashboardRepo, authRepo)
+	assignmentService := service.NewAssignmentService(db, assignmentRepo, quizzesRepo, questionRepo, classroomService)
 
-	authRouter := router.NewAuthRouter(authService)
+	authRouter := router.NewAuthRouter(authService, ssoService)
 	resourceRouter := router.NewResourceRouter(resourceService)
-	openAIService := service.NewOpenAIService()
-	problemRouter := router.NewProblemRouter(problemService, openAIService)
-	dashboardRouter := router.NewDashboardRouter(dashboardService)
+	problemRouter := router.NewProblemRouter(problemService, aiService)
+	dashboardRouter := router.NewDashboardRouter(dashboardService, streakService, classroomService)
 	quizzesRouter := router.NewQuizRouter(quizzesService)
+	dailyRouter := router.NewDailyChallengeRouter(dailyService)
+	mockExamRouter := router.NewMockExamRouter(mockExamService)
+	achievementRouter := router.NewAchievementRouter(achievementService)
+	searchRouter := router.NewSearchRouter(searchService)
+	classroomRouter := router.NewClassroomRouter(classroomService)
+	assignmentRouter := router.NewAssignmentRouter(assignmentService)
+
+	if err := achievementService.SyncDefinitions(config.AppConfig.Achievements); err != nil {
+		log.Printf("Failed to sync achievements from config: %v", err)
+	}
+
+	jobs := scheduler.New()
+	jobs.Daily("daily-challenge", dailyService.EnsureTodayChallenges)
+	jobs.Every("streak-reminders", time.Hour, streakService.QueueStreakReminders)
+	jobs.Every("link-check", 6*time.Hour, resourceService.CheckLinks)
+	jobs.Every("session-cleanup", 24*time.Hour, authService.CleanupSessions)
+	jobs.Every("login-audit-cleanup", 24*time.Hour, authService.CleanupLoginAttempts)
+	jobs.Start()
 
 	r := gin.Default()
+	// Rate limits and the login audit key on the client's IP, so only
+	// configured proxies may set it through X-Forwarded-For.
+	if err := r.SetTrustedProxies(config.AppConfig.Server.TrustedProxies); err != nil {
+		log.Fatalf("Invalid trusted proxies: %v", err)
+	}
 
 	r.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
@@ -50,13 +119,30 @@ func InitRouter(db *gorm.DB) *gin.Engine {
 		c.Next()
 	})
 
-	apiV1 := r.Group("/api/v1")
+	// Every request draws from its user's bucket, or its IP's when signed
+	// out. The auth routes are also limited per IP and route to slow down
+	// credential guessing, and the problem and quiz generation routes, which
+	// call OpenAI, per user and route.
+	identify := func(c *gin.Context) (uint, bool) {
+		return auth.Identify(c, config.AppConfig.Auth.SecretKey)
+	}
+	apiLimit := ratelimit.New(300, time.Minute, 60).Middleware(ratelimit.User(identify))
+	authLimit := ratelimit.New(30, time.Minute, 10).Middleware(ratelimit.IP, ratelimit.Route)
+	aiLimit := aiLimiter.Middleware(ratelimit.User(identify), ratelimit.Route)
+
+	apiV1 := r.Group("/api/v1", apiLimit)
 	{
-		authRouter.RegisterRoutes(apiV1)
+		authRouter.RegisterRoutes(apiV1.Group("", authLimit))
 		resourceRouter.RegisterRoutes(apiV1)
-		problemRouter.RegisterRoutes(apiV1)
+		problemRouter.RegisterRoutes(apiV1, aiLimit)
 		dashboardRouter.RegisterRoutes(apiV1)
-		quizzesRouter.RegisterRoutes(apiV1)
+		quizzesRouter.RegisterRoutes(apiV1, aiLimit)
+		dailyRouter.RegisterRoutes(apiV1)
+		mockExamRouter.RegisterRoutes(apiV1)
+		achievementRouter.RegisterRoutes(apiV1)
+		searchRouter.RegisterRoutes(apiV1)
+		classroomRouter.RegisterRoutes(apiV1)
+		assignmentRouter.RegisterRoutes(apiV1)
 	}
 
 	return r
@@ -0,0 +1,30 @@
+package model
+
+import "gorm.io/gorm"
+
+type Achievement struct {
+	gorm.Model
+	Code        string `json:"code"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Icon        string `json:"icon"`
+	Event       string `json:"event"`
+	Metric      string `json:"metric"`
+	Threshold   int    `json:"threshold"`
+	Topic       string `json:"topic"`
+	Active      bool   `gorm:"default:true" json:"active"`
+}
+
+func (a Achievement) TableName() string {
+	return "achievement"
+}
+
+type UserAchievement struct {
+	gorm.Model
+	UserID        uint `json:"user_id"`
+	AchievementID uint `json:"achievement_id"`
+}
+
+func (u UserAchievement) TableName() string {
+	return "user_achievement"
+}
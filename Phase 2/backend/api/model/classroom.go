@@ -0,0 +1,31 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type Classroom struct {
+	gorm.Model
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	OwnerID     uint   `json:"owner_id"`
+	JoinCode    string `json:"join_code"`
+}
+
+func (c Classroom) TableName() string {
+	return "classroom"
+}
+
+// ClassroomMember is a user's place in a class, either as a teacher or as a
+// student.
+type ClassroomMember struct {
+	ClassroomID uint      `gorm:"primaryKey" json:"classroom_id"`
+	UserID      uint      `gorm:"primaryKey" json:"user_id"`
+	Role        string    `json:"role"`
+	JoinedAt    time.Time `gorm:"default:now()" json:"joined_at"`
+}
+
+func (m ClassroomMember) TableName() string {
+	return "classroom_member"
+}
@@ -0,0 +1,40 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type DailyChallenge struct {
+	gorm.Model
+	ChallengeDate time.Time `gorm:"type:date" json:"challenge_date"`
+	Level         string    `json:"level"`
+	ProblemID     uint      `json:"problem_id"`
+}
+
+func (d DailyChallenge) TableName() string {
+	return "daily_challenge"
+}
+
+type DailyChallengeAttempt struct {
+	gorm.Model
+	ChallengeID uint `json:"challenge_id"`
+	UserID      uint `json:"user_id"`
+	Correct     bool `json:"correct"`
+}
+
+func (d DailyChallengeAttempt) TableName() string {
+	return "daily_challenge_attempt"
+}
+
+type DailyStreak struct {
+	UserID          uint       `gorm:"primaryKey" json:"user_id"`
+	Current         int        `json:"current"`
+	Longest         int        `json:"longest"`
+	LastAttemptDate *time.Time `gorm:"type:date" json:"last_attempt_date"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (d DailyStreak) TableName() string {
+	return "daily_streak"
+}
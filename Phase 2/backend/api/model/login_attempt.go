@@ -0,0 +1,16 @@
+package model
+
+import "gorm.io/gorm"
+
+type LoginAttempt struct {
+	gorm.Model
+	Email     string `json:"email"`
+	UserID    *uint  `json:"user_id"`
+	IP        string `gorm:"column:ip" json:"ip"`
+	UserAgent string `json:"user_agent"`
+	Outcome   string `json:"outcome"`
+}
+
+func (a LoginAttempt) TableName() string {
+	return "login_attempt"
+}
@@ -0,0 +1,80 @@
+package model
+
+import (
+	"M-AI/api/constants"
+	"gorm.io/gorm"
+	"time"
+)
+
+type MockExam struct {
+	gorm.Model
+	Title            string `json:"title"`
+	Description      string `json:"description"`
+	Tier             string `json:"tier"`
+	Paper            string `json:"paper"`
+	TimeLimitMinutes int    `json:"time_limit_minutes"`
+	CreatedBy        *uint  `json:"created_by"`
+}
+
+func (m MockExam) TableName() string {
+	return "mock_exam"
+}
+
+type MockExamQuestion struct {
+	gorm.Model
+	ExamID              uint                `json:"exam_id"`
+	Position            int                 `json:"position"`
+	Question            string              `json:"question"`
+	Answer              string              `json:"answer"`
+	AnswerType          string              `json:"answer_type"`
+	Tolerance           float64             `json:"tolerance"`
+	MarkScheme          string              `json:"mark_scheme"`
+	Marks               int                 `json:"marks"`
+	AssessmentObjective string              `json:"assessment_objective"`
+	Topic               constants.TopicEnum `gorm:"type:topic_enum" json:"topic"`
+}
+
+func (m MockExamQuestion) TableName() string {
+	return "mock_exam_question"
+}
+
+type MockExamBoundary struct {
+	gorm.Model
+	ExamID  uint `json:"exam_id"`
+	Grade   int  `json:"grade"`
+	MinMark int  `json:"min_mark"`
+}
+
+func (m MockExamBoundary) TableName() string {
+	return "mock_exam_boundary"
+}
+
+type MockExamAttempt struct {
+	gorm.Model
+	ExamID      uint       `json:"exam_id"`
+	UserID      uint       `json:"user_id"`
+	StartedAt   time.Time  `json:"started_at"`
+	Deadline    time.Time  `json:"deadline"`
+	SubmittedAt *time.Time `json:"submitted_at"`
+	RawMark     int        `json:"raw_mark"`
+	TotalMarks  int        `json:"total_marks"`
+	Grade       int        `json:"grade"`
+	OverTime    bool       `json:"over_time"`
+}
+
+func (m MockExamAttempt) TableName() string {
+	return "mock_exam_attempt"
+}
+
+type MockExamAnswer struct {
+	gorm.Model
+	AttemptID    uint   `json:"attempt_id"`
+	QuestionID   uint   `json:"question_id"`
+	Answer       string `json:"answer"`
+	MarksAwarded int    `json:"marks_awarded"`
+	Feedback     string `json:"feedback"`
+}
+
+func (m MockExamAnswer) TableName() string {
+	return "mock_exam_answer"
+}
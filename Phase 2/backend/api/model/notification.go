@@ -0,0 +1,20 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type Notification struct {
+	gorm.Model
+	UserID    uint       `json:"user_id"`
+	Kind      string     `json:"kind"`
+	Title     string     `json:"title"`
+	Body      string     `json:"body"`
+	DedupeKey string     `json:"dedupe_key"`
+	SentAt    *time.Time `json:"sent_at"`
+}
+
+func (n Notification) TableName() string {
+	return "notification"
+}
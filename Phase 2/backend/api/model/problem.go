@@ -7,9 +7,14 @@ import (
 
 type Problem struct {
 	gorm.Model
-	Topic    constants.TopicEnum `gorm:"type:topic_enum" json:"topic"`
-	Title    string              `json:"title"`
-	Question string              `json:"question"`
+	Topic      constants.TopicEnum `gorm:"type:topic_enum" json:"topic"`
+	Level      string              `json:"level"`
+	Title      string              `json:"title"`
+	Question   string              `json:"question"`
+	Answer     string              `json:"answer"`
+	AnswerType string              `json:"answer_type"`
+	Tolerance  float64             `json:"tolerance"`
+	Solution   string              `json:"solution"`
 }
 
 func (p Problem) TableName() string {
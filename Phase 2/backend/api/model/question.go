@@ -15,6 +15,7 @@ type Question struct {
 	AnswerC  string              `json:"answer_c" gorm:"column:answerc"`
 	AnswerD  string              `json:"answer_d" gorm:"column:answerd"`
 	Topic    constants.TopicEnum `json:"topic"`
+	Solution string              `json:"solution"`
 }
 
 func (q Question) TableName() string {
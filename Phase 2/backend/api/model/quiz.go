@@ -9,6 +9,7 @@ type Quiz struct {
 	Title       string `json:"title"`
 	Description string `json:"description"`
 	Level       string `json:"level"`
+	CreatedBy   *uint  `json:"created_by"`
 }
 
 func (q Quiz) TableName() string {
@@ -0,0 +1,20 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type QuizAssignment struct {
+	gorm.Model
+	ClassroomID uint      `json:"classroom_id"`
+	QuizID      uint      `json:"quiz_id"`
+	AssignedBy  uint      `json:"assigned_by"`
+	OpenAt      time.Time `json:"open_at"`
+	DueAt       time.Time `json:"due_at"`
+	MaxAttempts *int      `json:"max_attempts"`
+}
+
+func (a QuizAssignment) TableName() string {
+	return "quiz_assignment"
+}
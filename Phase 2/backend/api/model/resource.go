@@ -3,6 +3,7 @@ package model
 import (
 	"github.com/lib/pq"
 	"gorm.io/gorm"
+	"time"
 )
 
 type Resource struct {
@@ -13,4 +14,15 @@ type Resource struct {
 	Level           string         `json:"level"`
 	Description     string         `json:"description"`
 	LinkDescription string         `json:"link_description"`
+	LinkStatus      int            `json:"link_status"`
+	LinkError       string         `json:"link_error"`
+	LinkFailures    int            `json:"link_failures"`
+	LinkBroken      bool           `json:"link_broken"`
+	LinkCheckedAt   *time.Time     `json:"link_checked_at"`
+	CreatedBy       *uint          `json:"created_by"`
+
+	// Rank is the search relevance, only read when listing with a search.
+	Rank float32 `gorm:"->" json:"-"`
+	// State is filled in for the requesting user on student-facing lists.
+	State *ResourceState `gorm:"-" json:"state,omitempty"`
 }
@@ -0,0 +1,38 @@
+package model
+
+import "time"
+
+type ResourceActivity struct {
+	UserID        uint       `gorm:"primaryKey" json:"user_id"`
+	ResourceID    uint       `gorm:"primaryKey" json:"resource_id"`
+	OpenCount     int        `json:"open_count"`
+	FirstOpenedAt *time.Time `json:"first_opened_at"`
+	LastOpenedAt  *time.Time `json:"last_opened_at"`
+	SavedAt       *time.Time `json:"saved_at"`
+	CompletedAt   *time.Time `json:"completed_at"`
+}
+
+func (r ResourceActivity) TableName() string {
+	return "resource_activity"
+}
+
+// ResourceState is the requesting user's progress through a resource.
+type ResourceState struct {
+	Saved       bool       `json:"saved"`
+	SavedAt     *time.Time `json:"saved_at"`
+	Opened      bool       `json:"opened"`
+	OpenedAt    *time.Time `json:"opened_at"`
+	Completed   bool       `json:"completed"`
+	CompletedAt *time.Time `json:"completed_at"`
+}
+
+func (r ResourceActivity) State() *ResourceState {
+	return &ResourceState{
+		Saved:       r.SavedAt != nil,
+		SavedAt:     r.SavedAt,
+		Opened:      r.FirstOpenedAt != nil,
+		OpenedAt:    r.LastOpenedAt,
+		Completed:   r.CompletedAt != nil,
+		CompletedAt: r.CompletedAt,
+	}
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"M-AI/api/constants"
+	"time"
+)
+
+type TopicMastery struct {
+	UserID        uint                `gorm:"primaryKey" json:"user_id"`
+	Topic         constants.TopicEnum `gorm:"primaryKey" json:"topic"`
+	Probability   float64             `json:"probability"`
+	Attempts      int                 `json:"attempts"`
+	LastAttemptAt *time.Time          `json:"last_attempt_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+}
+
+func (t TopicMastery) TableName() string {
+	return "topic_mastery"
+}
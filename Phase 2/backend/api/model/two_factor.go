@@ -0,0 +1,41 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserTOTP struct {
+	UserID       uint       `gorm:"primaryKey" json:"user_id"`
+	Secret       string     `json:"-"`
+	ConfirmedAt  *time.Time `json:"confirmed_at"`
+	LastUsedStep int64      `json:"-"`
+	CreatedAt    time.Time  `json:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+func (t UserTOTP) TableName() string {
+	return "user_totp"
+}
+
+type RecoveryCode struct {
+	gorm.Model
+	UserID   uint       `json:"user_id"`
+	CodeHash string     `json:"-"`
+	UsedAt   *time.Time `json:"used_at"`
+}
+
+func (c RecoveryCode) TableName() string {
+	return "recovery_code"
+}
+
+type TwoFactorPolicy struct {
+	Role      string    `gorm:"primaryKey" json:"role"`
+	Required  bool      `json:"required"`
+	UpdatedAt time.Time `json:"updated_at"`
+	UpdatedBy *uint     `json:"updated_by"`
+}
+
+func (p TwoFactorPolicy) TableName() string {
+	return "two_factor_policy"
+}
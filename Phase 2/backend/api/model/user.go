@@ -1,10 +1,18 @@
 package model
 
-import "gorm.io/gorm"
+import (
+	"gorm.io/gorm"
+	"time"
+)
 
 type User struct {
 	gorm.Model
-	Name     string `json:"name"`
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Name            string     `json:"name"`
+	Email           string     `json:"email"`
+	Password        string     `json:"password"`
+	Timezone        string     `gorm:"default:UTC" json:"timezone"`
+	DailyGoalType   string     `gorm:"default:questions" json:"daily_goal_type"`
+	DailyGoalTarget int        `gorm:"default:10" json:"daily_goal_target"`
+	Role            string     `gorm:"default:student" json:"role"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
 }
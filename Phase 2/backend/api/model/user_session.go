@@ -0,0 +1,22 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserSession struct {
+	gorm.Model
+	UserID       uint       `json:"user_id"`
+	TokenHash    string     `json:"-"`
+	PreviousHash *string    `json:"-"`
+	UserAgent    string     `json:"user_agent"`
+	IP           string     `gorm:"column:ip" json:"ip"`
+	LastUsedAt   time.Time  `json:"last_used_at"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at"`
+}
+
+func (s UserSession) TableName() string {
+	return "user_session"
+}
@@ -0,0 +1,27 @@
+package model
+
+import "time"
+
+type UserTopicStats struct {
+	UserID         uint       `gorm:"primaryKey" json:"user_id"`
+	Topic          string     `gorm:"primaryKey" json:"topic"`
+	Attempts       int        `json:"attempts"`
+	MarksAwarded   int        `json:"marks_awarded"`
+	MarksAvailable int        `json:"marks_available"`
+	LastAttemptAt  *time.Time `json:"last_attempt_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+func (u UserTopicStats) TableName() string {
+	return "user_topic_stats"
+}
+
+type UserStats struct {
+	UserID           uint      `gorm:"primaryKey" json:"user_id"`
+	QuizzesCompleted int       `json:"quizzes_completed"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
+
+func (u UserStats) TableName() string {
+	return "user_stats"
+}
@@ -0,0 +1,19 @@
+package model
+
+import (
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserToken struct {
+	gorm.Model
+	UserID    uint       `json:"user_id"`
+	Purpose   string     `json:"purpose"`
+	TokenHash string     `json:"-"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at"`
+}
+
+func (t UserToken) TableName() string {
+	return "user_token"
+}
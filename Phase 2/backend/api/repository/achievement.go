@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AchievementRepository struct{}
+
+func (r *AchievementRepository) ListActive(db *gorm.DB) ([]model.Achievement, error) {
+	var achievements []model.Achievement
+	err := db.Where("active").Order("id").Find(&achievements).Error
+	return achievements, err
+}
+
+func (r *AchievementRepository) ListForUser(db *gorm.DB, userID uint) ([]dto.Achievement, error) {
+	var result []dto.Achievement
+	err := db.Raw(`
+		SELECT
+			a.code,
+			a.name,
+			a.description,
+			a.icon,
+			ua.id IS NOT NULL AS earned,
+			ua.created_at AS awarded_at
+		FROM achievement a
+		LEFT JOIN user_achievement ua ON ua.achievement_id = a.id AND ua.user_id = ? AND ua.deleted_at IS NULL
+		WHERE a.deleted_at IS NULL AND (a.active OR ua.id IS NOT NULL)
+		ORDER BY earned DESC, ua.created_at DESC, a.id
+	`, userID).Scan(&result).Error
+	return result, err
+}
+
+func (r *AchievementRepository) GetEarnedIDs(db *gorm.DB, userID uint) (map[uint]bool, error) {
+	var ids []uint
+	err := db.Model(&model.UserAchievement{}).
+		Where("user_id = ?", userID).
+		Pluck("achievement_id", &ids).Error
+	earned := make(map[uint]bool, len(ids))
+	for _, id := range ids {
+		earned[id] = true
+	}
+	return earned, err
+}
+
+// Award records the badge for the user. Awarding twice is a no-op.
+func (r *AchievementRepository) Award(db *gorm.DB, userID, achievementID uint) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&model.UserAchievement{
+		UserID:        userID,
+		AchievementID: achievementID,
+	}).Error
+}
+
+// UpsertDefinition creates the achievement or updates the one with the same
+// code.
+func (r *AchievementRepository) UpsertDefinition(db *gorm.DB, a *model.Achievement) error {
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "code"}},
+		DoUpdates: clause.AssignmentColumns([]string{"name", "description", "icon", "event", "metric", "threshold", "topic", "active", "updated_at"}),
+	}).Create(a).Error
+}
+
+func (r *AchievementRepository) CountQuizzesCompleted(db *gorm.DB, userID uint) (int, error) {
+	var count int
+	err := db.Raw(`SELECT COALESCE((SELECT quizzes_completed FROM user_stats WHERE user_id = ?), 0)`, userID).Scan(&count).Error
+	return count, err
+}
+
+func (r *AchievementRepository) CountCorrectAnswers(db *gorm.DB, userID uint, topic string) (int, error) {
+	var count int
+	err := db.Raw(`
+		SELECT COUNT(*)
+		FROM attempt
+		WHERE user_id = ? AND correct AND (? = '' OR topic = ?)
+	`, userID, topic, topic).Scan(&count).Error
+	return count, err
+}
+
+// CorrectStreak counts the user's answers since their last wrong one,
+// optionally within a topic. Answers are ordered by (created_at, source, id),
+// since a quiz's answers all share one timestamp.
+func (r *AchievementRepository) CorrectStreak(db *gorm.DB, userID uint, topic string) (int, error) {
+	var count int
+	err := db.Raw(`
+		WITH answers AS (
+			SELECT correct, created_at, source, id
+			FROM attempt
+			WHERE user_id = ? AND (? = '' OR topic = ?)
+		),
+		last_wrong AS (
+			SELECT created_at, source, id
+			FROM answers
+			WHERE NOT correct
+			ORDER BY created_at DESC, source DESC, id DESC
+			LIMIT 1
+		)
+		SELECT COUNT(*)
+		FROM answers a
+		WHERE NOT EXISTS (SELECT 1 FROM last_wrong)
+			OR (a.created_at, a.source, a.id) > (SELECT created_at, source, id FROM last_wrong)
+	`, userID, topic, topic).Scan(&count).Error
+	return count, err
+}
+
+func (r *AchievementRepository) CountProblemsSolved(db *gorm.DB, userID uint) (int, error) {
+	var count int
+	err := db.Raw(`
+		SELECT COUNT(DISTINCT problem_id)
+		FROM attempt
+		WHERE user_id = ? AND source = 'problem' AND correct
+	`, userID).Scan(&count).Error
+	return count, err
+}
@@ -0,0 +1,84 @@
+package repository
+
+import (
+	"M-AI/api/model"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+	"time"
+)
+
+type APIKeyRepository struct{}
+
+func (r *APIKeyRepository) Create(db *gorm.DB, key *model.APIKey) error {
+	return db.Create(key).Error
+}
+
+// activeKeys selects keys that are neither revoked nor expired.
+func activeKeys(db *gorm.DB, now time.Time) *gorm.DB {
+	return db.Where("revoked_at IS NULL AND (expires_at IS NULL OR expires_at > ?)", now)
+}
+
+func (r *APIKeyRepository) ListActive(db *gorm.DB, userID uint, now time.Time) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := activeKeys(db, now).
+		Where("user_id = ?", userID).
+		Order("created_at DESC").
+		Find(&keys).Error
+	return keys, err
+}
+
+func (r *APIKeyRepository) CountActive(db *gorm.DB, userID uint, now time.Time) (int64, error) {
+	var count int64
+	err := activeKeys(db.Model(&model.APIKey{}), now).
+		Where("user_id = ?", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// Revoke disables one of the user's keys and reports whether it was active.
+func (r *APIKeyRepository) Revoke(db *gorm.DB, userID, keyID uint, now time.Time) (bool, error) {
+	res := db.Model(&model.APIKey{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", keyID, userID).
+		Update("revoked_at", now)
+	return res.RowsAffected > 0, res.Error
+}
+
+// RevokeAll disables every key of the user.
+func (r *APIKeyRepository) RevokeAll(db *gorm.DB, userID uint, now time.Time) error {
+	return db.Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error
+}
+
+type APIKeyOwner struct {
+	KeyID  uint
+	UserID uint
+	Role   string
+	Scopes pq.StringArray
+}
+
+// GetOwner looks up an active key by hash along with its owner's current
+// role. It fails with gorm.ErrRecordNotFound for unknown, revoked and expired
+// keys, and keys of deleted users.
+func (r *APIKeyRepository) GetOwner(db *gorm.DB, hash string, now time.Time) (APIKeyOwner, error) {
+	var owner APIKeyOwner
+	res := db.Raw(`
+		SELECT k.id AS key_id, k.user_id, u.role, k.scopes
+		FROM api_key k
+		JOIN users u ON u.id = k.user_id AND u.deleted_at IS NULL
+		WHERE k.key_hash = ? AND k.revoked_at IS NULL AND k.deleted_at IS NULL
+			AND (k.expires_at IS NULL OR k.expires_at > ?)
+	`, hash, now).Scan(&owner)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return owner, gorm.ErrRecordNotFound
+	}
+	return owner, res.Error
+}
+
+// Touch records that a key was used. To spare a write on every request it
+// only does so once a minute.
+func (r *APIKeyRepository) Touch(db *gorm.DB, keyID uint, now time.Time) error {
+	return db.Model(&model.APIKey{}).
+		Where("id = ? AND (last_used_at IS NULL OR last_used_at < ?)", keyID, now.Add(-time.Minute)).
+		Update("last_used_at", now).Error
+}
@@ -0,0 +1,185 @@
+package repository
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"gorm.io/gorm"
+	"time"
+)
+
+type AssignmentRepository struct{}
+
+func (r *AssignmentRepository) CreateAssignment(db *gorm.DB, assignment *model.QuizAssignment) error {
+	return db.Create(assignment).Error
+}
+
+// GetAssignment returns an assignment of classID. It fails with
+// gorm.ErrRecordNotFound if the assignment belongs to another class.
+func (r *AssignmentRepository) GetAssignment(db *gorm.DB, classID, assignmentID uint) (model.QuizAssignment, error) {
+	var assignment model.QuizAssignment
+	err := db.Where("classroom_id = ?", classID).First(&assignment, assignmentID).Error
+	return assignment, err
+}
+
+func (r *AssignmentRepository) UpdateAssignment(db *gorm.DB, assignment *model.QuizAssignment) error {
+	return db.Model(assignment).
+		Select("open_at", "due_at", "max_attempts").
+		Updates(assignment).Error
+}
+
+func (r *AssignmentRepository) DeleteAssignment(db *gorm.DB, assignmentID uint) error {
+	return db.Delete(&model.QuizAssignment{}, assignmentID).Error
+}
+
+const assignmentQuery = `
+	SELECT
+		a.id,
+		a.classroom_id,
+		c.name AS classroom_name,
+		a.quiz_id,
+		q.title AS quiz_title,
+		a.assigned_by,
+		a.open_at,
+		a.due_at,
+		a.max_attempts,
+		a.created_at
+	FROM quiz_assignment a
+	JOIN classroom c ON c.id = a.classroom_id AND c.deleted_at IS NULL
+	JOIN quiz q ON q.id = a.quiz_id
+	WHERE a.deleted_at IS NULL
+`
+
+var assignmentListSpec = ListSpec[dto.QuizAssignment]{
+	Sorts: map[string]SortField[dto.QuizAssignment]{
+		"due_at":     {Column: "due_at", Cast: "timestamptz", Value: func(a dto.QuizAssignment) string { return timeValue(a.DueAt) }},
+		"open_at":    {Column: "open_at", Cast: "timestamptz", Desc: true, Value: func(a dto.QuizAssignment) string { return timeValue(a.OpenAt) }},
+		"created_at": {Column: "created_at", Cast: "timestamptz", Desc: true, Value: func(a dto.QuizAssignment) string { return timeValue(a.CreatedAt) }},
+	},
+	DefaultSort: "due_at",
+	Filters: FilterColumns{
+		Date:  "due_at",
+		Owner: "assigned_by",
+	},
+	ID: func(a dto.QuizAssignment) uint { return a.ID },
+}
+
+// ListAssignments returns a page of a class's assignments. Unless
+// includeUnopened is set, assignments that have not opened yet are left out.
+func (r *AssignmentRepository) ListAssignments(db *gorm.DB, classID, userID uint, includeUnopened bool, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[dto.QuizAssignment], error) {
+	query := assignmentQuery + " AND a.classroom_id = ?"
+	args := []interface{}{classID}
+	if !includeUnopened {
+		query += " AND a.open_at <= NOW()"
+	}
+	return Paginate(db, query, args, assignmentListSpec, userID, filter, page)
+}
+
+func (r *AssignmentRepository) GetAssignmentView(db *gorm.DB, assignmentID uint) (dto.QuizAssignment, error) {
+	var assignment dto.QuizAssignment
+	res := db.Raw(assignmentQuery+" AND a.id = ?", assignmentID).Scan(&assignment)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return assignment, gorm.ErrRecordNotFound
+	}
+	return assignment, res.Error
+}
+
+// GetSubmissions reads each enrolled student's quiz submissions on the given
+// assignments from quiz_log, keyed by assignment ID. Students with no
+// submissions are included with zero attempts. If userID is non-zero only
+// that student is read.
+func (r *AssignmentRepository) GetSubmissions(db *gorm.DB, assignmentIDs []uint, userID uint) (map[uint][]dto.AssignmentSubmission, error) {
+	type row struct {
+		AssignmentID uint
+		dto.AssignmentSubmission
+	}
+	var rows []row
+
+	query := `
+		SELECT
+			a.id AS assignment_id,
+			u.id AS user_id,
+			u.name,
+			u.email,
+			COUNT(l.id) AS attempts,
+			MAX(l.score) AS best_score,
+			MIN(l.created_at) AS first_submitted_at,
+			MAX(l.created_at) AS last_submitted_at
+		FROM quiz_assignment a
+		JOIN classroom_member m ON m.classroom_id = a.classroom_id AND m.role = ?
+		JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+		LEFT JOIN quiz_log l ON l.quiz_id = a.quiz_id AND l.user_id = m.user_id
+			AND l.created_at >= a.open_at AND l.deleted_at IS NULL
+		WHERE a.id IN ?
+	`
+	args := []interface{}{constants.ClassStudent, assignmentIDs}
+	if userID != 0 {
+		query += " AND m.user_id = ?"
+		args = append(args, userID)
+	}
+	query += `
+		GROUP BY a.id, u.id
+		ORDER BY u.name, u.id
+	`
+
+	if err := db.Raw(query, args...).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+	result := make(map[uint][]dto.AssignmentSubmission)
+	for _, row := range rows {
+		result[row.AssignmentID] = append(result[row.AssignmentID], row.AssignmentSubmission)
+	}
+	return result, nil
+}
+
+// GetLatestAnswers returns each enrolled student's latest answer to each of
+// the assignment's questions since it opened.
+func (r *AssignmentRepository) GetLatestAnswers(db *gorm.DB, assignment model.QuizAssignment) ([]dto.AssignmentAnswer, error) {
+	var answers []dto.AssignmentAnswer
+	err := db.Raw(`
+		SELECT DISTINCT ON (ul.user_id, ul.question_id)
+			ul.user_id,
+			ul.question_id,
+			ul.correct_answer AS correct
+		FROM user_log ul
+		JOIN classroom_member m ON m.user_id = ul.user_id AND m.classroom_id = ? AND m.role = ?
+		JOIN question q ON q.id = ul.question_id AND q.quiz_id = ?
+		WHERE ul.from_quiz AND ul.created_at >= ? AND ul.deleted_at IS NULL
+		ORDER BY ul.user_id, ul.question_id, ul.created_at DESC
+	`, assignment.ClassroomID, constants.ClassStudent, assignment.QuizID, assignment.OpenAt).Scan(&answers).Error
+	return answers, err
+}
+
+// LockAttempts holds a lock on the user's attempts at quizID until the
+// transaction ends, so concurrent submissions are counted against the attempt
+// limit one at a time.
+func (r *AssignmentRepository) LockAttempts(db *gorm.DB, userID, quizID uint) error {
+	return db.Exec("SELECT pg_advisory_xact_lock(?::int, ?::int)", userID, quizID).Error
+}
+
+// GetExhaustedAssignment returns an open assignment of quizID on which the
+// student has already used every attempt, or gorm.ErrRecordNotFound if there
+// is none.
+func (r *AssignmentRepository) GetExhaustedAssignment(db *gorm.DB, userID, quizID uint, now time.Time) (model.QuizAssignment, error) {
+	var assignment model.QuizAssignment
+	res := db.Raw(`
+		SELECT a.*
+		FROM quiz_assignment a
+		JOIN classroom c ON c.id = a.classroom_id AND c.deleted_at IS NULL
+		JOIN classroom_member m ON m.classroom_id = a.classroom_id AND m.user_id = ? AND m.role = ?
+		WHERE a.quiz_id = ? AND a.deleted_at IS NULL AND a.open_at <= ? AND a.max_attempts IS NOT NULL
+			AND (
+				SELECT COUNT(*)
+				FROM quiz_log l
+				WHERE l.quiz_id = a.quiz_id AND l.user_id = m.user_id
+					AND l.created_at >= a.open_at AND l.deleted_at IS NULL
+			) >= a.max_attempts
+		ORDER BY a.due_at
+		LIMIT 1
+	`, userID, constants.ClassStudent, quizID, now).Scan(&assignment)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return assignment, gorm.ErrRecordNotFound
+	}
+	return assignment, res.Error
+}
@@ -4,6 +4,7 @@ import (
 	"M-AI/api/model"
 	"errors"
 	"gorm.io/gorm"
+	"time"
 )
 
 type AuthRepository struct {
@@ -29,10 +30,18 @@ func (r *AuthRepository) GetUserByEmail(db *gorm.DB, email string) (model.User,
 	return user, nil
 }
 
+// FindUserByEmailFold looks a user up by email ignoring case, for addresses
+// that come from elsewhere, such as a sign-in provider.
+func (r *AuthRepository) FindUserByEmailFold(db *gorm.DB, email string) (model.User, error) {
+	var user model.User
+	err := db.Where("LOWER(email) = LOWER(?) AND deleted_at IS NULL", email).Order("id").First(&user).Error
+	return user, err
+}
+
 func (r *AuthRepository) GetUserByID(db *gorm.DB, userID uint) (model.User, error) {
 	var userDTO model.User
 	err := db.Model(&model.User{}).
-		Select("id, name, email, password").
+		Select("id, name, email, password, timezone, daily_goal_type, daily_goal_target, role, email_verified_at").
 		Where("id = ? AND deleted_at IS NULL", userID).
 		First(&userDTO).Error
 
@@ -60,3 +69,37 @@ func (r *AuthRepository) GetUserByIDWithPw(db *gorm.DB, userID uint) (model.User
 	}
 	return user, nil
 }
+
+func (r *AuthRepository) UpdateStudyGoal(db *gorm.DB, userID uint, goalType string, goalTarget int, timezone string) error {
+	return db.Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"daily_goal_type":   goalType,
+			"daily_goal_target": goalTarget,
+			"timezone":          timezone,
+		}).Error
+}
+
+func (r *AuthRepository) SetRole(db *gorm.DB, userID uint, role string) error {
+	return db.Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Update("role", role).Error
+}
+
+func (r *AuthRepository) CountByRole(db *gorm.DB, role string) (int64, error) {
+	var count int64
+	err := db.Model(&model.User{}).Where("role = ?", role).Count(&count).Error
+	return count, err
+}
+
+func (r *AuthRepository) SetEmailVerified(db *gorm.DB, userID uint, at time.Time) error {
+	return db.Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL AND email_verified_at IS NULL", userID).
+		Update("email_verified_at", at).Error
+}
+
+func (r *AuthRepository) SetPassword(db *gorm.DB, userID uint, hashed string) error {
+	return db.Model(&model.User{}).
+		Where("id = ? AND deleted_at IS NULL", userID).
+		Update("password", hashed).Error
+}
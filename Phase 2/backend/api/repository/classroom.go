@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"gorm.io/gorm"
+)
+
+type ClassroomRepository struct{}
+
+func (r *ClassroomRepository) CreateClassroom(db *gorm.DB, classroom *model.Classroom) error {
+	return db.Create(classroom).Error
+}
+
+func (r *ClassroomRepository) GetClassroom(db *gorm.DB, classID uint) (model.Classroom, error) {
+	var classroom model.Classroom
+	err := db.First(&classroom, classID).Error
+	return classroom, err
+}
+
+func (r *ClassroomRepository) GetClassroomByCode(db *gorm.DB, code string) (model.Classroom, error) {
+	var classroom model.Classroom
+	err := db.Where("join_code = ?", code).First(&classroom).Error
+	return classroom, err
+}
+
+func (r *ClassroomRepository) UpdateClassroom(db *gorm.DB, classID uint, name, description string) error {
+	return db.Model(&model.Classroom{}).
+		Where("id = ?", classID).
+		Updates(map[string]interface{}{"name": name, "description": description}).Error
+}
+
+func (r *ClassroomRepository) SetJoinCode(db *gorm.DB, classID uint, code string) error {
+	return db.Model(&model.Classroom{}).Where("id = ?", classID).Update("join_code", code).Error
+}
+
+func (r *ClassroomRepository) DeleteClassroom(db *gorm.DB, classID uint) error {
+	return db.Delete(&model.Classroom{}, classID).Error
+}
+
+func (r *ClassroomRepository) GetMembership(db *gorm.DB, classID, userID uint) (model.ClassroomMember, error) {
+	var member model.ClassroomMember
+	err := db.Where("classroom_id = ? AND user_id = ?", classID, userID).First(&member).Error
+	return member, err
+}
+
+func (r *ClassroomRepository) AddMember(db *gorm.DB, member *model.ClassroomMember) error {
+	return db.Create(member).Error
+}
+
+// RemoveMember removes userID from the class if they hold role there, and
+// reports whether they did.
+func (r *ClassroomRepository) RemoveMember(db *gorm.DB, classID, userID uint, role string) (bool, error) {
+	res := db.Where("classroom_id = ? AND user_id = ? AND role = ?", classID, userID, role).
+		Delete(&model.ClassroomMember{})
+	return res.RowsAffected > 0, res.Error
+}
+
+// TeachesStudent reports whether studentID is enrolled in any class that
+// teacherID teaches.
+func (r *ClassroomRepository) TeachesStudent(db *gorm.DB, teacherID, studentID uint) (bool, error) {
+	var found bool
+	err := db.Raw(`
+		SELECT EXISTS (
+			SELECT 1
+			FROM classroom_member t
+			JOIN classroom_member s ON s.classroom_id = t.classroom_id AND s.role = ?
+			JOIN classroom c ON c.id = t.classroom_id AND c.deleted_at IS NULL
+			WHERE t.user_id = ? AND t.role = ? AND s.user_id = ?
+		)
+	`, constants.ClassStudent, teacherID, constants.ClassTeacher, studentID).Scan(&found).Error
+	return found, err
+}
+
+const classroomSummaryQuery = `
+	SELECT
+		c.id,
+		c.name,
+		c.description,
+		c.owner_id,
+		CASE WHEN m.role = 'teacher' THEN c.join_code ELSE '' END AS join_code,
+		m.role,
+		(SELECT COUNT(*) FROM classroom_member s WHERE s.classroom_id = c.id AND s.role = 'student') AS student_count,
+		c.created_at
+	FROM classroom c
+	JOIN classroom_member m ON m.classroom_id = c.id AND m.user_id = ?
+	WHERE c.deleted_at IS NULL
+`
+
+var classroomListSpec = ListSpec[dto.ClassroomSummary]{
+	Sorts: map[string]SortField[dto.ClassroomSummary]{
+		"created_at": {Column: "created_at", Cast: "timestamptz", Desc: true, Value: func(c dto.ClassroomSummary) string { return timeValue(c.CreatedAt) }},
+		"name":       {Column: "name", Cast: "text", Value: func(c dto.ClassroomSummary) string { return c.Name }},
+	},
+	DefaultSort: "created_at",
+	Filters: FilterColumns{
+		Owner: "owner_id",
+	},
+	ID: func(c dto.ClassroomSummary) uint { return c.ID },
+}
+
+// ListClassrooms returns the classes userID teaches or is enrolled in.
+func (r *ClassroomRepository) ListClassrooms(db *gorm.DB, userID uint, page requests.PageRequest) (dto.Page[dto.ClassroomSummary], error) {
+	return Paginate(db, classroomSummaryQuery, []interface{}{userID}, classroomListSpec, userID, requests.FilterRequest{}, page)
+}
+
+// GetSummary returns classID as seen by userID. It fails with
+// gorm.ErrRecordNotFound if userID is not a member.
+func (r *ClassroomRepository) GetSummary(db *gorm.DB, classID, userID uint) (dto.ClassroomSummary, error) {
+	var summary dto.ClassroomSummary
+	res := db.Raw(classroomSummaryQuery+" AND c.id = ?", userID, classID).Scan(&summary)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return summary, gorm.ErrRecordNotFound
+	}
+	return summary, res.Error
+}
+
+func (r *ClassroomRepository) GetTeachers(db *gorm.DB, classID uint) ([]dto.ClassroomTeacher, error) {
+	teachers := []dto.ClassroomTeacher{}
+	err := db.Raw(`
+		SELECT u.id AS user_id, u.name, u.email, u.id = c.owner_id AS owner, m.joined_at
+		FROM classroom_member m
+		JOIN classroom c ON c.id = m.classroom_id
+		JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+		WHERE m.classroom_id = ? AND m.role = ?
+		ORDER BY owner DESC, m.joined_at, u.id
+	`, classID, constants.ClassTeacher).Scan(&teachers).Error
+	return teachers, err
+}
+
+var classStudentListSpec = ListSpec[dto.ClassroomStudent]{
+	Sorts: map[string]SortField[dto.ClassroomStudent]{
+		"name":      {Column: "name", Cast: "text", Value: func(s dto.ClassroomStudent) string { return s.Name }},
+		"joined_at": {Column: "joined_at", Cast: "timestamptz", Value: func(s dto.ClassroomStudent) string { return timeValue(s.JoinedAt) }},
+	},
+	DefaultSort: "name",
+	Filters: FilterColumns{
+		Date: "joined_at",
+	},
+	ID: func(s dto.ClassroomStudent) uint { return s.UserID },
+}
+
+func (r *ClassroomRepository) ListStudents(db *gorm.DB, classID uint, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[dto.ClassroomStudent], error) {
+	query := `
+		SELECT u.id, u.id AS user_id, u.name, u.email, m.joined_at
+		FROM classroom_member m
+		JOIN users u ON u.id = m.user_id AND u.deleted_at IS NULL
+		WHERE m.classroom_id = ? AND m.role = ?
+	`
+	return Paginate(db, query, []interface{}{classID, constants.ClassStudent}, classStudentListSpec, 0, filter, page)
+}
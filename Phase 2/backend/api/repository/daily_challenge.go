@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DailyChallengeRepository struct{}
+
+func (r *DailyChallengeRepository) GetByDateAndLevel(db *gorm.DB, date, level string) (model.DailyChallenge, error) {
+	var challenge model.DailyChallenge
+	err := db.Where("challenge_date = ? AND level = ?", date, level).First(&challenge).Error
+	return challenge, err
+}
+
+// CreateIfAbsent inserts the challenge unless another process already picked
+// one for the same date and level, then returns whichever row won.
+func (r *DailyChallengeRepository) CreateIfAbsent(db *gorm.DB, challenge *model.DailyChallenge) (model.DailyChallenge, error) {
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(challenge).Error
+	if err != nil {
+		return model.DailyChallenge{}, err
+	}
+	return r.GetByDateAndLevel(db, challenge.ChallengeDate.Format("2006-01-02"), challenge.Level)
+}
+
+// PickProblem chooses a bank problem for the level, preferring ones that have
+// been daily challenges least often. Ties are broken by a hash of the date so
+// the choice is stable for the day but varies between days.
+func (r *DailyChallengeRepository) PickProblem(db *gorm.DB, level, date string) (model.Problem, error) {
+	var problem model.Problem
+	err := db.Raw(`
+		SELECT p.*
+		FROM problem p
+		LEFT JOIN daily_challenge dc ON dc.problem_id = p.id
+		WHERE p.deleted_at IS NULL
+			AND LOWER(p.level) = ?
+			AND p.answer <> ''
+		GROUP BY p.id
+		ORDER BY COUNT(dc.id) ASC, md5(p.id::text || ?) ASC
+		LIMIT 1
+	`, level, date).Scan(&problem).Error
+	if err != nil {
+		return problem, err
+	}
+	if problem.ID == 0 {
+		return problem, gorm.ErrRecordNotFound
+	}
+	return problem, nil
+}
+
+func (r *DailyChallengeRepository) GetUserAttemptStats(db *gorm.DB, challengeID, userID uint) (int64, bool, error) {
+	var stats struct {
+		Attempts int64
+		Solved   bool
+	}
+	err := db.Raw(`
+		SELECT COUNT(*) AS attempts, COALESCE(BOOL_OR(correct), FALSE) AS solved
+		FROM daily_challenge_attempt
+		WHERE challenge_id = ? AND user_id = ? AND deleted_at IS NULL
+	`, challengeID, userID).Scan(&stats).Error
+	return stats.Attempts, stats.Solved, err
+}
+
+// CreateAttempt records an attempt. It reports false, recording nothing, for a
+// correct attempt at a challenge the user has already solved.
+func (r *DailyChallengeRepository) CreateAttempt(db *gorm.DB, attempt *model.DailyChallengeAttempt) (bool, error) {
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(attempt)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *DailyChallengeRepository) GetStreak(db *gorm.DB, userID uint) (model.DailyStreak, error) {
+	var streak model.DailyStreak
+	err := db.Where("user_id = ?", userID).First(&streak).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return model.DailyStreak{UserID: userID}, nil
+	}
+	return streak, err
+}
+
+func (r *DailyChallengeRepository) SaveStreak(db *gorm.DB, streak *model.DailyStreak) error {
+	return db.Clauses(clause.OnConflict{UpdateAll: true}).Create(streak).Error
+}
+
+func (r *DailyChallengeRepository) GetLeaderboard(db *gorm.DB, challengeID uint, limit int) ([]dto.DailyLeaderboardEntry, error) {
+	var entries []dto.DailyLeaderboardEntry
+
+	err := db.Raw(`
+		SELECT
+			RANK() OVER (
+				ORDER BY BOOL_OR(a.correct) DESC,
+					MIN(a.created_at) FILTER (WHERE a.correct) ASC NULLS LAST,
+					COUNT(a.id) ASC
+			) AS rank,
+			u.id AS user_id,
+			u.name,
+			COUNT(a.id) AS attempts,
+			BOOL_OR(a.correct) AS solved,
+			MIN(a.created_at) FILTER (WHERE a.correct) AS solved_at
+		FROM daily_challenge_attempt a
+		JOIN users u ON u.id = a.user_id
+		WHERE a.challenge_id = ? AND a.deleted_at IS NULL
+		GROUP BY u.id, u.name
+		ORDER BY rank
+		LIMIT ?
+	`, challengeID, limit).Scan(&entries).Error
+
+	return entries, err
+}
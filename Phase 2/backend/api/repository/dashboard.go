@@ -3,57 +3,46 @@ package repository
 import (
 	"M-AI/api/dto"
 	"gorm.io/gorm"
+	"time"
 )
 
+// MinTopicAttempts is how many answers a topic needs before its accuracy is
+// trusted enough to call it a strength or a weakness, or to predict a grade
+// from.
+const MinTopicAttempts = 5
+
+// The lifetime figures come from the running totals in user_stats and
+// user_topic_stats; the time-based ones read the attempt view. Either way
+// answers from quizzes, problems and mock exams are counted alike, and
+// accuracy is always marks awarded over marks available.
+
 type DashboardRepository struct{}
 
 func (r *DashboardRepository) GetDashboardStats(db *gorm.DB, userID uint) (dto.DashboardStats, error) {
 	var stats dto.DashboardStats
 
 	query := `
-		WITH user_quizzes AS (
-			SELECT ql.quiz_id, ql.score
-			FROM quiz_log ql
-			WHERE ql.user_id = ?
-		),
-		question_counts AS (
-			SELECT quiz_id, COUNT(*) AS total_questions
-			FROM question
-			GROUP BY quiz_id
-		),
-		joined AS (
-			SELECT uq.quiz_id, uq.score, qc.total_questions
-			FROM user_quizzes uq
-			JOIN question_counts qc ON uq.quiz_id = qc.quiz_id
-		)
 		SELECT
-			-- Count of quizzes completed
-			COUNT(*) AS quizzes_completed,
-
-			-- Accuracy = total correct answers / total questions attempted
-			ROUND((
-				100.0 * SUM((score / 100.0) * total_questions)::numeric 
-				/ NULLIF(SUM(total_questions), 0)
-			), 2)
-			AS accuracy_rate,
-
-			-- Most challenging topic from problem-level wrong answers
-			COALESCE((
-				SELECT topic
-				FROM (
-					SELECT p.topic, COUNT(*) AS wrong_count
-					FROM user_log ul
-					JOIN problem p ON ul.problem_id = p.id
-					WHERE ul.user_id = ? AND ul.correct_answer = FALSE
-					GROUP BY p.topic
-					ORDER BY wrong_count DESC
-					LIMIT 1
-				) AS sub
-			), 'Number') AS most_challenging_topic
-		FROM joined;
+			COALESCE((SELECT quizzes_completed FROM user_stats WHERE user_id = ?), 0) AS quizzes_completed,
+
+			COALESCE(SUM(t.attempts), 0) AS attempts,
+
+			-- NULL until the user has answered anything
+			ROUND(100.0 * SUM(t.marks_awarded) / NULLIF(SUM(t.marks_available), 0), 2) AS accuracy_rate,
+
+			-- Lowest accuracy among topics with enough answers to judge
+			(
+				SELECT w.topic
+				FROM user_topic_stats w
+				WHERE w.user_id = ? AND w.attempts >= ? AND w.marks_available > 0
+				ORDER BY w.marks_awarded::float / w.marks_available, w.topic
+				LIMIT 1
+			) AS most_challenging_topic
+		FROM user_topic_stats t
+		WHERE t.user_id = ?
 	`
 
-	err := db.Raw(query, userID, userID).Scan(&stats).Error
+	err := db.Raw(query, userID, userID, MinTopicAttempts, userID).Scan(&stats).Error
 	return stats, err
 }
 
@@ -73,21 +62,53 @@ func (r *DashboardRepository) GetRecentActivity(db *gorm.DB, userID uint) ([]dto
 
 		UNION ALL
 
-		-- Recent problem attempts from user_log
+		-- Recent problem attempts
 		SELECT 
 			'problem' AS type, 
 			p.title AS title, 
-			MAX(ul.created_at) AS timestamp
-		FROM user_log ul
-		JOIN problem p ON p.id = ul.problem_id
-		WHERE ul.user_id = ?
+			MAX(a.created_at) AS timestamp
+		FROM attempt a
+		JOIN problem p ON p.id = a.problem_id
+		WHERE a.user_id = ? AND a.source = 'problem'
 		GROUP BY p.id
 
+		UNION ALL
+
+		-- Submitted mock exams
+		SELECT
+			'mock_exam' AS type,
+			me.title AS title,
+			mat.submitted_at AS timestamp
+		FROM mock_exam_attempt mat
+		JOIN mock_exam me ON me.id = mat.exam_id
+		WHERE mat.user_id = ? AND mat.submitted_at IS NOT NULL
+
+		UNION ALL
+
+		-- Revision material read
+		SELECT
+			'resource' AS type,
+			res.title AS title,
+			ra.last_opened_at AS timestamp
+		FROM resource_activity ra
+		JOIN resources res ON res.id = ra.resource_id
+		WHERE ra.user_id = ? AND ra.last_opened_at IS NOT NULL
+
+		UNION ALL
+
+		SELECT
+			'resource_completed' AS type,
+			res.title AS title,
+			ra.completed_at AS timestamp
+		FROM resource_activity ra
+		JOIN resources res ON res.id = ra.resource_id
+		WHERE ra.user_id = ? AND ra.completed_at IS NOT NULL
+
 		ORDER BY timestamp DESC
 		LIMIT 5
 	`
 
-	err := db.Raw(query, userID, userID).Scan(&activities).Error
+	err := db.Raw(query, userID, userID, userID, userID, userID).Scan(&activities).Error
 	return activities, err
 }
 
@@ -97,30 +118,15 @@ func (r *DashboardRepository) GetTopicProficiency(db *gorm.DB, userID uint) ([]d
 	err := db.Raw(`
 		SELECT
 			topic,
-			SUM(correct) AS correct,
-			COUNT(*) AS total,
-			ROUND(SUM(correct) * 100.0 / COUNT(*), 2) AS percentage
-		FROM (
-			-- From problems
-			SELECT
-				p.topic AS topic,
-				CASE WHEN ul.correct_answer THEN 1 ELSE 0 END AS correct
-			FROM user_log ul
-			JOIN problem p ON ul.problem_id = p.id
-			WHERE ul.user_id = ? AND ul.from_quiz = FALSE
-
-			UNION ALL
-
-			-- From quiz questions
-			SELECT
-				q.topic AS topic,
-				CASE WHEN ul.correct_answer THEN 1 ELSE 0 END AS correct
-			FROM user_log ul
-			JOIN question q ON ul.question_id = q.id
-			WHERE ul.user_id = ? AND ul.from_quiz = TRUE
-		) AS combined
-		GROUP BY topic
-	`, userID, userID).Scan(&result).Error
+			marks_awarded AS correct,
+			marks_available AS total,
+			ROUND(marks_awarded * 100.0 / NULLIF(marks_available, 0), 2) AS percentage,
+			attempts,
+			attempts >= ? AS enough_data
+		FROM user_topic_stats
+		WHERE user_id = ? AND attempts > 0
+		ORDER BY topic
+	`, MinTopicAttempts, userID).Scan(&result).Error
 
 	return result, err
 }
@@ -131,31 +137,71 @@ func (r *DashboardRepository) GetChallengingTopics(db *gorm.DB, userID uint) ([]
 	err := db.Raw(`
 		SELECT
 			topic,
-			SUM(wrong) AS wrongAnswers,
-			COUNT(*) AS total,
-			ROUND(SUM(wrong) * 100.0 / COUNT(*), 2) AS percentage
-		FROM (
-			-- Logs from problems
-			SELECT
-				p.topic AS topic,
-				CASE WHEN ul.correct_answer = false THEN 1 ELSE 0 END AS wrong
-			FROM user_log ul
-			JOIN problem p ON ul.problem_id = p.id
-			WHERE ul.user_id = ? AND ul.from_quiz = FALSE
-
-			UNION ALL
-
-			-- Logs from quiz questions
-			SELECT
-				q.topic AS topic,
-				CASE WHEN ul.correct_answer = false THEN 1 ELSE 0 END AS wrong
-			FROM user_log ul
-			JOIN question q ON ul.question_id = q.id
-			WHERE ul.user_id = ? AND ul.from_quiz = TRUE
-		) AS combined
-		GROUP BY topic
-		ORDER BY percentage DESC
-	`, userID, userID).Scan(&result).Error
+			marks_available - marks_awarded AS wrong,
+			marks_available AS total,
+			ROUND((marks_available - marks_awarded) * 100.0 / NULLIF(marks_available, 0), 2) AS percentage,
+			attempts,
+			attempts >= ? AS enough_data
+		FROM user_topic_stats
+		WHERE user_id = ? AND attempts > 0
+		ORDER BY enough_data DESC, percentage DESC, topic
+	`, MinTopicAttempts, userID).Scan(&result).Error
+
+	return result, err
+}
+
+// The progress queries bucket on UTC so the buckets line up with the ones the
+// service generates; to is exclusive.
+
+func (r *DashboardRepository) GetAnswerProgress(db *gorm.DB, userID uint, bucket string, from, to time.Time) ([]dto.ProgressRow, error) {
+	var result []dto.ProgressRow
+
+	err := db.Raw(`
+		SELECT
+			date_trunc(?, created_at AT TIME ZONE 'UTC') AS bucket,
+			COUNT(*) AS answered,
+			SUM(marks_awarded) AS correct,
+			SUM(marks_available) AS total
+		FROM attempt
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY 1
+		ORDER BY 1
+	`, bucket, userID, from, to).Scan(&result).Error
+
+	return result, err
+}
+
+func (r *DashboardRepository) GetQuizProgress(db *gorm.DB, userID uint, bucket string, from, to time.Time) ([]dto.ProgressRow, error) {
+	var result []dto.ProgressRow
+
+	err := db.Raw(`
+		SELECT
+			date_trunc(?, ql.created_at AT TIME ZONE 'UTC') AS bucket,
+			COUNT(*) AS answered
+		FROM quiz_log ql
+		WHERE ql.user_id = ? AND ql.created_at >= ? AND ql.created_at < ?
+		GROUP BY 1
+		ORDER BY 1
+	`, bucket, userID, from, to).Scan(&result).Error
+
+	return result, err
+}
+
+func (r *DashboardRepository) GetTopicProgress(db *gorm.DB, userID uint, bucket string, from, to time.Time) ([]dto.ProgressRow, error) {
+	var result []dto.ProgressRow
+
+	err := db.Raw(`
+		SELECT
+			date_trunc(?, created_at AT TIME ZONE 'UTC') AS bucket,
+			topic,
+			COUNT(*) AS answered,
+			SUM(marks_awarded) AS correct,
+			SUM(marks_available) AS total
+		FROM attempt
+		WHERE user_id = ? AND created_at >= ? AND created_at < ?
+		GROUP BY 1, 2
+		ORDER BY 1, 2
+	`, bucket, userID, from, to).Scan(&result).Error
 
 	return result, err
 }
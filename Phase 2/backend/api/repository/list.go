@@ -0,0 +1,230 @@
+package repository
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/requests"
+	"M-AI/api/utils"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPageSize = 20
+	dateLayout      = "2006-01-02"
+)
+
+// ListError reports a bad cursor, sort or filter. Services return it to the
+// client as a validation error.
+type ListError string
+
+func (e ListError) Error() string {
+	return string(e)
+}
+
+// SortField is a column a list may be sorted by. Value renders a row's value
+// in a form Postgres can cast back to Cast, so it can be carried in a cursor.
+type SortField[T any] struct {
+	Column string
+	Cast   string
+	Desc   bool
+	Value  func(T) string
+}
+
+// FilterColumns names the columns of a listed query that the shared filters
+// apply to. A filter whose column is empty is not supported by the list.
+type FilterColumns struct {
+	// Topics is a text[] of the topics the row covers.
+	Topics string
+	Level  string
+	// Completed is NULL until the requesting user has completed the row.
+	Completed string
+	Date      string
+	Owner     string
+}
+
+// ListSpec describes how a list can be sorted and filtered. Every listed
+// query must return a unique id column, used to break ties between rows.
+type ListSpec[T any] struct {
+	Sorts       map[string]SortField[T]
+	DefaultSort string
+	Filters     FilterColumns
+	ID          func(T) uint
+}
+
+// Paginate runs query as a subquery and returns one keyset page of it, sorted
+// and filtered as requested. userID resolves the "me" owner filter.
+func Paginate[T any](db *gorm.DB, query string, args []interface{}, spec ListSpec[T], userID uint, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[T], error) {
+	result := dto.Page[T]{Items: []T{}}
+
+	sortKey := page.Sort
+	if sortKey == "" {
+		sortKey = spec.DefaultSort
+	}
+	field, ok := spec.Sorts[sortKey]
+	if !ok {
+		return result, ListError(fmt.Sprintf("Cannot sort by %q", sortKey))
+	}
+	desc := field.Desc
+	if page.Order != "" {
+		desc = page.Order == "desc"
+	}
+
+	sql := "SELECT * FROM (" + query + ") AS page WHERE TRUE"
+	where, whereArgs, err := filterClauses(spec.Filters, filter, userID)
+	if err != nil {
+		return result, err
+	}
+	sql += where
+	args = append(append([]interface{}{}, args...), whereArgs...)
+
+	cmp, dir := ">", "ASC"
+	if desc {
+		cmp, dir = "<", "DESC"
+	}
+	if page.Cursor != "" {
+		cursor, err := utils.DecodeCursor(page.Cursor)
+		if err != nil || cursor.Sort != sortKey || cursor.Desc != desc {
+			return result, ListError("Invalid cursor for this list and sort")
+		}
+		sql += fmt.Sprintf(" AND (page.%s, page.id) %s (?::%s, ?)", field.Column, cmp, field.Cast)
+		args = append(args, cursor.Value, cursor.ID)
+	}
+
+	limit := page.Limit
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	sql += fmt.Sprintf(" ORDER BY page.%s %s, page.id %s LIMIT ?", field.Column, dir, dir)
+	args = append(args, limit+1)
+
+	if err := db.Raw(sql, args...).Scan(&result.Items).Error; err != nil {
+		return result, err
+	}
+	if len(result.Items) > limit {
+		result.Items = result.Items[:limit]
+		last := result.Items[limit-1]
+		result.NextCursor = utils.EncodeCursor(utils.Cursor{
+			Sort:  sortKey,
+			Desc:  desc,
+			Value: field.Value(last),
+			ID:    spec.ID(last),
+		})
+	}
+	return result, nil
+}
+
+// OffsetPage reads the offset and limit for a ranked list, which is paged by
+// position rather than by key. It fetches one row more than the page so the
+// caller can tell whether there is a next page.
+func OffsetPage(page requests.PageRequest) (offset, limit int, err error) {
+	if page.Sort != "" {
+		return 0, 0, ListError("This list is ranked and cannot be sorted")
+	}
+	if page.Cursor != "" {
+		cursor, err := utils.DecodeCursor(page.Cursor)
+		if err != nil || cursor.Offset <= 0 {
+			return 0, 0, ListError("Invalid cursor for this list")
+		}
+		offset = cursor.Offset
+	}
+	limit = page.Limit
+	if limit == 0 {
+		limit = defaultPageSize
+	}
+	return offset, limit, nil
+}
+
+// NextOffsetCursor trims rows fetched with OffsetPage to the page and returns
+// the cursor for the page after it, if any.
+func NextOffsetCursor[T any](rows []T, offset, limit int) ([]T, string) {
+	if len(rows) <= limit {
+		return rows, ""
+	}
+	return rows[:limit], utils.EncodeCursor(utils.Cursor{Offset: offset + limit})
+}
+
+func filterClauses(cols FilterColumns, filter requests.FilterRequest, userID uint) (string, []interface{}, error) {
+	var sql strings.Builder
+	var args []interface{}
+
+	unsupported := func(name string) error {
+		return ListError(fmt.Sprintf("This list cannot be filtered by %s", name))
+	}
+
+	if filter.Topic != "" {
+		if cols.Topics == "" {
+			return "", nil, unsupported("topic")
+		}
+		if !constants.IsValidTopic(filter.Topic) {
+			return "", nil, ListError(fmt.Sprintf("Unknown topic %q", filter.Topic))
+		}
+		sql.WriteString(fmt.Sprintf(" AND ? = ANY(page.%s)", cols.Topics))
+		args = append(args, filter.Topic)
+	}
+
+	if filter.Level != "" {
+		if cols.Level == "" {
+			return "", nil, unsupported("level")
+		}
+		sql.WriteString(fmt.Sprintf(" AND LOWER(page.%s) = ?", cols.Level))
+		args = append(args, filter.Level)
+	}
+
+	if filter.Status != "" {
+		if cols.Completed == "" {
+			return "", nil, unsupported("status")
+		}
+		if filter.Status == "completed" {
+			sql.WriteString(fmt.Sprintf(" AND page.%s IS NOT NULL", cols.Completed))
+		} else {
+			sql.WriteString(fmt.Sprintf(" AND page.%s IS NULL", cols.Completed))
+		}
+	}
+
+	if filter.From != "" || filter.To != "" {
+		if cols.Date == "" {
+			return "", nil, unsupported("date")
+		}
+		if filter.From != "" {
+			from, _ := time.Parse(dateLayout, filter.From)
+			sql.WriteString(fmt.Sprintf(" AND page.%s >= ?", cols.Date))
+			args = append(args, from)
+		}
+		if filter.To != "" {
+			to, _ := time.Parse(dateLayout, filter.To)
+			sql.WriteString(fmt.Sprintf(" AND page.%s < ?", cols.Date))
+			args = append(args, to.AddDate(0, 0, 1))
+		}
+	}
+
+	if filter.Owner != "" {
+		if cols.Owner == "" {
+			return "", nil, unsupported("owner")
+		}
+		owner := userID
+		if filter.Owner != "me" {
+			id, err := strconv.ParseUint(filter.Owner, 10, 64)
+			if err != nil {
+				return "", nil, ListError(`Owner must be a user ID or "me"`)
+			}
+			owner = uint(id)
+		}
+		sql.WriteString(fmt.Sprintf(" AND page.%s = ?", cols.Owner))
+		args = append(args, owner)
+	}
+
+	return sql.String(), args, nil
+}
+
+func timeValue(t time.Time) string {
+	return t.UTC().Format(time.RFC3339Nano)
+}
+
+func rankValue(r float32) string {
+	return strconv.FormatFloat(float64(r), 'g', -1, 32)
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"gorm.io/gorm"
+	"time"
+)
+
+type LoginAttemptRepository struct{}
+
+func (r *LoginAttemptRepository) Record(db *gorm.DB, attempt *model.LoginAttempt) error {
+	return db.Create(attempt).Error
+}
+
+type LoginFailures struct {
+	Count        int64
+	LastFailedAt *time.Time
+}
+
+// RecentFailures counts the consecutive failed sign-ins on an email since
+// since, and returns when the latest was made. Failures before the latest
+// success or password reset are not counted.
+func (r *LoginAttemptRepository) RecentFailures(db *gorm.DB, email string, since time.Time) (LoginFailures, error) {
+	var failures LoginFailures
+	err := db.Raw(`
+		SELECT COUNT(*) AS count, MAX(created_at) AS last_failed_at
+		FROM login_attempt
+		WHERE email = ? AND outcome = ? AND created_at >= ? AND deleted_at IS NULL
+			AND created_at > COALESCE((
+				SELECT MAX(created_at)
+				FROM login_attempt
+				WHERE email = ? AND outcome IN ? AND deleted_at IS NULL
+			), '-infinity')
+	`, email, constants.LoginFailed, since, email, []string{constants.LoginSuccess, constants.LoginReset}).Scan(&failures).Error
+	return failures, err
+}
+
+var loginAttemptListSpec = ListSpec[dto.LoginAttempt]{
+	Sorts: map[string]SortField[dto.LoginAttempt]{
+		"created_at": {Column: "created_at", Cast: "timestamptz", Desc: true, Value: func(a dto.LoginAttempt) string { return timeValue(a.CreatedAt) }},
+	},
+	DefaultSort: "created_at",
+	Filters: FilterColumns{
+		Date:  "created_at",
+		Owner: "user_id",
+	},
+	ID: func(a dto.LoginAttempt) uint { return a.ID },
+}
+
+func (r *LoginAttemptRepository) ListAttempts(db *gorm.DB, userID uint, req requests.ListLoginAttemptsRequest) (dto.Page[dto.LoginAttempt], error) {
+	query := `
+		SELECT id, email, user_id, ip, user_agent, outcome, created_at
+		FROM login_attempt
+		WHERE deleted_at IS NULL
+	`
+	var args []interface{}
+	if req.Email != "" {
+		query += " AND email = ?"
+		args = append(args, req.Email)
+	}
+	if req.Outcome != "" {
+		query += " AND outcome = ?"
+		args = append(args, req.Outcome)
+	}
+	return Paginate(db, query, args, loginAttemptListSpec, userID, req.FilterRequest, req.PageRequest)
+}
+
+// DeleteBefore removes attempts made before cutoff.
+func (r *LoginAttemptRepository) DeleteBefore(db *gorm.DB, cutoff time.Time) (int64, error) {
+	res := db.Unscoped().Where("created_at < ?", cutoff).Delete(&model.LoginAttempt{})
+	return res.RowsAffected, res.Error
+}
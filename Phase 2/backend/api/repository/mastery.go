@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/model"
+	"M-AI/pkg/mastery"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"sort"
+)
+
+type MasteryRepository struct{}
+
+// Record folds newly logged answers into each user's topic mastery, in
+// insertion order. Rows are locked so concurrent submissions for the same
+// user and topic are applied one after another, and always in user and topic
+// order so two submissions touching the same topics cannot deadlock.
+func (r *MasteryRepository) Record(db *gorm.DB, answers []tracedAnswer) error {
+	type key struct {
+		userID uint
+		topic  constants.TopicEnum
+	}
+	rows := make(map[key]*model.TopicMastery)
+	var keys []key
+	for _, a := range answers {
+		k := key{a.UserID, a.Topic}
+		if _, ok := rows[k]; !ok {
+			rows[k] = nil
+			keys = append(keys, k)
+		}
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].userID != keys[j].userID {
+			return keys[i].userID < keys[j].userID
+		}
+		return keys[i].topic < keys[j].topic
+	})
+	for _, k := range keys {
+		locked, err := r.lock(db, k.userID, k.topic)
+		if err != nil {
+			return err
+		}
+		rows[k] = &locked
+	}
+
+	for _, a := range answers {
+		row := rows[key{a.UserID, a.Topic}]
+		params := mastery.FreeResponse
+		if a.FromQuiz {
+			params = mastery.MultipleChoice
+		}
+		p := row.Probability
+		if row.LastAttemptAt != nil {
+			p = mastery.Decay(p, a.CreatedAt.Sub(*row.LastAttemptAt))
+		}
+		row.Probability = mastery.Update(p, a.Correct, params)
+		row.Attempts++
+		at := a.CreatedAt
+		row.LastAttemptAt = &at
+	}
+
+	for _, k := range keys {
+		if err := db.Save(rows[k]).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// lock returns the user's mastery row for a topic, creating it at the prior
+// if needed, and holds a row lock until the transaction ends.
+func (r *MasteryRepository) lock(db *gorm.DB, userID uint, topic constants.TopicEnum) (model.TopicMastery, error) {
+	row := model.TopicMastery{UserID: userID, Topic: topic, Probability: mastery.Initial}
+	err := db.Clauses(clause.OnConflict{DoNothing: true}).Create(&row).Error
+	if err != nil {
+		return row, err
+	}
+
+	err = db.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND topic = ?", userID, topic).
+		First(&row).Error
+	return row, err
+}
+
+func (r *MasteryRepository) GetUserMastery(db *gorm.DB, userID uint) ([]model.TopicMastery, error) {
+	var rows []model.TopicMastery
+	err := db.Where("user_id = ?", userID).Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,152 @@
+package repository
+
+import (
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"gorm.io/gorm"
+	"time"
+)
+
+type MockExamRepository struct {
+	stats StatsRepository
+}
+
+func (r *MockExamRepository) CreateExam(tx *gorm.DB, exam *model.MockExam) error {
+	return tx.Create(exam).Error
+}
+
+func (r *MockExamRepository) BulkCreateQuestions(tx *gorm.DB, questions []model.MockExamQuestion) error {
+	return tx.Create(&questions).Error
+}
+
+func (r *MockExamRepository) BulkCreateBoundaries(tx *gorm.DB, boundaries []model.MockExamBoundary) error {
+	if len(boundaries) == 0 {
+		return nil
+	}
+	return tx.Create(&boundaries).Error
+}
+
+var mockExamListSpec = ListSpec[dto.MockExamSummary]{
+	Sorts: map[string]SortField[dto.MockExamSummary]{
+		"created_at": {Column: "created_at", Cast: "timestamptz", Desc: true, Value: func(e dto.MockExamSummary) string { return timeValue(e.CreatedAt) }},
+		"title":      {Column: "title", Cast: "text", Value: func(e dto.MockExamSummary) string { return e.Title }},
+	},
+	DefaultSort: "created_at",
+	Filters: FilterColumns{
+		Completed: "last_submitted_at",
+		Date:      "created_at",
+		Owner:     "created_by",
+	},
+	ID: func(e dto.MockExamSummary) uint { return e.ID },
+}
+
+func (r *MockExamRepository) ListExamsWithUserStats(db *gorm.DB, userID uint, tier, paper string, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[dto.MockExamSummary], error) {
+	query := `
+		SELECT
+			e.id,
+			e.title,
+			e.description,
+			e.tier,
+			e.paper,
+			e.time_limit_minutes,
+			e.created_at,
+			e.created_by,
+			(SELECT COUNT(*) FROM mock_exam_question q WHERE q.exam_id = e.id AND q.deleted_at IS NULL) AS question_count,
+			(SELECT COALESCE(SUM(q.marks), 0) FROM mock_exam_question q WHERE q.exam_id = e.id AND q.deleted_at IS NULL) AS total_marks,
+			COUNT(a.id) FILTER (WHERE a.submitted_at IS NOT NULL) AS attempts,
+			MAX(a.grade) FILTER (WHERE a.submitted_at IS NOT NULL) AS best_grade,
+			MAX(a.submitted_at) AS last_submitted_at
+		FROM mock_exam e
+		LEFT JOIN mock_exam_attempt a ON a.exam_id = e.id AND a.user_id = ? AND a.deleted_at IS NULL
+		WHERE e.deleted_at IS NULL
+	`
+	args := []interface{}{userID}
+
+	if tier != "" {
+		query += " AND e.tier = ?"
+		args = append(args, tier)
+	}
+	if paper != "" {
+		query += " AND e.paper = ?"
+		args = append(args, paper)
+	}
+
+	query += `
+		GROUP BY e.id
+	`
+
+	return Paginate(db, query, args, mockExamListSpec, userID, filter, page)
+}
+
+func (r *MockExamRepository) GetExam(db *gorm.DB, examID uint) (model.MockExam, error) {
+	var exam model.MockExam
+	err := db.Where("id = ?", examID).First(&exam).Error
+	return exam, err
+}
+
+func (r *MockExamRepository) GetQuestions(db *gorm.DB, examID uint) ([]model.MockExamQuestion, error) {
+	var questions []model.MockExamQuestion
+	err := db.Where("exam_id = ?", examID).Order("position").Find(&questions).Error
+	return questions, err
+}
+
+func (r *MockExamRepository) GetBoundaries(db *gorm.DB, examID uint) ([]model.MockExamBoundary, error) {
+	var boundaries []model.MockExamBoundary
+	err := db.Where("exam_id = ?", examID).Order("grade DESC").Find(&boundaries).Error
+	return boundaries, err
+}
+
+func (r *MockExamRepository) CreateAttempt(tx *gorm.DB, attempt *model.MockExamAttempt) error {
+	return tx.Create(attempt).Error
+}
+
+func (r *MockExamRepository) GetAttempt(db *gorm.DB, attemptID uint) (model.MockExamAttempt, error) {
+	var attempt model.MockExamAttempt
+	err := db.Where("id = ?", attemptID).First(&attempt).Error
+	return attempt, err
+}
+
+// GetOpenAttempt returns the user's unsubmitted attempt whose deadline has not
+// passed yet.
+func (r *MockExamRepository) GetOpenAttempt(db *gorm.DB, examID, userID uint, now time.Time) (model.MockExamAttempt, error) {
+	var attempt model.MockExamAttempt
+	err := db.Where("exam_id = ? AND user_id = ? AND submitted_at IS NULL AND deadline > ?", examID, userID, now).
+		Order("started_at DESC").
+		First(&attempt).Error
+	return attempt, err
+}
+
+// MarkSubmitted stores a marked attempt's result, unless it has already been
+// submitted. It reports whether this call submitted it, so two concurrent
+// submissions cannot both be recorded.
+func (r *MockExamRepository) MarkSubmitted(tx *gorm.DB, attempt model.MockExamAttempt) (bool, error) {
+	res := tx.Model(&model.MockExamAttempt{}).
+		Where("id = ? AND submitted_at IS NULL", attempt.ID).
+		Updates(map[string]interface{}{
+			"submitted_at": attempt.SubmittedAt,
+			"raw_mark":     attempt.RawMark,
+			"total_marks":  attempt.TotalMarks,
+			"grade":        attempt.Grade,
+			"over_time":    attempt.OverTime,
+		})
+	return res.RowsAffected > 0, res.Error
+}
+
+// BulkCreateAnswers saves a submitted attempt's marked answers and adds them
+// to the dashboard totals. The attempt must already be marked as submitted.
+func (r *MockExamRepository) BulkCreateAnswers(tx *gorm.DB, answers []model.MockExamAnswer) error {
+	if len(answers) == 0 {
+		return nil
+	}
+	if err := tx.Create(&answers).Error; err != nil {
+		return err
+	}
+	return r.stats.AddMockExamAttempt(tx, answers[0].AttemptID)
+}
+
+func (r *MockExamRepository) GetAnswers(db *gorm.DB, attemptID uint) ([]model.MockExamAnswer, error) {
+	var answers []model.MockExamAnswer
+	err := db.Where("attempt_id = ?", attemptID).Find(&answers).Error
+	return answers, err
+}
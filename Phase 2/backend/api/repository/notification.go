@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"M-AI/api/model"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type NotificationRepository struct{}
+
+// Queue stores a notification unless one with the same kind and dedupe key
+// was already queued for the user.
+func (r *NotificationRepository) Queue(db *gorm.DB, n *model.Notification) error {
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(n).Error
+}
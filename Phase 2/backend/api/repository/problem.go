@@ -1,7 +1,9 @@
 package repository
 
 import (
+	"M-AI/api/dto"
 	"M-AI/api/model"
+	"M-AI/api/requests"
 	"gorm.io/gorm"
 )
 
@@ -10,3 +12,101 @@ type ProblemRepository struct{}
 func (r *ProblemRepository) CreateProblem(db *gorm.DB, problem *model.Problem) error {
 	return db.Create(problem).Error
 }
+
+// todaysChallengeProblems selects the problems set as a daily challenge
+// today. They are kept out of practice and search until the day is over, so
+// the challenge can only be answered through its own endpoints.
+const todaysChallengeProblems = `
+	SELECT problem_id FROM daily_challenge
+	WHERE challenge_date = (NOW() AT TIME ZONE 'UTC')::date AND deleted_at IS NULL
+`
+
+// Problems created before answers were stored have an empty answer and could
+// never be marked correct, so practice and search leave them out.
+const problemWithStatsSelect = `
+	SELECT
+		p.id,
+		p.topic,
+		p.level,
+		p.title,
+		p.question,
+		p.answer_type,
+		p.created_at,
+		ARRAY[p.topic::text] AS topics,
+		COUNT(ul.id) AS attempts,
+		COALESCE(BOOL_OR(ul.correct_answer), FALSE) AS solved,
+		MAX(ul.created_at) AS last_attempt_at,
+		MIN(ul.created_at) FILTER (WHERE ul.correct_answer) AS solved_at,
+		g.user_id IS NOT NULL AS gave_up
+	FROM problem p
+	LEFT JOIN user_log ul ON ul.problem_id = p.id AND ul.user_id = ? AND ul.deleted_at IS NULL
+	LEFT JOIN problem_give_up g ON g.problem_id = p.id AND g.user_id = ?
+	WHERE p.deleted_at IS NULL AND p.answer <> '' AND p.id NOT IN (` + todaysChallengeProblems + `)
+`
+
+// Problems count as completed once the user has solved them.
+var problemListSpec = ListSpec[dto.ProblemWithStats]{
+	Sorts: map[string]SortField[dto.ProblemWithStats]{
+		"created_at": {Column: "created_at", Cast: "timestamptz", Desc: true, Value: func(p dto.ProblemWithStats) string { return timeValue(p.CreatedAt) }},
+		"title":      {Column: "title", Cast: "text", Value: func(p dto.ProblemWithStats) string { return p.Title }},
+	},
+	DefaultSort: "created_at",
+	Filters: FilterColumns{
+		Topics:    "topics",
+		Level:     "level",
+		Completed: "solved_at",
+		Date:      "created_at",
+	},
+	ID: func(p dto.ProblemWithStats) uint { return p.ID },
+}
+
+func (r *ProblemRepository) ListProblemsWithUserStats(db *gorm.DB, userID uint, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[dto.ProblemWithStats], error) {
+	query := problemWithStatsSelect + `
+		GROUP BY p.id, g.user_id
+	`
+	return Paginate(db, query, []interface{}{userID, userID}, problemListSpec, userID, filter, page)
+}
+
+func (r *ProblemRepository) GetProblemWithUserStats(db *gorm.DB, userID, problemID uint) (dto.ProblemWithStats, error) {
+	var result dto.ProblemWithStats
+
+	query := problemWithStatsSelect + `
+		AND p.id = ?
+		GROUP BY p.id, g.user_id
+	`
+
+	err := db.Raw(query, userID, userID, problemID).Scan(&result).Error
+	return result, err
+}
+
+// GetPracticeProblem is GetProblemByID for the practice endpoints, which do
+// not see today's challenge problems or problems without an answer.
+func (r *ProblemRepository) GetPracticeProblem(db *gorm.DB, problemID uint) (model.Problem, error) {
+	var problem model.Problem
+	err := db.Where("id = ? AND answer <> '' AND id NOT IN ("+todaysChallengeProblems+")", problemID).First(&problem).Error
+	return problem, err
+}
+
+func (r *ProblemRepository) GetProblemByID(db *gorm.DB, problemID uint) (model.Problem, error) {
+	var problem model.Problem
+	err := db.Where("id = ?", problemID).First(&problem).Error
+	return problem, err
+}
+
+// GiveUp records that the user gave up on a problem. Giving up twice is
+// harmless.
+func (r *ProblemRepository) GiveUp(db *gorm.DB, userID, problemID uint) error {
+	return db.Exec(`
+		INSERT INTO problem_give_up (user_id, problem_id)
+		VALUES (?, ?)
+		ON CONFLICT (user_id, problem_id) DO NOTHING
+	`, userID, problemID).Error
+}
+
+func (r *ProblemRepository) HasGivenUp(db *gorm.DB, userID, problemID uint) (bool, error) {
+	var gaveUp bool
+	err := db.Raw(`
+		SELECT EXISTS (SELECT 1 FROM problem_give_up WHERE user_id = ? AND problem_id = ?)
+	`, userID, problemID).Scan(&gaveUp).Error
+	return gaveUp, err
+}
@@ -1,8 +1,10 @@
 package repository
 
 import (
+	"M-AI/api/constants"
 	"M-AI/api/dto"
 	"M-AI/api/model"
+	"M-AI/api/requests"
 	"gorm.io/gorm"
 )
 
@@ -12,76 +14,127 @@ func (r *QuizRepository) CreateQuiz(tx *gorm.DB, quiz *model.Quiz) error {
 	return tx.Create(quiz).Error
 }
 
+func (r *QuizRepository) GetQuiz(tx *gorm.DB, quizID uint) (model.Quiz, error) {
+	var quiz model.Quiz
+	err := tx.First(&quiz, quizID).Error
+	return quiz, err
+}
+
 func (r *QuizRepository) BulkCreateQuestions(tx *gorm.DB, questions []model.Question) error {
 	return tx.Create(&questions).Error
 }
 
-func (r *QuizRepository) ListQuizzesWithUserStats(tx *gorm.DB, userID uint, search string, filter string) ([]dto.QuizWithStats, error) {
-	var results []dto.QuizWithStats
+var quizListSpec = ListSpec[dto.QuizWithStats]{
+	Sorts: map[string]SortField[dto.QuizWithStats]{
+		"created_at": {Column: "created_at", Cast: "timestamptz", Desc: true, Value: func(q dto.QuizWithStats) string { return timeValue(q.CreatedAt) }},
+		"title":      {Column: "title", Cast: "text", Value: func(q dto.QuizWithStats) string { return q.Title }},
+		"relevance":  {Column: "rank", Cast: "real", Desc: true, Value: func(q dto.QuizWithStats) string { return rankValue(q.Rank) }},
+		"due_at":     {Column: "due_sort", Cast: "timestamptz", Value: quizDueValue},
+	},
+	DefaultSort: "created_at",
+	Filters: FilterColumns{
+		Topics:    "topics",
+		Level:     "level",
+		Completed: "completed_at",
+		Date:      "created_at",
+		Owner:     "created_by",
+	},
+	ID: func(q dto.QuizWithStats) uint { return q.ID },
+}
 
-	baseQuery := `
-		SELECT 
+// quizDueValue sorts quizzes that are not assigned to the user after those
+// that are.
+func quizDueValue(q dto.QuizWithStats) string {
+	if q.DueAt == nil {
+		return "infinity"
+	}
+	return timeValue(*q.DueAt)
+}
+
+// ListQuizzesWithUserStats returns a page of quizzes with the user's latest
+// score on each. Questions are not attached; fetch a single quiz for those.
+// A search matches quiz titles and descriptions or question text by
+// full-text search, or a topic or level by substring, and sorts by relevance
+// unless another sort is given. Quizzes open as assignments in the user's
+// classes carry the assignment due soonest; assigned limits the list to
+// those, soonest due first unless another sort is given.
+func (r *QuizRepository) ListQuizzesWithUserStats(tx *gorm.DB, userID uint, search string, assigned bool, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[dto.QuizWithStats], error) {
+	rank := "0::real"
+	var args []interface{}
+	if search != "" {
+		rank = "ts_rank(q.search_vector, websearch_to_tsquery('english', ?))"
+		args = append(args, search)
+		if page.Sort == "" {
+			page.Sort = "relevance"
+		}
+	}
+
+	query := `
+		SELECT
 			q.id,
 			q.level,
 			q.created_at,
 			q.title,
 			q.description,
+			q.created_by,
 			ARRAY_AGG(DISTINCT ques.topic::text) FILTER (WHERE ques.topic IS NOT NULL) AS topics,
 			COUNT(ques.id) AS question_count,
 			COALESCE(ql.score, 0) AS score,
-			ql.created_at AS completed_at
+			ql.created_at AS completed_at,
+			qa.id AS assignment_id,
+			qa.due_at,
+			COALESCE(qa.due_at, 'infinity') AS due_sort,
+			` + rank + ` AS rank
 		FROM quiz q
-		LEFT JOIN question ques ON q.id = ques.quiz_id
-		LEFT JOIN quiz_log ql ON ql.quiz_id = q.id AND ql.user_id = ?
-		WHERE 
-			(ques.topic::text ILIKE ? OR q.level::text ILIKE ?)
+		LEFT JOIN question ques ON q.id = ques.quiz_id AND ques.deleted_at IS NULL
+		LEFT JOIN LATERAL (
+			SELECT score, created_at
+			FROM quiz_log
+			WHERE quiz_id = q.id AND user_id = ?
+			ORDER BY created_at DESC
+			LIMIT 1
+		) ql ON TRUE
+		LEFT JOIN LATERAL (
+			SELECT a.id, a.due_at
+			FROM quiz_assignment a
+			JOIN classroom c ON c.id = a.classroom_id AND c.deleted_at IS NULL
+			JOIN classroom_member m ON m.classroom_id = a.classroom_id AND m.user_id = ? AND m.role = ?
+			WHERE a.quiz_id = q.id AND a.deleted_at IS NULL AND a.open_at <= NOW()
+			ORDER BY a.due_at < NOW(), a.due_at
+			LIMIT 1
+		) qa ON TRUE
+		WHERE q.deleted_at IS NULL
 	`
+	args = append(args, userID, userID, constants.ClassStudent)
 
-	args := []interface{}{userID, "%" + search + "%", "%" + search + "%"}
+	if assigned {
+		query += " AND qa.id IS NOT NULL"
+		if page.Sort == "" {
+			page.Sort = "due_at"
+		}
+	}
 
-	if filter == "completed" {
-		baseQuery += `
-			AND EXISTS (
-				SELECT 1 
-				FROM quiz_log ql2 
-				WHERE ql2.user_id = ? AND ql2.quiz_id = q.id
+	if search != "" {
+		query += `
+			AND (
+				q.search_vector @@ websearch_to_tsquery('english', ?)
+				OR EXISTS (
+					SELECT 1
+					FROM question sq
+					WHERE sq.quiz_id = q.id AND sq.deleted_at IS NULL
+						AND (sq.search_vector @@ websearch_to_tsquery('english', ?) OR sq.topic::text ILIKE ?)
+				)
+				OR q.level::text ILIKE ?
 			)
 		`
-		args = append(args, userID)
+		args = append(args, search, search, "%"+search+"%", "%"+search+"%")
 	}
 
-	baseQuery += `
-		GROUP BY q.id, q.level, q.created_at, q.title, q.description, ql.score, ql.created_at
-		ORDER BY q.created_at DESC
+	query += `
+		GROUP BY q.id, ql.score, ql.created_at, qa.id, qa.due_at
 	`
 
-	err := tx.Raw(baseQuery, args...).Scan(&results).Error
-	if err != nil {
-		return nil, err
-	}
-
-	var quizIDs []uint
-	quizIndex := make(map[uint]*dto.QuizWithStats)
-
-	for i := range results {
-		quizIDs = append(quizIDs, results[i].ID)
-		quizIndex[results[i].ID] = &results[i]
-	}
-
-	if len(quizIDs) > 0 {
-		var questions []model.Question
-		if err := tx.Where("quiz_id IN ?", quizIDs).Find(&questions).Error; err != nil {
-			return nil, err
-		}
-
-		for _, q := range questions {
-			if quiz, ok := quizIndex[q.QuizID]; ok {
-				quiz.Questions = append(quiz.Questions, q)
-			}
-		}
-	}
-
-	return results, nil
+	return Paginate(tx, query, args, quizListSpec, userID, filter, page)
 }
 
 func (r *QuizRepository) GetQuizByIDWithStats(tx *gorm.DB, userID, quizID uint) (dto.QuizWithStats, error) {
@@ -117,27 +170,3 @@ func (r *QuizRepository) GetQuizByIDWithStats(tx *gorm.DB, userID, quizID uint)
 
 	return result, nil
 }
-
-func (r *QuizRepository) GetTopicProficiency(db *gorm.DB, userID uint) ([]dto.TopicProficiencyFinal, error) {
-	var result []dto.TopicProficiencyFinal
-
-	query := `
-		SELECT 
-			q.topic,
-			ROUND(
-				(SUM(CASE WHEN ul.correct_answer THEN 1 ELSE 0 END)::float / NULLIF(COUNT(*), 0))::numeric, 
-				2
-			) AS score
-		FROM user_log ul
-		JOIN question q ON ul.question_id = q.id
-		WHERE ul.user_id = ? AND ul.from_quiz = TRUE
-		GROUP BY q.topic
-	`
-
-	err := db.Raw(query, userID).Scan(&result).Error
-	if err != nil {
-		return nil, err
-	}
-
-	return result, nil
-}
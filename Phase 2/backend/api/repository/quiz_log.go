@@ -5,15 +5,28 @@ import (
 	"gorm.io/gorm"
 )
 
-type QuizLogRepository struct{}
+type QuizLogRepository struct {
+	stats StatsRepository
+}
 
 func (r *QuizLogRepository) Create(db *gorm.DB, log *model.QuizLog) error {
-	return db.Create(log).Error
+	if err := db.Create(log).Error; err != nil {
+		return err
+	}
+	return r.stats.AddQuizCompletion(db, log.UserID)
 }
 
 func (r *QuizLogRepository) CreateBatch(db *gorm.DB, logs []model.QuizLog) error {
 	if len(logs) == 0 {
 		return nil
 	}
-	return db.Create(&logs).Error
+	if err := db.Create(&logs).Error; err != nil {
+		return err
+	}
+	for _, l := range logs {
+		if err := r.stats.AddQuizCompletion(db, l.UserID); err != nil {
+			return err
+		}
+	}
+	return nil
 }
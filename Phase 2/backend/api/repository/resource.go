@@ -1,32 +1,248 @@
 package repository
 
 import (
-	"M-AI/api/constants"
+	"M-AI/api/dto"
 	"M-AI/api/model"
-	"strings"
+	"M-AI/api/requests"
+	"time"
 
 	"gorm.io/gorm"
 )
 
 type ResourceRepository struct{}
 
-func (r *ResourceRepository) GetResources(db *gorm.DB, search string, level string) ([]model.Resource, error) {
-	var resources []model.Resource
+var resourceFilters = FilterColumns{
+	Topics:    "topics",
+	Level:     "level",
+	Completed: "completed_at",
+	Date:      "created_at",
+	Owner:     "created_by",
+}
+
+var resourceSorts = map[string]SortField[model.Resource]{
+	"created_at": {Column: "created_at", Cast: "timestamptz", Desc: true, Value: func(r model.Resource) string { return timeValue(r.CreatedAt) }},
+	"title":      {Column: "title", Cast: "text", Value: func(r model.Resource) string { return r.Title }},
+	"relevance":  {Column: "rank", Cast: "real", Desc: true, Value: func(r model.Resource) string { return rankValue(r.Rank) }},
+}
 
-	query := db.Model(&model.Resource{})
+var resourceListSpec = ListSpec[model.Resource]{
+	Sorts:       resourceSorts,
+	DefaultSort: "created_at",
+	Filters:     resourceFilters,
+	ID:          func(r model.Resource) uint { return r.ID },
+}
 
+// GetResources returns a page of the resources students can see. A search
+// ranks full-text matches by weight, with a substring match on the title so
+// partial words such as "frac" still work, and sorts by relevance unless
+// another sort is given.
+func (r *ResourceRepository) GetResources(db *gorm.DB, userID uint, search string, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[model.Resource], error) {
+	rank := "0::real"
+	var args []interface{}
 	if search != "" {
-		likeQuery := "%" + search + "%"
-		query = query.Where("title ILIKE ? OR description ILIKE ?", likeQuery, likeQuery)
+		rank = "ts_rank(res.search_vector, websearch_to_tsquery('english', ?))"
+		args = append(args, search)
+		if page.Sort == "" {
+			page.Sort = "relevance"
+		}
 	}
 
-	if level != "" {
-		level = strings.ToLower(level)
-		if level == constants.LevelBeginner || level == constants.LevelIntermediate || level == constants.LevelAdvanced {
-			query = query.Where("LOWER(level) = ?", level)
-		}
+	query := `
+		SELECT res.*, res.topic::text[] AS topics, ra.completed_at, ` + rank + ` AS rank
+		FROM resources res
+		LEFT JOIN resource_activity ra ON ra.resource_id = res.id AND ra.user_id = ?
+		WHERE res.deleted_at IS NULL AND NOT res.link_broken
+	`
+	args = append(args, userID)
+
+	if search != "" {
+		query += " AND (res.search_vector @@ websearch_to_tsquery('english', ?) OR res.title ILIKE ?)"
+		args = append(args, search, "%"+search+"%")
 	}
 
-	err := query.Find(&resources).Error
+	return Paginate(db, query, args, resourceListSpec, userID, filter, page)
+}
+
+// GetVisibleResources returns every resource students can see.
+func (r *ResourceRepository) GetVisibleResources(db *gorm.DB) ([]model.Resource, error) {
+	var resources []model.Resource
+	err := db.Where("NOT link_broken").Order("id").Find(&resources).Error
 	return resources, err
 }
+
+var adminResourceListSpec = ListSpec[model.Resource]{
+	Sorts:       resourceSorts,
+	DefaultSort: "created_at",
+	Filters: FilterColumns{
+		Topics: "topics",
+		Level:  "level",
+		Date:   "created_at",
+		Owner:  "created_by",
+	},
+	ID: func(r model.Resource) uint { return r.ID },
+}
+
+// GetAllResources returns a page of every resource for administration,
+// optionally only those whose links are, or are not, broken.
+func (r *ResourceRepository) GetAllResources(db *gorm.DB, userID uint, broken *bool, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[model.Resource], error) {
+	query := `
+		SELECT res.*, res.topic::text[] AS topics, 0::real AS rank
+		FROM resources res
+		WHERE res.deleted_at IS NULL
+	`
+	var args []interface{}
+	if broken != nil {
+		query += " AND res.link_broken = ?"
+		args = append(args, *broken)
+	}
+
+	return Paginate(db, query, args, adminResourceListSpec, userID, filter, page)
+}
+
+func (r *ResourceRepository) GetResourceByID(db *gorm.DB, resourceID uint) (model.Resource, error) {
+	var resource model.Resource
+	err := db.First(&resource, resourceID).Error
+	return resource, err
+}
+
+// GetActivity returns the user's activity for each resource they have
+// touched, keyed by resource ID.
+func (r *ResourceRepository) GetActivity(db *gorm.DB, userID uint) (map[uint]model.ResourceActivity, error) {
+	var rows []model.ResourceActivity
+	if err := db.Where("user_id = ?", userID).Find(&rows).Error; err != nil {
+		return nil, err
+	}
+	activity := make(map[uint]model.ResourceActivity, len(rows))
+	for _, row := range rows {
+		activity[row.ResourceID] = row
+	}
+	return activity, nil
+}
+
+type savedResource struct {
+	model.Resource
+	SavedAt time.Time
+}
+
+var savedResourceListSpec = ListSpec[savedResource]{
+	Sorts: map[string]SortField[savedResource]{
+		"saved_at": {Column: "saved_at", Cast: "timestamptz", Desc: true, Value: func(r savedResource) string { return timeValue(r.SavedAt) }},
+		"title":    {Column: "title", Cast: "text", Value: func(r savedResource) string { return r.Title }},
+	},
+	DefaultSort: "saved_at",
+	Filters:     resourceFilters,
+	ID:          func(r savedResource) uint { return r.ID },
+}
+
+// GetSavedResources returns a page of the user's bookmarked resources, most
+// recently saved first. Resources with broken links stay hidden.
+func (r *ResourceRepository) GetSavedResources(db *gorm.DB, userID uint, filter requests.FilterRequest, page requests.PageRequest) (dto.Page[model.Resource], error) {
+	query := `
+		SELECT res.*, res.topic::text[] AS topics, ra.saved_at, ra.completed_at
+		FROM resources res
+		JOIN resource_activity ra ON ra.resource_id = res.id
+		WHERE ra.user_id = ? AND ra.saved_at IS NOT NULL AND res.deleted_at IS NULL AND NOT res.link_broken
+	`
+	saved, err := Paginate(db, query, []interface{}{userID}, savedResourceListSpec, userID, filter, page)
+	result := dto.Page[model.Resource]{Items: make([]model.Resource, 0, len(saved.Items)), NextCursor: saved.NextCursor}
+	for _, row := range saved.Items {
+		result.Items = append(result.Items, row.Resource)
+	}
+	return result, err
+}
+
+func (r *ResourceRepository) RecordOpen(db *gorm.DB, userID, resourceID uint, at time.Time) error {
+	return db.Exec(`
+		INSERT INTO resource_activity AS ra (user_id, resource_id, open_count, first_opened_at, last_opened_at)
+		VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT (user_id, resource_id) DO UPDATE SET
+			open_count = ra.open_count + 1,
+			first_opened_at = COALESCE(ra.first_opened_at, EXCLUDED.first_opened_at),
+			last_opened_at = EXCLUDED.last_opened_at
+	`, userID, resourceID, at, at).Error
+}
+
+// SetSaved bookmarks a resource, keeping the original time if it was already
+// saved, or removes the bookmark when at is nil.
+func (r *ResourceRepository) SetSaved(db *gorm.DB, userID, resourceID uint, at *time.Time) error {
+	if at == nil {
+		return db.Exec(`UPDATE resource_activity SET saved_at = NULL WHERE user_id = ? AND resource_id = ?`, userID, resourceID).Error
+	}
+	return db.Exec(`
+		INSERT INTO resource_activity AS ra (user_id, resource_id, saved_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (user_id, resource_id) DO UPDATE SET
+			saved_at = COALESCE(ra.saved_at, EXCLUDED.saved_at)
+	`, userID, resourceID, *at).Error
+}
+
+// SetCompleted marks a resource as completed, keeping the original time if it
+// was already completed, or clears it when at is nil.
+func (r *ResourceRepository) SetCompleted(db *gorm.DB, userID, resourceID uint, at *time.Time) error {
+	if at == nil {
+		return db.Exec(`UPDATE resource_activity SET completed_at = NULL WHERE user_id = ? AND resource_id = ?`, userID, resourceID).Error
+	}
+	return db.Exec(`
+		INSERT INTO resource_activity AS ra (user_id, resource_id, completed_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT (user_id, resource_id) DO UPDATE SET
+			completed_at = COALESCE(ra.completed_at, EXCLUDED.completed_at)
+	`, userID, resourceID, *at).Error
+}
+
+func (r *ResourceRepository) GetResourceByLink(db *gorm.DB, link string) (model.Resource, error) {
+	var resource model.Resource
+	err := db.Where("link = ?", link).First(&resource).Error
+	return resource, err
+}
+
+// GetExistingLinks returns which of links already belong to a resource.
+func (r *ResourceRepository) GetExistingLinks(db *gorm.DB, links []string) (map[string]bool, error) {
+	var found []string
+	if err := db.Model(&model.Resource{}).Where("link IN ?", links).Pluck("link", &found).Error; err != nil {
+		return nil, err
+	}
+	existing := make(map[string]bool, len(found))
+	for _, link := range found {
+		existing[link] = true
+	}
+	return existing, nil
+}
+
+func (r *ResourceRepository) CreateResources(db *gorm.DB, resources []model.Resource) error {
+	return db.Create(&resources).Error
+}
+
+func (r *ResourceRepository) UpdateResource(db *gorm.DB, resource *model.Resource) error {
+	return db.Save(resource).Error
+}
+
+func (r *ResourceRepository) DeleteResource(db *gorm.DB, resourceID uint) error {
+	return db.Delete(&model.Resource{}, resourceID).Error
+}
+
+// GetResourcesToCheck returns up to limit resources whose links were checked
+// least recently, never-checked ones first.
+func (r *ResourceRepository) GetResourcesToCheck(db *gorm.DB, limit int) ([]model.Resource, error) {
+	var resources []model.Resource
+	err := db.Order("link_checked_at NULLS FIRST, id").Limit(limit).Find(&resources).Error
+	return resources, err
+}
+
+// RecordLinkCheck stores a check result. Consecutive failures are counted and
+// the resource is marked broken once they reach failureLimit; any success
+// clears the count.
+func (r *ResourceRepository) RecordLinkCheck(db *gorm.DB, resourceID uint, status int, checkErr string, ok bool, at time.Time, failureLimit int) (bool, error) {
+	var broken bool
+	err := db.Raw(`
+		UPDATE resources SET
+			link_status = ?,
+			link_error = ?,
+			link_checked_at = ?,
+			link_failures = CASE WHEN ? THEN 0 ELSE link_failures + 1 END,
+			link_broken = CASE WHEN ? THEN FALSE ELSE link_failures + 1 >= ? END
+		WHERE id = ?
+		RETURNING link_broken
+	`, status, checkErr, at, ok, ok, failureLimit, resourceID).Scan(&broken).Error
+	return broken, err
+}
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"M-AI/api/dto"
+	"html"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type SearchRepository struct{}
+
+// Matched terms are marked with private-use characters rather than tags, so
+// the text can be HTML-escaped before the marks become <mark> tags. Any found
+// in the documents themselves are stripped first.
+const (
+	headlineStart = "\uE000"
+	headlineStop  = "\uE001"
+)
+
+// headlineOptions keeps snippets short and marks matched terms.
+const headlineOptions = `StartSel="` + headlineStart + `", StopSel="` + headlineStop + `"` +
+	", MaxWords=30, MinWords=10, MaxFragments=2, FragmentDelimiter=\" … \""
+
+var headlineMarks = strings.NewReplacer(headlineStart, "<mark>", headlineStop, "</mark>")
+
+// markHeadline escapes a ts_headline result for HTML and turns its marks
+// into <mark> tags, so stored text cannot inject markup into results.
+func markHeadline(headline string) string {
+	return headlineMarks.Replace(html.EscapeString(headline))
+}
+
+// Search ranks quizzes, questions, problems and resources together against a
+// web-style query. Only the requested page is highlighted, since ts_headline
+// re-parses the document. An empty kind searches every type. Today's
+// challenge problems are left out.
+func (r *SearchRepository) Search(db *gorm.DB, query, kind string, limit, offset int) ([]dto.SearchResult, error) {
+	var results []dto.SearchResult
+
+	err := db.Raw(`
+		WITH query AS (
+			SELECT websearch_to_tsquery('english', ?) AS q
+		),
+		hits AS (
+			SELECT 'quiz' AS type, q.id, NULL::bigint AS quiz_id, q.title, q.description AS body,
+				ts_rank(q.search_vector, query.q) AS rank
+			FROM quiz q, query
+			WHERE q.deleted_at IS NULL AND q.search_vector @@ query.q
+
+			UNION ALL
+
+			SELECT 'question', ques.id, ques.quiz_id, qz.title, ques.question,
+				ts_rank(ques.search_vector, query.q)
+			FROM question ques
+			JOIN quiz qz ON qz.id = ques.quiz_id AND qz.deleted_at IS NULL, query
+			WHERE ques.deleted_at IS NULL AND ques.search_vector @@ query.q
+
+			UNION ALL
+
+			SELECT 'problem', p.id, NULL, coalesce(p.title, ''), p.question,
+				ts_rank(p.search_vector, query.q)
+			FROM problem p, query
+			WHERE p.deleted_at IS NULL AND p.search_vector @@ query.q
+				AND p.answer <> ''
+				AND p.id NOT IN (`+todaysChallengeProblems+`)
+
+			UNION ALL
+
+			SELECT 'resource', res.id, NULL, res.title, res.description,
+				ts_rank(res.search_vector, query.q)
+			FROM resources res, query
+			WHERE res.deleted_at IS NULL AND NOT res.link_broken AND res.search_vector @@ query.q
+		),
+		page AS (
+			SELECT *, COUNT(*) OVER () AS total
+			FROM hits
+			WHERE ? = '' OR type = ?
+			ORDER BY rank DESC, type, id
+			LIMIT ? OFFSET ?
+		)
+		SELECT
+			page.type,
+			page.id,
+			page.quiz_id,
+			page.title,
+			ts_headline('english', translate(page.title, ?, ''), query.q, ?) AS highlight,
+			ts_headline('english', translate(coalesce(page.body, ''), ?, ''), query.q, ?) AS snippet,
+			page.rank,
+			page.total
+		FROM page, query
+		ORDER BY page.rank DESC, page.type, page.id
+	`, query, kind, kind, limit, offset,
+		headlineStart+headlineStop, headlineOptions, headlineStart+headlineStop, headlineOptions,
+	).Scan(&results).Error
+
+	for i := range results {
+		results[i].Highlight = markHeadline(results[i].Highlight)
+		results[i].Snippet = markHeadline(results[i].Snippet)
+	}
+	return results, err
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"M-AI/api/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type SessionRepository struct{}
+
+func (r *SessionRepository) Create(db *gorm.DB, session *model.UserSession) error {
+	return db.Create(session).Error
+}
+
+func (r *SessionRepository) GetByTokenHash(db *gorm.DB, hash string) (model.UserSession, error) {
+	var session model.UserSession
+	err := db.Where("token_hash = ?", hash).First(&session).Error
+	return session, err
+}
+
+// GetByPreviousHash finds the session whose last rotated-out refresh token
+// had this hash.
+func (r *SessionRepository) GetByPreviousHash(db *gorm.DB, hash string) (model.UserSession, error) {
+	var session model.UserSession
+	err := db.Where("previous_hash = ?", hash).First(&session).Error
+	return session, err
+}
+
+// Rotate replaces a session's refresh token and extends it. It reports false
+// if the token was rotated or the session revoked in the meantime.
+func (r *SessionRepository) Rotate(db *gorm.DB, sessionID uint, oldHash, newHash string, now, expiresAt time.Time) (bool, error) {
+	res := db.Exec(`
+		UPDATE user_session
+		SET previous_hash = token_hash, token_hash = ?, last_used_at = ?, expires_at = ?, updated_at = ?
+		WHERE id = ? AND token_hash = ? AND revoked_at IS NULL AND deleted_at IS NULL
+	`, newHash, now, expiresAt, now, sessionID, oldHash)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *SessionRepository) ListActive(db *gorm.DB, userID uint, now time.Time) ([]model.UserSession, error) {
+	var sessions []model.UserSession
+	err := db.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, now).
+		Order("last_used_at DESC").
+		Find(&sessions).Error
+	return sessions, err
+}
+
+// Revoke ends one of the user's sessions and reports whether it was active.
+func (r *SessionRepository) Revoke(db *gorm.DB, userID, sessionID uint, now time.Time) (bool, error) {
+	res := db.Model(&model.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+		Update("revoked_at", now)
+	return res.RowsAffected > 0, res.Error
+}
+
+// RevokeAll ends every session of the user except keepID, which may be zero.
+func (r *SessionRepository) RevokeAll(db *gorm.DB, userID, keepID uint, now time.Time) (int64, error) {
+	res := db.Model(&model.UserSession{}).
+		Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, keepID).
+		Update("revoked_at", now)
+	return res.RowsAffected, res.Error
+}
+
+// DeleteEnded removes sessions that expired or were revoked before cutoff.
+func (r *SessionRepository) DeleteEnded(db *gorm.DB, cutoff time.Time) (int64, error) {
+	res := db.Unscoped().
+		Where("expires_at < ? OR revoked_at < ?", cutoff, cutoff).
+		Delete(&model.UserSession{})
+	return res.RowsAffected, res.Error
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"M-AI/api/dto"
+	"gorm.io/gorm"
+	"time"
+)
+
+type StudyStreakRepository struct{}
+
+// sessionGapMinutes is the longest pause between two answers still counted
+// as practice time.
+const sessionGapMinutes = 10
+
+// GetStudyDays returns the user's activity per local day in timezone, oldest
+// first. Each answer or completed quiz adds the time since the previous one,
+// up to sessionGapMinutes, and the first in a session counts as one minute.
+func (r *StudyStreakRepository) GetStudyDays(db *gorm.DB, userID uint, timezone string) ([]dto.StudyDay, error) {
+	var days []dto.StudyDay
+
+	err := db.Raw(`
+		WITH events AS (
+			SELECT created_at, 1 AS question, 0 AS quiz
+			FROM user_log
+			WHERE user_id = ? AND deleted_at IS NULL
+
+			UNION ALL
+
+			SELECT created_at, 0 AS question, 1 AS quiz
+			FROM quiz_log
+			WHERE user_id = ? AND deleted_at IS NULL
+		),
+		gaps AS (
+			SELECT
+				created_at,
+				question,
+				quiz,
+				EXTRACT(EPOCH FROM created_at - LAG(created_at) OVER (ORDER BY created_at)) / 60 AS gap
+			FROM events
+		)
+		SELECT
+			(created_at AT TIME ZONE ?)::date AS day,
+			SUM(question) AS questions,
+			SUM(quiz) AS quizzes,
+			ROUND(SUM(CASE WHEN gap IS NULL OR gap > ? THEN 1 ELSE gap END)::numeric, 1) AS minutes
+		FROM gaps
+		GROUP BY 1
+		ORDER BY 1
+	`, userID, userID, timezone, sessionGapMinutes).Scan(&days).Error
+
+	return days, err
+}
+
+// GetStreakCandidates returns users active since the given time, the only
+// ones who can have a streak to lose.
+func (r *StudyStreakRepository) GetStreakCandidates(db *gorm.DB, since time.Time) ([]dto.StreakCandidate, error) {
+	var result []dto.StreakCandidate
+
+	err := db.Raw(`
+		SELECT u.id AS user_id, u.timezone, u.daily_goal_type, u.daily_goal_target
+		FROM users u
+		WHERE u.deleted_at IS NULL AND (
+			EXISTS (SELECT 1 FROM user_log ul WHERE ul.user_id = u.id AND ul.created_at >= ?)
+			OR EXISTS (SELECT 1 FROM quiz_log ql WHERE ql.user_id = u.id AND ql.created_at >= ?)
+		)
+	`, since, since).Scan(&result).Error
+
+	return result, err
+}
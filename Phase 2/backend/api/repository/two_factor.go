@@ -0,0 +1,119 @@
+package repository
+
+import (
+	"M-AI/api/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type TwoFactorRepository struct{}
+
+func (r *TwoFactorRepository) GetTOTP(db *gorm.DB, userID uint) (model.UserTOTP, error) {
+	var totp model.UserTOTP
+	err := db.Where("user_id = ?", userID).First(&totp).Error
+	return totp, err
+}
+
+// SavePendingTOTP stores a new, unconfirmed secret for the user, replacing an
+// earlier unconfirmed one. It reports false if the user already has a
+// confirmed authenticator, which is left alone.
+func (r *TwoFactorRepository) SavePendingTOTP(db *gorm.DB, userID uint, secret string, now time.Time) (bool, error) {
+	res := db.Exec(`
+		INSERT INTO user_totp (user_id, secret, created_at, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (user_id) DO UPDATE SET
+			secret = EXCLUDED.secret,
+			last_used_step = 0,
+			created_at = EXCLUDED.created_at,
+			updated_at = EXCLUDED.updated_at
+		WHERE user_totp.confirmed_at IS NULL
+	`, userID, secret, now, now)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *TwoFactorRepository) ConfirmTOTP(db *gorm.DB, userID uint, now time.Time) error {
+	return db.Model(&model.UserTOTP{}).
+		Where("user_id = ? AND confirmed_at IS NULL", userID).
+		Updates(map[string]interface{}{"confirmed_at": now, "updated_at": now}).Error
+}
+
+// UseStep records that a code for step was accepted. It reports false if a
+// code for this or a later step was already used, so each code works once.
+func (r *TwoFactorRepository) UseStep(db *gorm.DB, userID uint, step int64) (bool, error) {
+	res := db.Model(&model.UserTOTP{}).
+		Where("user_id = ? AND last_used_step < ?", userID, step).
+		Update("last_used_step", step)
+	return res.RowsAffected > 0, res.Error
+}
+
+// DeleteTOTP removes the user's authenticator and recovery codes.
+func (r *TwoFactorRepository) DeleteTOTP(db *gorm.DB, userID uint) (bool, error) {
+	res := db.Where("user_id = ?", userID).Delete(&model.UserTOTP{})
+	if res.Error != nil {
+		return false, res.Error
+	}
+	return res.RowsAffected > 0, db.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error
+}
+
+// ReplaceRecoveryCodes swaps the user's recovery codes for new ones.
+func (r *TwoFactorRepository) ReplaceRecoveryCodes(db *gorm.DB, userID uint, hashes []string) error {
+	if err := db.Unscoped().Where("user_id = ?", userID).Delete(&model.RecoveryCode{}).Error; err != nil {
+		return err
+	}
+	codes := make([]model.RecoveryCode, len(hashes))
+	for i, hash := range hashes {
+		codes[i] = model.RecoveryCode{UserID: userID, CodeHash: hash}
+	}
+	return db.Create(&codes).Error
+}
+
+// UseRecoveryCode marks one of the user's unused codes as used and reports
+// whether there was one to use.
+func (r *TwoFactorRepository) UseRecoveryCode(db *gorm.DB, userID uint, hash string, now time.Time) (bool, error) {
+	res := db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hash).
+		Update("used_at", now)
+	return res.RowsAffected > 0, res.Error
+}
+
+func (r *TwoFactorRepository) CountRecoveryCodes(db *gorm.DB, userID uint) (int64, error) {
+	var count int64
+	err := db.Model(&model.RecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", userID).
+		Count(&count).Error
+	return count, err
+}
+
+type TwoFactorState struct {
+	Enabled  bool
+	Required bool
+}
+
+// GetState reports whether the user has a confirmed authenticator and
+// whether their role requires one.
+func (r *TwoFactorRepository) GetState(db *gorm.DB, userID uint, role string) (TwoFactorState, error) {
+	var state TwoFactorState
+	err := db.Raw(`
+		SELECT
+			EXISTS (SELECT 1 FROM user_totp WHERE user_id = ? AND confirmed_at IS NOT NULL) AS enabled,
+			EXISTS (SELECT 1 FROM two_factor_policy WHERE role = ? AND required) AS required
+	`, userID, role).Scan(&state).Error
+	return state, err
+}
+
+func (r *TwoFactorRepository) ListPolicies(db *gorm.DB) ([]model.TwoFactorPolicy, error) {
+	var policies []model.TwoFactorPolicy
+	err := db.Order("role").Find(&policies).Error
+	return policies, err
+}
+
+func (r *TwoFactorRepository) SetPolicy(db *gorm.DB, role string, required bool, updatedBy uint, now time.Time) error {
+	return db.Exec(`
+		INSERT INTO two_factor_policy (role, required, updated_at, updated_by)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT (role) DO UPDATE SET
+			required = EXCLUDED.required,
+			updated_at = EXCLUDED.updated_at,
+			updated_by = EXCLUDED.updated_by
+	`, role, required, now, updatedBy).Error
+}
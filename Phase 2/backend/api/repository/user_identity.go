@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"M-AI/api/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserIdentityRepository struct{}
+
+func (r *UserIdentityRepository) Create(db *gorm.DB, identity *model.UserIdentity) error {
+	return db.Create(identity).Error
+}
+
+func (r *UserIdentityRepository) Get(db *gorm.DB, issuer, subject string) (model.UserIdentity, error) {
+	var identity model.UserIdentity
+	err := db.Where("issuer = ? AND subject = ?", issuer, subject).First(&identity).Error
+	return identity, err
+}
+
+// RecordLogin notes a sign-in with the identity and the email the provider
+// gave for it.
+func (r *UserIdentityRepository) RecordLogin(db *gorm.DB, identityID uint, email string, now time.Time) error {
+	return db.Model(&model.UserIdentity{}).
+		Where("id = ?", identityID).
+		Updates(map[string]interface{}{"email": email, "last_login_at": now}).Error
+}
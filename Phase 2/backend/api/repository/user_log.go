@@ -1,19 +1,73 @@
 package repository
 
 import (
+	"M-AI/api/constants"
 	"M-AI/api/model"
 	"gorm.io/gorm"
+	"time"
 )
 
-type UserLogRepository struct{}
+// UserLogRepository keeps topic mastery and the dashboard totals in step with
+// the log: every insert is traced in the same transaction.
+type UserLogRepository struct {
+	mastery MasteryRepository
+	stats   StatsRepository
+}
+
+// tracedAnswer is a logged answer resolved to the topic it practised.
+type tracedAnswer struct {
+	ID        uint
+	UserID    uint
+	Topic     constants.TopicEnum
+	FromQuiz  bool
+	Correct   bool
+	CreatedAt time.Time
+}
 
 func (r *UserLogRepository) Create(db *gorm.DB, log *model.UserLog) error {
-	return db.Create(log).Error
+	if err := db.Create(log).Error; err != nil {
+		return err
+	}
+	return r.trace(db, []model.UserLog{*log})
 }
 
 func (r *UserLogRepository) CreateBatch(db *gorm.DB, logs []model.UserLog) error {
 	if len(logs) == 0 {
 		return nil
 	}
-	return db.Create(&logs).Error
+	if err := db.Create(&logs).Error; err != nil {
+		return err
+	}
+	return r.trace(db, logs)
+}
+
+func (r *UserLogRepository) trace(db *gorm.DB, logs []model.UserLog) error {
+	ids := make([]uint, 0, len(logs))
+	for _, l := range logs {
+		ids = append(ids, l.ID)
+	}
+
+	var answers []tracedAnswer
+	err := db.Raw(`
+		SELECT
+			ul.id,
+			ul.user_id,
+			COALESCE(q.topic, p.topic) AS topic,
+			ul.from_quiz,
+			ul.correct_answer AS correct,
+			ul.created_at
+		FROM user_log ul
+		LEFT JOIN question q ON ul.question_id = q.id
+		LEFT JOIN problem p ON ul.problem_id = p.id
+		WHERE ul.id IN ? AND COALESCE(q.topic, p.topic) IS NOT NULL
+		ORDER BY ul.id
+	`, ids).Scan(&answers).Error
+	if err != nil || len(answers) == 0 {
+		return err
+	}
+
+	if err := r.mastery.Record(db, answers); err != nil {
+		return err
+	}
+	return r.stats.AddAnswers(db, answers)
 }
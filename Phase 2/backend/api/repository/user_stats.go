@@ -0,0 +1,118 @@
+package repository
+
+import (
+	"M-AI/api/constants"
+	"gorm.io/gorm"
+	"time"
+)
+
+// StatsRepository maintains user_topic_stats and user_stats, the running
+// totals the dashboard reads instead of scanning a user's whole history.
+type StatsRepository struct{}
+
+const upsertTopicStats = `
+	INSERT INTO user_topic_stats AS s (user_id, topic, attempts, marks_awarded, marks_available, last_attempt_at, updated_at)
+	VALUES (?, ?, ?, ?, ?, ?, NOW())
+	ON CONFLICT (user_id, topic) DO UPDATE SET
+		attempts = s.attempts + EXCLUDED.attempts,
+		marks_awarded = s.marks_awarded + EXCLUDED.marks_awarded,
+		marks_available = s.marks_available + EXCLUDED.marks_available,
+		last_attempt_at = GREATEST(s.last_attempt_at, EXCLUDED.last_attempt_at),
+		updated_at = NOW()
+`
+
+func (r *StatsRepository) AddAnswers(db *gorm.DB, answers []tracedAnswer) error {
+	type key struct {
+		userID uint
+		topic  constants.TopicEnum
+	}
+	type delta struct {
+		attempts, correct int
+		last              time.Time
+	}
+	deltas := make(map[key]*delta)
+	var order []key
+	for _, a := range answers {
+		k := key{a.UserID, a.Topic}
+		d, ok := deltas[k]
+		if !ok {
+			d = &delta{}
+			deltas[k] = d
+			order = append(order, k)
+		}
+		d.attempts++
+		if a.Correct {
+			d.correct++
+		}
+		if a.CreatedAt.After(d.last) {
+			d.last = a.CreatedAt
+		}
+	}
+
+	for _, k := range order {
+		d := deltas[k]
+		err := db.Exec(upsertTopicStats, k.userID, string(k.topic), d.attempts, d.correct, d.attempts, d.last).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddMockExamAttempt adds a submitted mock exam's answers, by their marks.
+// It must run after the answers are saved in the same transaction.
+func (r *StatsRepository) AddMockExamAttempt(db *gorm.DB, attemptID uint) error {
+	return db.Exec(`
+		INSERT INTO user_topic_stats AS s (user_id, topic, attempts, marks_awarded, marks_available, last_attempt_at, updated_at)
+		SELECT user_id, topic, COUNT(*), SUM(marks_awarded), SUM(marks_available), MAX(created_at), NOW()
+		FROM attempt
+		WHERE mock_exam_attempt_id = ?
+		GROUP BY user_id, topic
+		ON CONFLICT (user_id, topic) DO UPDATE SET
+			attempts = s.attempts + EXCLUDED.attempts,
+			marks_awarded = s.marks_awarded + EXCLUDED.marks_awarded,
+			marks_available = s.marks_available + EXCLUDED.marks_available,
+			last_attempt_at = GREATEST(s.last_attempt_at, EXCLUDED.last_attempt_at),
+			updated_at = NOW()
+	`, attemptID).Error
+}
+
+func (r *StatsRepository) AddQuizCompletion(db *gorm.DB, userID uint) error {
+	return db.Exec(`
+		INSERT INTO user_stats AS s (user_id, quizzes_completed, updated_at)
+		VALUES (?, 1, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			quizzes_completed = s.quizzes_completed + 1,
+			updated_at = NOW()
+	`, userID).Error
+}
+
+// Rebuild recomputes the totals from the attempt view and quiz_log, for one
+// user or, when userID is nil, for everyone.
+func (r *StatsRepository) Rebuild(db *gorm.DB, userID *uint) error {
+	filter, args := "TRUE", []interface{}{}
+	if userID != nil {
+		filter, args = "user_id = ?", []interface{}{*userID}
+	}
+
+	steps := []string{
+		`DELETE FROM user_topic_stats WHERE ` + filter,
+		`DELETE FROM user_stats WHERE ` + filter,
+		`INSERT INTO user_topic_stats (user_id, topic, attempts, marks_awarded, marks_available, last_attempt_at, updated_at)
+		SELECT user_id, topic, COUNT(*), SUM(marks_awarded), SUM(marks_available), MAX(created_at), NOW()
+		FROM attempt
+		WHERE ` + filter + `
+		GROUP BY user_id, topic`,
+		`INSERT INTO user_stats (user_id, quizzes_completed, updated_at)
+		SELECT user_id, COUNT(*), NOW()
+		FROM quiz_log
+		WHERE deleted_at IS NULL AND ` + filter + `
+		GROUP BY user_id`,
+	}
+	for _, step := range steps {
+		if err := db.Exec(step, args...).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
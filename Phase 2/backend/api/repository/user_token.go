@@ -0,0 +1,46 @@
+package repository
+
+import (
+	"M-AI/api/model"
+	"gorm.io/gorm"
+	"time"
+)
+
+type UserTokenRepository struct{}
+
+func (r *UserTokenRepository) Create(db *gorm.DB, token *model.UserToken) error {
+	return db.Create(token).Error
+}
+
+// Consume marks an unused, unexpired token as used and returns it. It fails
+// with gorm.ErrRecordNotFound for unknown, used or expired tokens, so each
+// token works exactly once.
+func (r *UserTokenRepository) Consume(db *gorm.DB, purpose, hash string, now time.Time) (model.UserToken, error) {
+	var token model.UserToken
+	res := db.Raw(`
+		UPDATE user_token
+		SET used_at = ?, updated_at = ?
+		WHERE token_hash = ? AND purpose = ? AND used_at IS NULL AND expires_at > ? AND deleted_at IS NULL
+		RETURNING *
+	`, now, now, hash, purpose, now).Scan(&token)
+	if res.Error == nil && res.RowsAffected == 0 {
+		return token, gorm.ErrRecordNotFound
+	}
+	return token, res.Error
+}
+
+// Invalidate uses up every outstanding token of the user for purpose.
+func (r *UserTokenRepository) Invalidate(db *gorm.DB, userID uint, purpose string, now time.Time) error {
+	return db.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", now).Error
+}
+
+// CountRecent counts the tokens issued to the user for purpose since.
+func (r *UserTokenRepository) CountRecent(db *gorm.DB, userID uint, purpose string, since time.Time) (int64, error) {
+	var count int64
+	err := db.Model(&model.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND created_at >= ?", userID, purpose, since).
+		Count(&count).Error
+	return count, err
+}
@@ -1,8 +1,33 @@
 package requests
 
+import "time"
+
+// LoginRequest signs a user in. Clients that cannot keep cookies, such as the
+// mobile app, set ReturnTokens to get the tokens in the response body and
+// send the access token in an "Authorization: Bearer" header.
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required"`
+	Email        string `json:"email" binding:"required,email"`
+	Password     string `json:"password" binding:"required"`
+	ReturnTokens bool   `json:"return_tokens"`
+}
+
+// TwoFactorLoginRequest is the second sign-in step, with the token from the
+// first. It takes a code from the authenticator app or a recovery code.
+type TwoFactorLoginRequest struct {
+	MFAToken     string `json:"mfa_token" binding:"required"`
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+	ReturnTokens bool   `json:"return_tokens"`
+}
+
+type MFATokenRequest struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+}
+
+// RefreshRequest carries the refresh token of a client that keeps its own
+// tokens. Browsers send it in a cookie instead.
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
 }
 
 type SignupRequest struct {
@@ -17,3 +42,56 @@ type ChangePasswordRequest struct {
 	OldPassword string `json:"old_password" binding:"required"`
 	NewPassword string `json:"new_password" binding:"required,min=6"`
 }
+
+type UpdateStudyGoalRequest struct {
+	GoalType   string `json:"goal_type" binding:"required,oneof=questions minutes"`
+	GoalTarget int    `json:"goal_target" binding:"required,min=1,max=1440"`
+	Timezone   string `json:"timezone" binding:"required"`
+}
+
+type SetRoleRequest struct {
+	Role string `json:"role" binding:"required,oneof=student teacher admin"`
+}
+
+type VerifyEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6"`
+}
+
+// ListLoginAttemptsRequest filters the sign-in audit log. The owner filter
+// selects attempts on a user's account, and the dates when they were made.
+type ListLoginAttemptsRequest struct {
+	Email   string `form:"email"`
+	Outcome string `form:"outcome" binding:"omitempty,oneof=success failed locked reset challenged"`
+	FilterRequest
+	PageRequest
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string     `json:"name" binding:"required,max=100"`
+	Scopes    []string   `json:"scopes" binding:"required,min=1,dive,oneof=read write"`
+	ExpiresAt *time.Time `json:"expires_at"`
+}
+
+type TOTPCodeRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactorRequest proves the user still holds the second factor,
+// with a code from the authenticator app or a recovery code.
+type DisableTwoFactorRequest struct {
+	Code         string `json:"code" binding:"required_without=RecoveryCode"`
+	RecoveryCode string `json:"recovery_code"`
+}
+
+type SetTwoFactorPolicyRequest struct {
+	Required *bool `json:"required" binding:"required"`
+}
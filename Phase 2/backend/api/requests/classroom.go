@@ -0,0 +1,48 @@
+package requests
+
+import "time"
+
+type ClassroomRequest struct {
+	Name        string `json:"name" binding:"required,max=100"`
+	Description string `json:"description" binding:"max=500"`
+}
+
+type JoinClassroomRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type AddTeacherRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ListClassroomsRequest struct {
+	PageRequest
+}
+
+type ListClassStudentsRequest struct {
+	FilterRequest
+	PageRequest
+}
+
+// AssignmentRequest sets a quiz for a class. Without an open date the
+// assignment opens immediately; without max_attempts any number of attempts
+// is allowed.
+type AssignmentRequest struct {
+	QuizID      uint       `json:"quiz_id" binding:"required"`
+	OpenAt      *time.Time `json:"open_at"`
+	DueAt       time.Time  `json:"due_at" binding:"required"`
+	MaxAttempts *int       `json:"max_attempts" binding:"omitempty,min=1,max=100"`
+}
+
+// UpdateAssignmentRequest changes only the fields given. A max_attempts of 0
+// removes the attempt limit.
+type UpdateAssignmentRequest struct {
+	OpenAt      *time.Time `json:"open_at"`
+	DueAt       *time.Time `json:"due_at"`
+	MaxAttempts *int       `json:"max_attempts" binding:"omitempty,min=0,max=100"`
+}
+
+type ListAssignmentsRequest struct {
+	FilterRequest
+	PageRequest
+}
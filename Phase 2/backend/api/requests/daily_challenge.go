@@ -0,0 +1,15 @@
+package requests
+
+type DailyChallengeRequest struct {
+	Level string `form:"level" binding:"omitempty,oneof=beginner intermediate advanced"`
+}
+
+type DailyLeaderboardRequest struct {
+	Level string `form:"level" binding:"omitempty,oneof=beginner intermediate advanced"`
+	Date  string `form:"date" binding:"omitempty,datetime=2006-01-02"`
+}
+
+type DailyChallengeAttemptRequest struct {
+	Level  string `json:"level" binding:"omitempty,oneof=beginner intermediate advanced"`
+	Answer string `json:"answer" binding:"required"`
+}
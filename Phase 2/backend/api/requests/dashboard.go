@@ -0,0 +1,13 @@
+package requests
+
+type PredictedGradeRequest struct {
+	Tier string `form:"tier" binding:"omitempty,oneof=foundation higher"`
+}
+
+type ProgressRequest struct {
+	Bucket string `form:"bucket" binding:"omitempty,oneof=day week month"`
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Window int    `form:"window" binding:"omitempty,min=1,max=90"`
+	Topic  string `form:"topic"`
+}
@@ -0,0 +1,22 @@
+package requests
+
+// PageRequest selects a page of a list. Cursor is the next_cursor of the
+// previous page; Sort must be one the list allows, and Order defaults to the
+// sort's natural direction.
+type PageRequest struct {
+	Cursor string `form:"cursor"`
+	Limit  int    `form:"limit" binding:"omitempty,min=1,max=100"`
+	Sort   string `form:"sort"`
+	Order  string `form:"order" binding:"omitempty,oneof=asc desc"`
+}
+
+// FilterRequest holds the filters shared by lists. Not every list supports
+// every filter. From and To are inclusive dates; Owner is a user ID or "me".
+type FilterRequest struct {
+	Topic  string `form:"topic"`
+	Level  string `form:"level" binding:"omitempty,oneof=beginner intermediate advanced"`
+	Status string `form:"status" binding:"omitempty,oneof=completed not_completed"`
+	From   string `form:"from" binding:"omitempty,datetime=2006-01-02"`
+	To     string `form:"to" binding:"omitempty,datetime=2006-01-02"`
+	Owner  string `form:"owner"`
+}
@@ -0,0 +1,40 @@
+package requests
+
+import "M-AI/api/constants"
+
+type CreateMockExamRequest struct {
+	Title            string                          `json:"title" binding:"required"`
+	Description      string                          `json:"description"`
+	Tier             string                          `json:"tier" binding:"required,oneof=foundation higher"`
+	Paper            string                          `json:"paper" binding:"required,oneof=calculator non-calculator"`
+	TimeLimitMinutes int                             `json:"time_limit_minutes" binding:"required,min=1,max=300"`
+	Questions        []CreateMockExamQuestionRequest `json:"questions" binding:"required,min=1,dive"`
+	Boundaries       []GradeBoundaryRequest          `json:"boundaries" binding:"omitempty,dive"`
+}
+
+type CreateMockExamQuestionRequest struct {
+	Question            string              `json:"question" binding:"required"`
+	Answer              string              `json:"answer" binding:"required"`
+	AnswerType          string              `json:"answer_type" binding:"omitempty,oneof=exact numeric ai"`
+	Tolerance           float64             `json:"tolerance" binding:"min=0"`
+	MarkScheme          string              `json:"mark_scheme"`
+	Marks               int                 `json:"marks" binding:"required,min=1,max=20"`
+	AssessmentObjective string              `json:"assessment_objective" binding:"required,oneof=AO1 AO2 AO3"`
+	Topic               constants.TopicEnum `json:"topic" binding:"required"`
+}
+
+type GradeBoundaryRequest struct {
+	Grade   int `json:"grade" binding:"required,min=1,max=9"`
+	MinMark int `json:"min_mark" binding:"min=0"`
+}
+
+type ListMockExamsRequest struct {
+	Tier  string `form:"tier" binding:"omitempty,oneof=foundation higher"`
+	Paper string `form:"paper" binding:"omitempty,oneof=calculator non-calculator"`
+	FilterRequest
+	PageRequest
+}
+
+type SubmitMockExamRequest struct {
+	Answers map[uint]string `json:"answers" binding:"required"`
+}
@@ -3,3 +3,12 @@ package requests
 type CreateProblemRequest struct {
 	Question string `json:"question" binding:"required"`
 }
+
+type ListProblemsRequest struct {
+	FilterRequest
+	PageRequest
+}
+
+type SubmitProblemAnswerRequest struct {
+	Answer string `json:"answer" binding:"required"`
+}
@@ -17,4 +17,21 @@ type CreateQuestionRequest struct {
 	AnswerC  string              `json:"answer_c" binding:"required"`
 	AnswerD  string              `json:"answer_d" binding:"required"`
 	Topic    constants.TopicEnum `json:"topic" binding:"required"`
+	Solution string              `json:"solution"`
+}
+
+// ListQuizzesRequest keeps the older filter=completed parameter working
+// alongside status. Assigned narrows the list to quizzes currently assigned
+// to the user through their classes.
+type ListQuizzesRequest struct {
+	Search   string `form:"search"`
+	Filter   string `form:"filter" binding:"omitempty,oneof=all completed"`
+	Assigned bool   `form:"assigned"`
+	FilterRequest
+	PageRequest
+}
+
+type ExportQuizRequest struct {
+	Answers string `form:"answers" binding:"omitempty,oneof=none key solutions"`
+	Seed    int64  `form:"seed"`
 }
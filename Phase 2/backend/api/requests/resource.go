@@ -0,0 +1,40 @@
+package requests
+
+type ListResourcesRequest struct {
+	Search string `form:"search"`
+	FilterRequest
+	PageRequest
+}
+
+type SavedResourcesRequest struct {
+	FilterRequest
+	PageRequest
+}
+
+type AdminListResourcesRequest struct {
+	Broken *bool `form:"broken"`
+	FilterRequest
+	PageRequest
+}
+
+// RecommendedResourcesRequest pages through recommendations in rank order,
+// so it takes a cursor and limit but no sort.
+type RecommendedResourcesRequest struct {
+	Level string `form:"level" binding:"omitempty,oneof=beginner intermediate advanced"`
+	PageRequest
+}
+
+// ResourceRequest creates or replaces a resource. The yaml tags let the same
+// shape be used for bulk imports.
+type ResourceRequest struct {
+	Title           string   `json:"title" yaml:"title" binding:"required"`
+	Link            string   `json:"link" yaml:"link" binding:"required,url"`
+	Level           string   `json:"level" yaml:"level" binding:"required"`
+	Topics          []string `json:"topics" yaml:"topics" binding:"required,min=1"`
+	Description     string   `json:"description" yaml:"description"`
+	LinkDescription string   `json:"link_description" yaml:"link_description"`
+}
+
+type ImportResourcesRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv yaml"`
+}
@@ -0,0 +1,9 @@
+package requests
+
+// SearchRequest pages through results in rank order, so it takes a cursor
+// and limit but no sort.
+type SearchRequest struct {
+	Query string `form:"q" binding:"required,max=200"`
+	Type  string `form:"type" binding:"omitempty,oneof=quiz question problem resource"`
+	PageRequest
+}
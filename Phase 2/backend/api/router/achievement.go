@@ -0,0 +1,33 @@
+package router
+
+import (
+	"M-AI/api/service"
+	"M-AI/api/utils"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"github.com/gin-gonic/gin"
+)
+
+type AchievementRouter struct {
+	achievementService *service.AchievementService
+}
+
+func NewAchievementRouter(achievementService *service.AchievementService) *AchievementRouter {
+	return &AchievementRouter{achievementService: achievementService}
+}
+
+func (r *AchievementRouter) RegisterRoutes(router *gin.RouterGroup) {
+	achievementGroup := router.Group("/achievements", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
+	{
+		achievementGroup.GET("", r.ListAchievements)
+	}
+}
+
+func (r *AchievementRouter) ListAchievements(c *gin.Context) {
+	achievements, err := r.achievementService.ListForUser(getUserID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch achievements")
+		return
+	}
+	utils.SendSuccess(c, "Achievements fetched", achievements)
+}
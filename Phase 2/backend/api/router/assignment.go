@@ -0,0 +1,136 @@
+package router
+
+import (
+	"M-AI/api/requests"
+	"M-AI/api/service"
+	"M-AI/api/utils"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type AssignmentRouter struct {
+	assignmentService *service.AssignmentService
+}
+
+func NewAssignmentRouter(assignmentService *service.AssignmentService) *AssignmentRouter {
+	return &AssignmentRouter{assignmentService: assignmentService}
+}
+
+func (r *AssignmentRouter) RegisterRoutes(router *gin.RouterGroup) {
+	assignmentGroup := router.Group("/classes/:id/assignments", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
+	{
+		assignmentGroup.GET("", r.ListAssignments)
+		assignmentGroup.POST("", r.CreateAssignment)
+		assignmentGroup.PUT("/:assignmentId", r.UpdateAssignment)
+		assignmentGroup.DELETE("/:assignmentId", r.DeleteAssignment)
+		assignmentGroup.GET("/:assignmentId/results", r.GetResults)
+	}
+}
+
+func (r *AssignmentRouter) ListAssignments(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req requests.ListAssignmentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	assignments, err := r.assignmentService.ListAssignments(classID, getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch assignments")
+		return
+	}
+
+	utils.SendPage(c, "Assignments fetched successfully", assignments.Items, assignments.NextCursor)
+}
+
+func (r *AssignmentRouter) CreateAssignment(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req requests.AssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	assignment, err := r.assignmentService.CreateAssignment(classID, getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to create assignment")
+		return
+	}
+
+	utils.SendSuccess(c, "Assignment created successfully", assignment)
+}
+
+func (r *AssignmentRouter) UpdateAssignment(c *gin.Context) {
+	classID, assignmentID, ok := assignmentParams(c)
+	if !ok {
+		return
+	}
+
+	var req requests.UpdateAssignmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	assignment, err := r.assignmentService.UpdateAssignment(classID, assignmentID, getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to update assignment")
+		return
+	}
+
+	utils.SendSuccess(c, "Assignment updated successfully", assignment)
+}
+
+func (r *AssignmentRouter) DeleteAssignment(c *gin.Context) {
+	classID, assignmentID, ok := assignmentParams(c)
+	if !ok {
+		return
+	}
+
+	if err := r.assignmentService.DeleteAssignment(classID, assignmentID, getUserID(c)); err != nil {
+		sendServiceError(c, err, "Failed to delete assignment")
+		return
+	}
+
+	utils.SendSuccess(c, "Assignment deleted successfully", nil)
+}
+
+func (r *AssignmentRouter) GetResults(c *gin.Context) {
+	classID, assignmentID, ok := assignmentParams(c)
+	if !ok {
+		return
+	}
+
+	results, err := r.assignmentService.GetResults(classID, assignmentID, getUserID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch assignment results")
+		return
+	}
+
+	utils.SendSuccess(c, "Assignment results fetched successfully", results)
+}
+
+func assignmentParams(c *gin.Context) (uint, uint, bool) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return 0, 0, false
+	}
+	assignmentID, err := strconv.ParseUint(c.Param("assignmentId"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid assignment ID")
+		return 0, 0, false
+	}
+	return classID, uint(assignmentID), true
+}
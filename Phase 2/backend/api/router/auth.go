@@ -1,33 +1,92 @@
 package router
 
 import (
+	"M-AI/api/dto"
 	"M-AI/api/model"
 	"M-AI/api/requests"
 	"M-AI/api/service"
 	"M-AI/api/utils"
 	"M-AI/internal/config"
 	"M-AI/pkg/auth"
+	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	accessCookie   = "token"
+	refreshCookie  = "refresh_token"
+	ssoStateCookie = "sso_state"
 )
 
 type AuthRouter struct {
 	authService *service.AuthService
+	ssoService  *service.SSOService
+	// refreshPath scopes the refresh cookie to the auth routes, so it is not
+	// sent with every API request.
+	refreshPath string
 }
 
-func NewAuthRouter(authService *service.AuthService) *AuthRouter {
-	return &AuthRouter{authService: authService}
+func NewAuthRouter(authService *service.AuthService, ssoService *service.SSOService) *AuthRouter {
+	return &AuthRouter{authService: authService, ssoService: ssoService}
 }
 
 func (r *AuthRouter) RegisterRoutes(router *gin.RouterGroup) {
 	authGroup := router.Group("/auth")
+	r.refreshPath = authGroup.BasePath()
 	{
 		authGroup.POST("/login", r.Login)
+		authGroup.POST("/login/2fa", r.LoginSecondFactor)
+		authGroup.POST("/login/2fa/setup", r.BeginLoginEnrolment)
+		authGroup.POST("/refresh", r.Refresh)
 		authGroup.POST("/logout", r.Logout)
 		authGroup.POST("/signup", r.SignUp)
+		authGroup.POST("/verify-email", r.VerifyEmail)
+		authGroup.POST("/verify-email/resend", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), r.ResendVerification)
+		authGroup.POST("/password/forgot", r.ForgotPassword)
+		authGroup.POST("/password/reset", r.ResetPassword)
+		authGroup.GET("/oidc", r.ListSSOProviders)
+		authGroup.GET("/oidc/:provider", r.BeginSSOLogin)
+		authGroup.GET("/oidc/:provider/callback", r.CompleteSSOLogin)
 		authGroup.GET("/me", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), r.GetCurrentUser)
 		authGroup.PUT("/me/name", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), r.ChangeName)
-		authGroup.PUT("/me/password", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), r.ChangePassword)
+		authGroup.PUT("/me/password", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.RequireSession(), r.ChangePassword)
+		authGroup.PUT("/me/goal", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), r.UpdateStudyGoal)
+		authGroup.GET("/sessions", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.RequireSession(), r.ListSessions)
+		authGroup.DELETE("/sessions", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.RequireSession(), r.RevokeOtherSessions)
+		authGroup.DELETE("/sessions/:id", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.RequireSession(), r.RevokeSession)
+	}
+
+	// API keys cannot manage API keys, so a leaked key cannot mint more.
+	keyGroup := router.Group("/auth/api-keys", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.RequireSession())
+	{
+		keyGroup.GET("", r.ListAPIKeys)
+		keyGroup.POST("", r.CreateAPIKey)
+		keyGroup.DELETE("/:id", r.RevokeAPIKey)
+	}
+
+	twoFactorGroup := router.Group("/auth/2fa", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.RequireSession())
+	{
+		twoFactorGroup.GET("", r.TwoFactorStatus)
+		twoFactorGroup.POST("/setup", r.BeginTOTPEnrolment)
+		twoFactorGroup.POST("/confirm", r.ConfirmTOTPEnrolment)
+		twoFactorGroup.DELETE("", r.DisableTwoFactor)
+		twoFactorGroup.POST("/recovery-codes", r.RegenerateRecoveryCodes)
+	}
+
+	adminGroup := router.Group("/admin/users", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.Require(auth.ManageUsers))
+	{
+		adminGroup.PUT("/:id/role", r.SetRole)
+		adminGroup.DELETE("/:id/2fa", r.ResetTwoFactor)
+		adminGroup.GET("/login-attempts", r.ListLoginAttempts)
+	}
+
+	policyGroup := router.Group("/admin/2fa-policies", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.Require(auth.ManageUsers))
+	{
+		policyGroup.GET("", r.ListTwoFactorPolicies)
+		policyGroup.PUT("/:role", r.SetTwoFactorPolicy)
 	}
 }
 
@@ -53,6 +112,61 @@ func (r *AuthRouter) SignUp(c *gin.Context) {
 	utils.SendSuccess(c, "Signup successful", nil)
 }
 
+func (r *AuthRouter) VerifyEmail(c *gin.Context) {
+	var req requests.VerifyEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := r.authService.VerifyEmail(req.Token); err != nil {
+		sendServiceError(c, err, "Failed to verify email")
+		return
+	}
+
+	utils.SendSuccess(c, "Email verified successfully", nil)
+}
+
+func (r *AuthRouter) ResendVerification(c *gin.Context) {
+	if err := r.authService.ResendVerification(getUserID(c)); err != nil {
+		sendServiceError(c, err, "Failed to send verification email")
+		return
+	}
+
+	utils.SendSuccess(c, "Verification email sent", nil)
+}
+
+func (r *AuthRouter) ForgotPassword(c *gin.Context) {
+	var req requests.ForgotPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := r.authService.RequestPasswordReset(req.Email); err != nil {
+		sendServiceError(c, err, "Failed to request password reset")
+		return
+	}
+
+	utils.SendSuccess(c, "If an account exists for this email, a reset link has been sent", nil)
+}
+
+func (r *AuthRouter) ResetPassword(c *gin.Context) {
+	var req requests.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := r.authService.ResetPassword(req.Token, req.NewPassword); err != nil {
+		sendServiceError(c, err, "Failed to reset password")
+		return
+	}
+
+	r.clearSessionCookies(c)
+	utils.SendSuccess(c, "Password reset successfully", nil)
+}
+
 func (r *AuthRouter) Login(c *gin.Context) {
 	var req requests.LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -60,59 +174,182 @@ func (r *AuthRouter) Login(c *gin.Context) {
 		return
 	}
 
-	user, err := r.authService.AuthenticateUser(req.Email, req.Password)
+	user, challenge, err := r.authService.AuthenticateUser(req.Email, req.Password, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		sendServiceError(c, err, "Failed to log in")
+		return
+	}
+	if challenge != nil {
+		utils.SendSuccess(c, "Two-factor authentication required", challenge)
+		return
+	}
+
+	tokens, err := r.authService.StartSession(user, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		sendServiceError(c, err, "Failed to start session")
+		return
+	}
+
+	r.sendSession(c, "Login successful", tokens, req.ReturnTokens)
+}
+
+// LoginSecondFactor is the second sign-in step for users with two-factor
+// authentication, taking the token Login returned and a code.
+func (r *AuthRouter) LoginSecondFactor(c *gin.Context) {
+	var req requests.TwoFactorLoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	user, codes, err := r.authService.CompleteSecondFactor(req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		sendServiceError(c, err, "Failed to log in")
+		return
+	}
+
+	tokens, err := r.authService.StartSession(user, c.Request.UserAgent(), c.ClientIP())
 	if err != nil {
-		utils.SendError(c, http.StatusUnauthorized, "Invalid email or password")
+		sendServiceError(c, err, "Failed to start session")
 		return
 	}
+	tokens.RecoveryCodes = codes
+
+	r.sendSession(c, "Login successful", tokens, req.ReturnTokens)
+}
 
-	token, err := auth.GenerateToken(user.ID, config.AppConfig.Auth.SecretKey)
+// BeginLoginEnrolment sets up an authenticator for a user whose role requires
+// one, partway through signing in. They finish with LoginSecondFactor.
+func (r *AuthRouter) BeginLoginEnrolment(c *gin.Context) {
+	var req requests.MFATokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	enrolment, err := r.authService.BeginLoginEnrolment(req.MFAToken)
 	if err != nil {
-		utils.SendError(c, http.StatusInternalServerError, "Failed to generate token")
+		sendServiceError(c, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	utils.SendSuccess(c, "Scan the QR code with your authenticator app", enrolment)
+}
+
+func (r *AuthRouter) Refresh(c *gin.Context) {
+	refreshToken, inBody := refreshTokenFrom(c)
+	if refreshToken == "" {
+		utils.SendError(c, http.StatusUnauthorized, "Missing refresh token")
 		return
 	}
 
-	c.SetCookie(
-		"token",
-		token,
-		86400,
-		"/",
-		"",
-		true,
-		true,
-	)
+	tokens, err := r.authService.RefreshSession(refreshToken, c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		// A conflict means another request just rotated the token and set
+		// newer cookies, which must be kept.
+		var serr *service.ServiceError
+		if !errors.As(err, &serr) || serr.Code != "conflict" {
+			r.clearSessionCookies(c)
+		}
+		sendServiceError(c, err, "Failed to refresh session")
+		return
+	}
 
-	utils.SendSuccess(c, "Login successful", nil)
+	r.sendSession(c, "Session refreshed", tokens, inBody)
 }
 
 func (r *AuthRouter) Logout(c *gin.Context) {
-	c.SetCookie(
-		"token",
-		"",
-		-1,
-		"/",
-		"",
-		true,
-		true,
-	)
+	if refreshToken, _ := refreshTokenFrom(c); refreshToken != "" {
+		if err := r.authService.EndSession(refreshToken); err != nil {
+			sendServiceError(c, err, "Failed to log out")
+			return
+		}
+	}
 
+	r.clearSessionCookies(c)
 	utils.SendSuccess(c, "Logged out successfully", nil)
 }
 
-func (r *AuthRouter) GetCurrentUser(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.SendError(c, http.StatusUnauthorized, "Unauthorized")
+func (r *AuthRouter) ListSessions(c *gin.Context) {
+	sessions, err := r.authService.ListSessions(getUserID(c), auth.GetSessionID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch sessions")
 		return
 	}
 
-	userIDFloat, ok := userID.(float64)
-	if !ok {
-		utils.SendError(c, http.StatusInternalServerError, "Invalid user ID format")
+	utils.SendSuccess(c, "Sessions fetched successfully", sessions)
+}
+
+func (r *AuthRouter) RevokeSession(c *gin.Context) {
+	sessionID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid session ID")
 		return
 	}
 
-	user, err := r.authService.GetUserByID(uint(userIDFloat))
+	if err := r.authService.RevokeSession(getUserID(c), uint(sessionID)); err != nil {
+		sendServiceError(c, err, "Failed to revoke session")
+		return
+	}
+	if uint(sessionID) == auth.GetSessionID(c) {
+		r.clearSessionCookies(c)
+	}
+
+	utils.SendSuccess(c, "Session revoked successfully", nil)
+}
+
+// RevokeOtherSessions signs the user out of every device but this one.
+func (r *AuthRouter) RevokeOtherSessions(c *gin.Context) {
+	count, err := r.authService.RevokeOtherSessions(getUserID(c), auth.GetSessionID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to revoke sessions")
+		return
+	}
+
+	utils.SendSuccess(c, "Sessions revoked successfully", gin.H{"revoked": count})
+}
+
+// refreshTokenFrom reads the refresh token from its cookie or, for clients
+// that keep their own tokens, the request body, and reports which.
+func refreshTokenFrom(c *gin.Context) (string, bool) {
+	if token, err := c.Cookie(refreshCookie); err == nil && token != "" {
+		return token, false
+	}
+	var req requests.RefreshRequest
+	if c.Request.ContentLength == 0 || c.ShouldBindJSON(&req) != nil {
+		return "", false
+	}
+	return req.RefreshToken, true
+}
+
+// sendSession hands a session's tokens to the client: in cookies for
+// browsers, or in the body for clients that send bearer tokens.
+func (r *AuthRouter) sendSession(c *gin.Context, message string, tokens dto.SessionTokens, inBody bool) {
+	if inBody {
+		utils.SendSuccess(c, message, dto.BearerTokens{
+			SessionTokens: tokens,
+			TokenType:     "Bearer",
+			AccessToken:   tokens.AccessToken,
+			RefreshToken:  tokens.RefreshToken,
+		})
+		return
+	}
+	r.setSessionCookies(c, tokens)
+	utils.SendSuccess(c, message, tokens)
+}
+
+func (r *AuthRouter) setSessionCookies(c *gin.Context, tokens dto.SessionTokens) {
+	c.SetCookie(accessCookie, tokens.AccessToken, int(time.Until(tokens.AccessExpiresAt).Seconds()), "/", "", true, true)
+	c.SetCookie(refreshCookie, tokens.RefreshToken, int(time.Until(tokens.RefreshExpiresAt).Seconds()), r.refreshPath, "", true, true)
+}
+
+func (r *AuthRouter) clearSessionCookies(c *gin.Context) {
+	c.SetCookie(accessCookie, "", -1, "/", "", true, true)
+	c.SetCookie(refreshCookie, "", -1, r.refreshPath, "", true, true)
+}
+
+func (r *AuthRouter) GetCurrentUser(c *gin.Context) {
+	user, err := r.authService.GetUserByID(getUserID(c))
 	if err != nil {
 		utils.SendError(c, http.StatusNotFound, "User not found")
 		return
@@ -128,15 +365,7 @@ func (r *AuthRouter) ChangeName(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.SendError(c, http.StatusUnauthorized, "Unauthorized")
-		return
-	}
-
-	userIDUint := uint(userID.(float64))
-
-	err := r.authService.ChangeUserName(userIDUint, req.Name)
+	err := r.authService.ChangeUserName(getUserID(c), req.Name)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to update name")
 		return
@@ -152,19 +381,257 @@ func (r *AuthRouter) ChangePassword(c *gin.Context) {
 		return
 	}
 
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.SendError(c, http.StatusUnauthorized, "Unauthorized")
+	err := r.authService.ChangeUserPassword(getUserID(c), auth.GetSessionID(c), req.OldPassword, req.NewPassword)
+	if err != nil {
+		utils.SendError(c, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	utils.SendSuccess(c, "Password changed successfully", nil)
+}
+
+func (r *AuthRouter) UpdateStudyGoal(c *gin.Context) {
+	var req requests.UpdateStudyGoalRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := r.authService.UpdateStudyGoal(getUserID(c), req); err != nil {
+		sendServiceError(c, err, "Failed to update study goal")
+		return
+	}
+
+	utils.SendSuccess(c, "Study goal updated successfully", nil)
+}
+
+func (r *AuthRouter) SetRole(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	var req requests.SetRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := r.authService.SetUserRole(uint(userID), req.Role); err != nil {
+		sendServiceError(c, err, "Failed to update role")
+		return
+	}
+
+	utils.SendSuccess(c, "Role updated successfully", nil)
+}
+
+func (r *AuthRouter) ListLoginAttempts(c *gin.Context) {
+	var req requests.ListLoginAttemptsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	attempts, err := r.authService.ListLoginAttempts(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch sign-in attempts")
 		return
 	}
 
-	userIDUint := uint(userID.(float64))
+	utils.SendPage(c, "Sign-in attempts fetched successfully", attempts.Items, attempts.NextCursor)
+}
 
-	err := r.authService.ChangeUserPassword(userIDUint, req.OldPassword, req.NewPassword)
+func (r *AuthRouter) ListAPIKeys(c *gin.Context) {
+	keys, err := r.authService.ListAPIKeys(getUserID(c))
 	if err != nil {
-		utils.SendError(c, http.StatusUnauthorized, err.Error())
+		sendServiceError(c, err, "Failed to fetch API keys")
 		return
 	}
 
-	utils.SendSuccess(c, "Password changed successfully", nil)
+	utils.SendSuccess(c, "API keys fetched successfully", keys)
+}
+
+func (r *AuthRouter) CreateAPIKey(c *gin.Context) {
+	var req requests.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	key, err := r.authService.CreateAPIKey(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to create API key")
+		return
+	}
+
+	utils.SendSuccess(c, "API key created successfully", key)
+}
+
+func (r *AuthRouter) RevokeAPIKey(c *gin.Context) {
+	keyID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid API key ID")
+		return
+	}
+
+	if err := r.authService.RevokeAPIKey(getUserID(c), uint(keyID)); err != nil {
+		sendServiceError(c, err, "Failed to revoke API key")
+		return
+	}
+
+	utils.SendSuccess(c, "API key revoked successfully", nil)
+}
+
+func (r *AuthRouter) TwoFactorStatus(c *gin.Context) {
+	status, err := r.authService.TwoFactorStatus(getUserID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch two-factor status")
+		return
+	}
+
+	utils.SendSuccess(c, "Two-factor status fetched successfully", status)
+}
+
+func (r *AuthRouter) BeginTOTPEnrolment(c *gin.Context) {
+	enrolment, err := r.authService.BeginTOTPEnrolment(getUserID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	utils.SendSuccess(c, "Scan the QR code with your authenticator app", enrolment)
+}
+
+func (r *AuthRouter) ConfirmTOTPEnrolment(c *gin.Context) {
+	var req requests.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	codes, err := r.authService.ConfirmTOTPEnrolment(getUserID(c), req.Code)
+	if err != nil {
+		sendServiceError(c, err, "Failed to set up two-factor authentication")
+		return
+	}
+
+	utils.SendSuccess(c, "Two-factor authentication turned on", dto.RecoveryCodes{Codes: codes})
+}
+
+func (r *AuthRouter) DisableTwoFactor(c *gin.Context) {
+	var req requests.DisableTwoFactorRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := r.authService.DisableTwoFactor(getUserID(c), req); err != nil {
+		sendServiceError(c, err, "Failed to turn off two-factor authentication")
+		return
+	}
+
+	utils.SendSuccess(c, "Two-factor authentication turned off", nil)
+}
+
+func (r *AuthRouter) RegenerateRecoveryCodes(c *gin.Context) {
+	var req requests.TOTPCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	codes, err := r.authService.RegenerateRecoveryCodes(getUserID(c), req.Code)
+	if err != nil {
+		sendServiceError(c, err, "Failed to create recovery codes")
+		return
+	}
+
+	utils.SendSuccess(c, "Recovery codes created successfully", dto.RecoveryCodes{Codes: codes})
+}
+
+func (r *AuthRouter) ResetTwoFactor(c *gin.Context) {
+	userID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := r.authService.ResetTwoFactor(uint(userID)); err != nil {
+		sendServiceError(c, err, "Failed to reset two-factor authentication")
+		return
+	}
+
+	utils.SendSuccess(c, "Two-factor authentication reset successfully", nil)
+}
+
+func (r *AuthRouter) ListTwoFactorPolicies(c *gin.Context) {
+	policies, err := r.authService.ListTwoFactorPolicies()
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch two-factor policies")
+		return
+	}
+
+	utils.SendSuccess(c, "Two-factor policies fetched successfully", policies)
+}
+
+func (r *AuthRouter) SetTwoFactorPolicy(c *gin.Context) {
+	var req requests.SetTwoFactorPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if err := r.authService.SetTwoFactorPolicy(getUserID(c), c.Param("role"), *req.Required); err != nil {
+		sendServiceError(c, err, "Failed to update two-factor policy")
+		return
+	}
+
+	utils.SendSuccess(c, "Two-factor policy updated successfully", nil)
+}
+
+func (r *AuthRouter) ListSSOProviders(c *gin.Context) {
+	utils.SendSuccess(c, "Sign-in providers fetched successfully", r.ssoService.Providers())
+}
+
+// BeginSSOLogin sends the browser to the provider's sign-in page. return_to
+// is the frontend path to land on once signed in.
+func (r *AuthRouter) BeginSSOLogin(c *gin.Context) {
+	authURL, state, err := r.ssoService.BeginLogin(c.Request.Context(), c.Param("provider"), c.Query("return_to"))
+	if err != nil {
+		sendServiceError(c, err, "Failed to start sign-in")
+		return
+	}
+
+	c.SetCookie(ssoStateCookie, state, 0, r.refreshPath+"/oidc", "", true, true)
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// CompleteSSOLogin is where the provider sends the browser back. It starts a
+// session and redirects to the frontend, or to its login page with the
+// reason signing in failed.
+func (r *AuthRouter) CompleteSSOLogin(c *gin.Context) {
+	state, _ := c.Cookie(ssoStateCookie)
+	c.SetCookie(ssoStateCookie, "", -1, r.refreshPath+"/oidc", "", true, true)
+
+	if c.Query("error") != "" {
+		c.Redirect(http.StatusFound, r.ssoService.FailureURL("Sign-in was cancelled or refused by the provider"))
+		return
+	}
+
+	tokens, next, err := r.ssoService.CompleteLogin(c.Request.Context(), c.Param("provider"), state, c.Query("state"), c.Query("code"), c.Request.UserAgent(), c.ClientIP())
+	if err != nil {
+		message := "Failed to sign in"
+		var serr *service.ServiceError
+		if errors.As(err, &serr) {
+			message = serr.Message
+		}
+		c.Redirect(http.StatusFound, r.ssoService.FailureURL(message))
+		return
+	}
+
+	if tokens.AccessToken != "" {
+		r.setSessionCookies(c, tokens)
+	}
+	c.Redirect(http.StatusFound, next)
 }
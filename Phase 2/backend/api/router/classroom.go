@@ -0,0 +1,239 @@
+package router
+
+import (
+	"M-AI/api/requests"
+	"M-AI/api/service"
+	"M-AI/api/utils"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type ClassroomRouter struct {
+	classroomService *service.ClassroomService
+}
+
+func NewClassroomRouter(classroomService *service.ClassroomService) *ClassroomRouter {
+	return &ClassroomRouter{classroomService: classroomService}
+}
+
+func (r *ClassroomRouter) RegisterRoutes(router *gin.RouterGroup) {
+	classGroup := router.Group("/classes", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
+	{
+		classGroup.GET("", r.ListClassrooms)
+		classGroup.POST("", auth.Require(auth.ManageClasses), r.CreateClassroom)
+		classGroup.POST("/join", r.JoinClassroom)
+		classGroup.GET("/:id", r.GetClassroom)
+		classGroup.PUT("/:id", r.UpdateClassroom)
+		classGroup.DELETE("/:id", r.DeleteClassroom)
+		classGroup.POST("/:id/join-code", r.RegenerateJoinCode)
+		classGroup.GET("/:id/students", r.ListStudents)
+		classGroup.DELETE("/:id/students/:userId", r.RemoveStudent)
+		classGroup.POST("/:id/teachers", r.AddTeacher)
+		classGroup.DELETE("/:id/teachers/:userId", r.RemoveTeacher)
+	}
+}
+
+func (r *ClassroomRouter) ListClassrooms(c *gin.Context) {
+	var req requests.ListClassroomsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	classes, err := r.classroomService.ListClassrooms(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch classes")
+		return
+	}
+
+	utils.SendPage(c, "Classes fetched successfully", classes.Items, classes.NextCursor)
+}
+
+func (r *ClassroomRouter) CreateClassroom(c *gin.Context) {
+	var req requests.ClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	class, err := r.classroomService.CreateClassroom(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to create class")
+		return
+	}
+
+	utils.SendSuccess(c, "Class created successfully", class)
+}
+
+func (r *ClassroomRouter) JoinClassroom(c *gin.Context) {
+	var req requests.JoinClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	class, err := r.classroomService.JoinClassroom(getUserID(c), req.Code)
+	if err != nil {
+		sendServiceError(c, err, "Failed to join class")
+		return
+	}
+
+	utils.SendSuccess(c, "Joined class successfully", class)
+}
+
+func (r *ClassroomRouter) GetClassroom(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	class, err := r.classroomService.GetClassroom(classID, getUserID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch class")
+		return
+	}
+
+	utils.SendSuccess(c, "Class fetched successfully", class)
+}
+
+func (r *ClassroomRouter) UpdateClassroom(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req requests.ClassroomRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	class, err := r.classroomService.UpdateClassroom(classID, getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to update class")
+		return
+	}
+
+	utils.SendSuccess(c, "Class updated successfully", class)
+}
+
+func (r *ClassroomRouter) DeleteClassroom(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	if err := r.classroomService.DeleteClassroom(classID, getUserID(c)); err != nil {
+		sendServiceError(c, err, "Failed to delete class")
+		return
+	}
+
+	utils.SendSuccess(c, "Class deleted successfully", nil)
+}
+
+func (r *ClassroomRouter) RegenerateJoinCode(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	class, err := r.classroomService.RegenerateJoinCode(classID, getUserID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to regenerate join code")
+		return
+	}
+
+	utils.SendSuccess(c, "Join code regenerated successfully", class)
+}
+
+func (r *ClassroomRouter) ListStudents(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req requests.ListClassStudentsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	students, err := r.classroomService.ListStudents(classID, getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch students")
+		return
+	}
+
+	utils.SendPage(c, "Students fetched successfully", students.Items, students.NextCursor)
+}
+
+func (r *ClassroomRouter) RemoveStudent(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+	studentID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := r.classroomService.RemoveStudent(classID, getUserID(c), uint(studentID)); err != nil {
+		sendServiceError(c, err, "Failed to remove student")
+		return
+	}
+
+	utils.SendSuccess(c, "Student removed successfully", nil)
+}
+
+func (r *ClassroomRouter) AddTeacher(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+
+	var req requests.AddTeacherRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	class, err := r.classroomService.AddTeacher(classID, getUserID(c), req.Email)
+	if err != nil {
+		sendServiceError(c, err, "Failed to add co-teacher")
+		return
+	}
+
+	utils.SendSuccess(c, "Co-teacher added successfully", class)
+}
+
+func (r *ClassroomRouter) RemoveTeacher(c *gin.Context) {
+	classID, ok := classIDParam(c)
+	if !ok {
+		return
+	}
+	teacherID, err := strconv.ParseUint(c.Param("userId"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid user ID")
+		return
+	}
+
+	if err := r.classroomService.RemoveTeacher(classID, getUserID(c), uint(teacherID)); err != nil {
+		sendServiceError(c, err, "Failed to remove co-teacher")
+		return
+	}
+
+	utils.SendSuccess(c, "Co-teacher removed successfully", nil)
+}
+
+func classIDParam(c *gin.Context) (uint, bool) {
+	classID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid class ID")
+		return 0, false
+	}
+	return uint(classID), true
+}
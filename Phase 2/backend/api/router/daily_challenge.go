@@ -0,0 +1,87 @@
+package router
+
+import (
+	"M-AI/api/requests"
+	"M-AI/api/service"
+	"M-AI/api/utils"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type DailyChallengeRouter struct {
+	dailyService *service.DailyChallengeService
+}
+
+func NewDailyChallengeRouter(dailyService *service.DailyChallengeService) *DailyChallengeRouter {
+	return &DailyChallengeRouter{dailyService: dailyService}
+}
+
+func (r *DailyChallengeRouter) RegisterRoutes(router *gin.RouterGroup) {
+	dailyGroup := router.Group("/daily", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
+	{
+		dailyGroup.GET("", r.GetToday)
+		dailyGroup.POST("/attempt", r.Attempt)
+		dailyGroup.GET("/leaderboard", r.GetLeaderboard)
+		dailyGroup.GET("/streak", r.GetStreak)
+	}
+}
+
+func (r *DailyChallengeRouter) GetToday(c *gin.Context) {
+	var req requests.DailyChallengeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	challenge, err := r.dailyService.GetToday(getUserID(c), req.Level)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch daily challenge")
+		return
+	}
+
+	utils.SendSuccess(c, "Daily challenge fetched", challenge)
+}
+
+func (r *DailyChallengeRouter) Attempt(c *gin.Context) {
+	var req requests.DailyChallengeAttemptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := r.dailyService.Attempt(getUserID(c), req.Level, req.Answer)
+	if err != nil {
+		sendServiceError(c, err, "Failed to submit attempt")
+		return
+	}
+
+	utils.SendSuccess(c, "Attempt recorded", result)
+}
+
+func (r *DailyChallengeRouter) GetLeaderboard(c *gin.Context) {
+	var req requests.DailyLeaderboardRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	entries, err := r.dailyService.GetLeaderboard(req.Level, req.Date)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch leaderboard")
+		return
+	}
+
+	utils.SendSuccess(c, "Leaderboard fetched", entries)
+}
+
+func (r *DailyChallengeRouter) GetStreak(c *gin.Context) {
+	streak, err := r.dailyService.GetStreak(getUserID(c))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch streak")
+		return
+	}
+
+	utils.SendSuccess(c, "Streak fetched", streak)
+}
@@ -1,20 +1,24 @@
 package router
 
 import (
+	"M-AI/api/requests"
 	"M-AI/api/service"
 	"M-AI/api/utils"
 	"M-AI/internal/config"
 	"M-AI/pkg/auth"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
 )
 
 type DashboardRouter struct {
 	dashboardService *service.DashboardService
+	streakService    *service.StudyStreakService
+	classroomService *service.ClassroomService
 }
 
-func NewDashboardRouter(dashboardService *service.DashboardService) *DashboardRouter {
-	return &DashboardRouter{dashboardService: dashboardService}
+func NewDashboardRouter(dashboardService *service.DashboardService, streakService *service.StudyStreakService, classroomService *service.ClassroomService) *DashboardRouter {
+	return &DashboardRouter{dashboardService: dashboardService, streakService: streakService, classroomService: classroomService}
 }
 
 func (r *DashboardRouter) RegisterRoutes(router *gin.RouterGroup) {
@@ -24,11 +28,18 @@ func (r *DashboardRouter) RegisterRoutes(router *gin.RouterGroup) {
 		dashboardGroup.GET("/recent", r.GetRecentActivity)
 		dashboardGroup.GET("/proficiency", r.GetTopicProficiency)
 		dashboardGroup.GET("/challenges", r.GetChallengingTopics)
+		dashboardGroup.GET("/predicted-grade", r.GetPredictedGrade)
+		dashboardGroup.GET("/progress", r.GetProgress)
+		dashboardGroup.GET("/progress/topics", r.GetTopicProgress)
+		dashboardGroup.GET("/streak", r.GetStreak)
 	}
 }
 
 func (r *DashboardRouter) GetStats(c *gin.Context) {
-	userID := getUserID(c)
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
 	stats, err := r.dashboardService.GetStats(userID)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to fetch stats")
@@ -38,7 +49,10 @@ func (r *DashboardRouter) GetStats(c *gin.Context) {
 }
 
 func (r *DashboardRouter) GetRecentActivity(c *gin.Context) {
-	userID := getUserID(c)
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
 	data, err := r.dashboardService.GetRecentActivity(userID)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to fetch recent activity")
@@ -48,7 +62,10 @@ func (r *DashboardRouter) GetRecentActivity(c *gin.Context) {
 }
 
 func (r *DashboardRouter) GetTopicProficiency(c *gin.Context) {
-	userID := getUserID(c)
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
 	data, err := r.dashboardService.GetTopicProficiency(userID)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to fetch topic proficiency")
@@ -58,7 +75,10 @@ func (r *DashboardRouter) GetTopicProficiency(c *gin.Context) {
 }
 
 func (r *DashboardRouter) GetChallengingTopics(c *gin.Context) {
-	userID := getUserID(c)
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
 	data, err := r.dashboardService.GetChallengingTopics(userID)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to fetch challenging topics")
@@ -67,7 +87,118 @@ func (r *DashboardRouter) GetChallengingTopics(c *gin.Context) {
 	utils.SendSuccess(c, "Challenging topics fetched", data)
 }
 
+func (r *DashboardRouter) GetPredictedGrade(c *gin.Context) {
+	var req requests.PredictedGradeRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
+
+	data, err := r.dashboardService.GetPredictedGrade(userID, req.Tier)
+	if err != nil {
+		sendServiceError(c, err, "Failed to predict grade")
+		return
+	}
+	utils.SendSuccess(c, "Predicted grade fetched", data)
+}
+
+func (r *DashboardRouter) GetProgress(c *gin.Context) {
+	var req requests.ProgressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
+
+	data, err := r.dashboardService.GetProgress(userID, req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch progress")
+		return
+	}
+	utils.SendSuccess(c, "Progress fetched", data)
+}
+
+func (r *DashboardRouter) GetTopicProgress(c *gin.Context) {
+	var req requests.ProgressRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
+
+	data, err := r.dashboardService.GetTopicProgress(userID, req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch topic progress")
+		return
+	}
+	utils.SendSuccess(c, "Topic progress fetched", data)
+}
+
+func (r *DashboardRouter) GetStreak(c *gin.Context) {
+	userID, ok := r.subjectID(c)
+	if !ok {
+		return
+	}
+
+	data, err := r.streakService.GetStreak(userID)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch study streak")
+		return
+	}
+	utils.SendSuccess(c, "Study streak fetched", data)
+}
+
+// subjectID returns whose analytics a dashboard request is for: the caller's
+// own, or those of the user named by the user_id query parameter if the
+// caller's role may view other users' analytics. Admins may view anyone's;
+// teachers only those of students in their classes. It responds with the
+// error and returns false otherwise.
+func (r *DashboardRouter) subjectID(c *gin.Context) (uint, bool) {
+	self := getUserID(c)
+	raw := c.Query("user_id")
+	if raw == "" {
+		return self, true
+	}
+
+	userID, err := strconv.ParseUint(raw, 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid user ID")
+		return 0, false
+	}
+	if uint(userID) == self {
+		return self, true
+	}
+
+	role := auth.GetRole(c)
+	allowed := auth.Can(role, auth.ViewAnalytics)
+	if allowed && role != auth.RoleAdmin {
+		allowed, err = r.classroomService.TeachesStudent(self, uint(userID))
+		if err != nil {
+			utils.SendError(c, http.StatusInternalServerError, "Failed to check class membership")
+			return 0, false
+		}
+	}
+	if !allowed {
+		utils.SendError(c, http.StatusForbidden, "You do not have permission to view this user's analytics")
+		return 0, false
+	}
+	return uint(userID), true
+}
+
 func getUserID(c *gin.Context) uint {
-	uid, _ := c.Get("user_id")
-	return uint(uid.(float64))
+	p, _ := auth.GetPrincipal(c)
+	return p.UserID
 }
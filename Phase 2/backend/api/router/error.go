@@ -0,0 +1,40 @@
+package router
+
+import (
+	"M-AI/api/service"
+	"M-AI/api/utils"
+	"M-AI/pkg/ratelimit"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// sendServiceError maps a service error code onto an HTTP status. Errors
+// without a client-facing code are reported as fallback with a 500.
+func sendServiceError(c *gin.Context, err error, fallback string) {
+	var serr *service.ServiceError
+	if !errors.As(err, &serr) {
+		utils.SendError(c, http.StatusInternalServerError, fallback)
+		return
+	}
+
+	switch serr.Code {
+	case "not_found":
+		utils.SendError(c, http.StatusNotFound, serr.Message)
+	case "bad_request":
+		utils.SendError(c, http.StatusBadRequest, serr.Message)
+	case "unauthorized":
+		utils.SendError(c, http.StatusUnauthorized, serr.Message)
+	case "forbidden":
+		utils.SendError(c, http.StatusForbidden, serr.Message)
+	case "conflict":
+		utils.SendError(c, http.StatusConflict, serr.Message)
+	case "too_many_requests":
+		ratelimit.SetRetryAfter(c, serr.RetryAfter)
+		utils.SendError(c, http.StatusTooManyRequests, serr.Message)
+	case "unavailable":
+		utils.SendError(c, http.StatusServiceUnavailable, serr.Message)
+	default:
+		utils.SendError(c, http.StatusInternalServerError, fallback)
+	}
+}
@@ -0,0 +1,134 @@
+package router
+
+import (
+	"M-AI/api/requests"
+	"M-AI/api/service"
+	"M-AI/api/utils"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+)
+
+type MockExamRouter struct {
+	mockExamService *service.MockExamService
+}
+
+func NewMockExamRouter(mockExamService *service.MockExamService) *MockExamRouter {
+	return &MockExamRouter{mockExamService: mockExamService}
+}
+
+func (r *MockExamRouter) RegisterRoutes(router *gin.RouterGroup) {
+	examGroup := router.Group("/mock-exams", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
+	{
+		examGroup.POST("", auth.Require(auth.CreateContent), r.CreateExam)
+		examGroup.GET("", r.ListExams)
+		examGroup.GET("/:id", r.GetExam)
+		examGroup.POST("/:id/start", r.StartAttempt)
+		examGroup.POST("/attempts/:attemptId/submit", r.SubmitAttempt)
+		examGroup.GET("/attempts/:attemptId", r.GetResult)
+	}
+}
+
+func (r *MockExamRouter) CreateExam(c *gin.Context) {
+	var req requests.CreateMockExamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	examID, err := r.mockExamService.CreateExam(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to create mock exam")
+		return
+	}
+
+	utils.SendSuccess(c, "Mock exam created successfully", gin.H{"id": examID})
+}
+
+func (r *MockExamRouter) ListExams(c *gin.Context) {
+	var req requests.ListMockExamsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	exams, err := r.mockExamService.ListExams(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to list mock exams")
+		return
+	}
+
+	utils.SendPage(c, "Mock exams fetched successfully", exams.Items, exams.NextCursor)
+}
+
+func (r *MockExamRouter) GetExam(c *gin.Context) {
+	examID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid mock exam ID")
+		return
+	}
+
+	exam, err := r.mockExamService.GetExam(getUserID(c), uint(examID))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch mock exam")
+		return
+	}
+
+	utils.SendSuccess(c, "Mock exam fetched successfully", exam)
+}
+
+func (r *MockExamRouter) StartAttempt(c *gin.Context) {
+	examID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid mock exam ID")
+		return
+	}
+
+	attempt, err := r.mockExamService.StartAttempt(getUserID(c), uint(examID))
+	if err != nil {
+		sendServiceError(c, err, "Failed to start mock exam")
+		return
+	}
+
+	utils.SendSuccess(c, "Mock exam started", attempt)
+}
+
+func (r *MockExamRouter) SubmitAttempt(c *gin.Context) {
+	attemptID, err := strconv.ParseUint(c.Param("attemptId"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid attempt ID")
+		return
+	}
+
+	var req requests.SubmitMockExamRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := r.mockExamService.SubmitAttempt(getUserID(c), uint(attemptID), req.Answers)
+	if err != nil {
+		sendServiceError(c, err, "Failed to submit mock exam")
+		return
+	}
+
+	utils.SendSuccess(c, "Mock exam submitted", result)
+}
+
+func (r *MockExamRouter) GetResult(c *gin.Context) {
+	attemptID, err := strconv.ParseUint(c.Param("attemptId"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid attempt ID")
+		return
+	}
+
+	result, err := r.mockExamService.GetResult(getUserID(c), uint(attemptID))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch mock exam result")
+		return
+	}
+
+	utils.SendSuccess(c, "Mock exam result fetched", result)
+}
@@ -4,8 +4,11 @@ import (
 	"M-AI/api/requests"
 	"M-AI/api/service"
 	"M-AI/api/utils"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
 )
 
 type ProblemRouter struct {
@@ -17,11 +20,22 @@ func NewProblemRouter(problemService *service.ProblemService, aiService *service
 	return &ProblemRouter{problemService: problemService, aiService: aiService}
 }
 
-func (r *ProblemRouter) RegisterRoutes(router *gin.RouterGroup) {
-	problemGroup := router.Group("/problems")
+// RegisterRoutes adds the problem routes. aiLimit throttles the routes that
+// stream from OpenAI; answers are throttled by the service, and only when the
+// AI grader is consulted.
+func (r *ProblemRouter) RegisterRoutes(router *gin.RouterGroup, aiLimit gin.HandlerFunc) {
+	problemGroup := router.Group("/problems", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.Require(auth.CreateContent))
 	{
-		problemGroup.POST("", r.CreateProblem)
-		problemGroup.POST("/image", r.CreateProblemWithImage)
+		problemGroup.POST("", aiLimit, r.CreateProblem)
+		problemGroup.POST("/image", aiLimit, r.CreateProblemWithImage)
+	}
+
+	practiceGroup := router.Group("/problems", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
+	{
+		practiceGroup.GET("", r.ListProblems)
+		practiceGroup.GET("/:id", r.GetProblem)
+		practiceGroup.POST("/:id/answer", r.SubmitAnswer)
+		practiceGroup.POST("/:id/give-up", r.GiveUp)
 	}
 }
 
@@ -72,3 +86,73 @@ func (r *ProblemRouter) CreateProblemWithImage(c *gin.Context) {
 		})
 	}
 }
+
+func (r *ProblemRouter) ListProblems(c *gin.Context) {
+	var req requests.ListProblemsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	problems, err := r.problemService.ListProblems(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to list problems")
+		return
+	}
+
+	utils.SendPage(c, "Problems fetched successfully", problems.Items, problems.NextCursor)
+}
+
+func (r *ProblemRouter) GetProblem(c *gin.Context) {
+	problemID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid problem ID")
+		return
+	}
+
+	problem, err := r.problemService.GetProblem(getUserID(c), uint(problemID))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch problem")
+		return
+	}
+
+	utils.SendSuccess(c, "Problem fetched successfully", problem)
+}
+
+func (r *ProblemRouter) SubmitAnswer(c *gin.Context) {
+	problemID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid problem ID")
+		return
+	}
+
+	var req requests.SubmitProblemAnswerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	result, err := r.problemService.SubmitAnswer(getUserID(c), uint(problemID), req.Answer)
+	if err != nil {
+		sendServiceError(c, err, "Failed to submit answer")
+		return
+	}
+
+	utils.SendSuccess(c, "Answer submitted", result)
+}
+
+func (r *ProblemRouter) GiveUp(c *gin.Context) {
+	problemID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid problem ID")
+		return
+	}
+
+	result, err := r.problemService.GiveUp(getUserID(c), uint(problemID))
+	if err != nil {
+		sendServiceError(c, err, "Failed to give up on problem")
+		return
+	}
+
+	utils.SendSuccess(c, "Answer revealed", result)
+}
@@ -7,8 +7,10 @@ import (
 	"M-AI/api/utils"
 	"M-AI/internal/config"
 	"M-AI/pkg/auth"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
 )
 
 type QuizRouter struct {
@@ -19,13 +21,17 @@ func NewQuizRouter(quizService *service.QuizService) *QuizRouter {
 	return &QuizRouter{quizService: quizService}
 }
 
-func (r *QuizRouter) RegisterRoutes(router *gin.RouterGroup) {
+// RegisterRoutes adds the quiz routes. aiLimit throttles quiz generation,
+// which calls OpenAI.
+func (r *QuizRouter) RegisterRoutes(router *gin.RouterGroup, aiLimit gin.HandlerFunc) {
 	quizGroup := router.Group("/quizzes", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
 	{
-		quizGroup.POST("", r.CreateQuiz)
+		quizGroup.POST("", auth.Require(auth.CreateContent), r.CreateQuiz)
 		quizGroup.GET("", r.ListQuizzes)
 		quizGroup.POST("/complete", r.CompleteQuiz)
-		quizGroup.POST("/generate", r.GenerateAIQuiz)
+		quizGroup.POST("/generate", aiLimit, r.GenerateAIQuiz)
+		quizGroup.GET("/:id", r.GetQuiz)
+		quizGroup.GET("/:id/export.pdf", r.ExportQuizPDF)
 	}
 }
 
@@ -36,7 +42,7 @@ func (r *QuizRouter) CreateQuiz(c *gin.Context) {
 		return
 	}
 
-	err := r.quizService.CreateQuizWithQuestions(req)
+	err := r.quizService.CreateQuizWithQuestions(getUserID(c), req)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to create quiz")
 		return
@@ -46,52 +52,47 @@ func (r *QuizRouter) CreateQuiz(c *gin.Context) {
 }
 
 func (r *QuizRouter) ListQuizzes(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.SendError(c, http.StatusUnauthorized, "Unauthorized")
-		return
-	}
-
-	userIDFloat, ok := userID.(float64)
-	if !ok {
-		utils.SendError(c, http.StatusInternalServerError, "Invalid user ID format")
+	var req requests.ListQuizzesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	search := c.Query("search")
-	filter := c.DefaultQuery("filter", "all")
-
-	quizzes, err := r.quizService.ListQuizzesWithUserStats(uint(userIDFloat), search, filter)
+	quizzes, err := r.quizService.ListQuizzesWithUserStats(getUserID(c), req)
 	if err != nil {
-		utils.SendError(c, http.StatusInternalServerError, "Failed to list quizzes")
+		sendServiceError(c, err, "Failed to list quizzes")
 		return
 	}
 
-	utils.SendSuccess(c, "Quizzes fetched successfully", quizzes)
+	utils.SendPage(c, "Quizzes fetched successfully", quizzes.Items, quizzes.NextCursor)
 }
 
-func (r *QuizRouter) CompleteQuiz(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.SendError(c, http.StatusUnauthorized, "Unauthorized")
+func (r *QuizRouter) GetQuiz(c *gin.Context) {
+	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid quiz ID")
 		return
 	}
 
-	userIDFloat, ok := userID.(float64)
-	if !ok {
-		utils.SendError(c, http.StatusInternalServerError, "Invalid user ID format")
+	quiz, err := r.quizService.GetQuiz(getUserID(c), uint(quizID))
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch quiz")
 		return
 	}
 
+	utils.SendSuccess(c, "Quiz fetched successfully", quiz)
+}
+
+func (r *QuizRouter) CompleteQuiz(c *gin.Context) {
 	var submission dto.QuizSubmission
 	if err := c.ShouldBindJSON(&submission); err != nil {
 		utils.SendError(c, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
-	submission.UserID = uint(userIDFloat)
+	submission.UserID = getUserID(c)
 	if err := r.quizService.CompleteQuiz(submission); err != nil {
-		utils.SendError(c, http.StatusInternalServerError, "Failed to complete quiz")
+		sendServiceError(c, err, "Failed to complete quiz")
 		return
 	}
 
@@ -99,25 +100,13 @@ func (r *QuizRouter) CompleteQuiz(c *gin.Context) {
 }
 
 func (r *QuizRouter) GenerateAIQuiz(c *gin.Context) {
-	userID, exists := c.Get("user_id")
-	if !exists {
-		utils.SendError(c, http.StatusUnauthorized, "Unauthorized")
-		return
-	}
-
-	userIDFloat, ok := userID.(float64)
-	if !ok {
-		utils.SendError(c, http.StatusInternalServerError, "Invalid user ID format")
-		return
-	}
-
 	var req dto.AIQuizRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.SendError(c, http.StatusBadRequest, "Invalid request payload")
 		return
 	}
 
-	req.UserID = uint(userIDFloat)
+	req.UserID = getUserID(c)
 	q, err := r.quizService.GenerateQuizFromPrompt(req)
 	if err != nil {
 		utils.SendError(c, http.StatusInternalServerError, "Failed to generate quiz")
@@ -126,3 +115,30 @@ func (r *QuizRouter) GenerateAIQuiz(c *gin.Context) {
 
 	utils.SendSuccess(c, "Quiz generated successfully", q)
 }
+
+func (r *QuizRouter) ExportQuizPDF(c *gin.Context) {
+	quizID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid quiz ID")
+		return
+	}
+
+	var req requests.ExportQuizRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	pdf, err := r.quizService.ExportQuizPDF(getUserID(c), uint(quizID), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to export quiz")
+		return
+	}
+
+	filename := fmt.Sprintf("quiz-%d.pdf", quizID)
+	if req.Seed != 0 {
+		filename = fmt.Sprintf("quiz-%d-v%d.pdf", quizID, req.Seed)
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+	c.Data(http.StatusOK, "application/pdf", pdf)
+}
@@ -1,12 +1,15 @@
 package router
 
 import (
+	"M-AI/api/requests"
 	"M-AI/api/service"
 	"M-AI/api/utils"
 	"M-AI/internal/config"
 	"M-AI/pkg/auth"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strconv"
+	"strings"
 )
 
 type ResourceRouter struct {
@@ -21,18 +24,232 @@ func (r *ResourceRouter) RegisterRoutes(router *gin.RouterGroup) {
 	resourceGroup := router.Group("/resources", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey))
 	{
 		resourceGroup.GET("", r.GetResources)
+		resourceGroup.GET("/recommended", r.GetRecommended)
+		resourceGroup.GET("/saved", r.GetSaved)
+		resourceGroup.POST("/:id/open", r.RecordOpen)
+		resourceGroup.PUT("/:id/save", r.SaveResource)
+		resourceGroup.DELETE("/:id/save", r.UnsaveResource)
+		resourceGroup.PUT("/:id/complete", r.CompleteResource)
+		resourceGroup.DELETE("/:id/complete", r.UncompleteResource)
+	}
+
+	adminGroup := router.Group("/admin/resources", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), auth.Require(auth.ManageResources))
+	{
+		adminGroup.GET("", r.ListAllResources)
+		adminGroup.POST("", r.CreateResource)
+		adminGroup.POST("/import", r.ImportResources)
+		adminGroup.POST("/check-links", r.CheckLinks)
+		adminGroup.PUT("/:id", r.UpdateResource)
+		adminGroup.DELETE("/:id", r.DeleteResource)
 	}
 }
 
 func (r *ResourceRouter) GetResources(c *gin.Context) {
-	search := c.Query("search")
-	level := c.Query("level")
+	var req requests.ListResourcesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resources, err := r.resourceService.GetResources(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch resources")
+		return
+	}
+
+	utils.SendPage(c, "Resources fetched successfully", resources.Items, resources.NextCursor)
+}
+
+func (r *ResourceRouter) GetRecommended(c *gin.Context) {
+	var req requests.RecommendedResourcesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
 
-	resources, err := r.resourceService.GetResources(search, level)
+	resources, err := r.resourceService.Recommend(getUserID(c), req)
 	if err != nil {
-		utils.SendError(c, http.StatusInternalServerError, "Failed to fetch resources")
+		sendServiceError(c, err, "Failed to fetch recommendations")
+		return
+	}
+
+	utils.SendPage(c, "Recommended resources fetched successfully", resources.Items, resources.NextCursor)
+}
+
+func (r *ResourceRouter) RecordOpen(c *gin.Context) {
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid resource ID")
+		return
+	}
+
+	if err := r.resourceService.RecordOpen(getUserID(c), uint(resourceID)); err != nil {
+		sendServiceError(c, err, "Failed to record resource open")
+		return
+	}
+
+	utils.SendSuccess(c, "Resource open recorded", nil)
+}
+
+func (r *ResourceRouter) GetSaved(c *gin.Context) {
+	var req requests.SavedResourcesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resources, err := r.resourceService.GetSavedResources(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch saved resources")
+		return
+	}
+
+	utils.SendPage(c, "Saved resources fetched successfully", resources.Items, resources.NextCursor)
+}
+
+func (r *ResourceRouter) SaveResource(c *gin.Context) {
+	r.setState(c, r.resourceService.SetSaved, true, "Resource saved")
+}
+
+func (r *ResourceRouter) UnsaveResource(c *gin.Context) {
+	r.setState(c, r.resourceService.SetSaved, false, "Resource removed from saved")
+}
+
+func (r *ResourceRouter) CompleteResource(c *gin.Context) {
+	r.setState(c, r.resourceService.SetCompleted, true, "Resource marked as completed")
+}
+
+func (r *ResourceRouter) UncompleteResource(c *gin.Context) {
+	r.setState(c, r.resourceService.SetCompleted, false, "Resource marked as not completed")
+}
+
+func (r *ResourceRouter) setState(c *gin.Context, set func(userID, resourceID uint, on bool) error, on bool, message string) {
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid resource ID")
+		return
+	}
+
+	if err := set(getUserID(c), uint(resourceID), on); err != nil {
+		sendServiceError(c, err, "Failed to update resource")
+		return
+	}
+
+	utils.SendSuccess(c, message, nil)
+}
+
+func (r *ResourceRouter) ListAllResources(c *gin.Context) {
+	var req requests.AdminListResourcesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resources, err := r.resourceService.ListAllResources(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to fetch resources")
+		return
+	}
+
+	utils.SendPage(c, "Resources fetched successfully", resources.Items, resources.NextCursor)
+}
+
+func (r *ResourceRouter) CreateResource(c *gin.Context) {
+	var req requests.ResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resource, err := r.resourceService.CreateResource(getUserID(c), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to create resource")
+		return
+	}
+
+	utils.SendSuccess(c, "Resource created successfully", resource)
+}
+
+func (r *ResourceRouter) UpdateResource(c *gin.Context) {
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid resource ID")
+		return
+	}
+
+	var req requests.ResourceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	resource, err := r.resourceService.UpdateResource(uint(resourceID), req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to update resource")
+		return
+	}
+
+	utils.SendSuccess(c, "Resource updated successfully", resource)
+}
+
+func (r *ResourceRouter) DeleteResource(c *gin.Context) {
+	resourceID, err := strconv.ParseUint(c.Param("id"), 10, 64)
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Invalid resource ID")
+		return
+	}
+
+	if err := r.resourceService.DeleteResource(uint(resourceID)); err != nil {
+		sendServiceError(c, err, "Failed to delete resource")
+		return
+	}
+
+	utils.SendSuccess(c, "Resource deleted successfully", nil)
+}
+
+// ImportResources accepts a CSV or YAML document as the request body. The
+// format comes from the format query parameter, or else the Content-Type.
+func (r *ResourceRouter) ImportResources(c *gin.Context) {
+	var req requests.ImportResourcesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		contentType := c.ContentType()
+		switch {
+		case strings.Contains(contentType, "csv"):
+			format = "csv"
+		case strings.Contains(contentType, "yaml"):
+			format = "yaml"
+		default:
+			utils.SendError(c, http.StatusBadRequest, "Specify format=csv or format=yaml")
+			return
+		}
+	}
+
+	body, err := c.GetRawData()
+	if err != nil {
+		utils.SendError(c, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	result, err := r.resourceService.ImportResources(getUserID(c), format, body)
+	if err != nil {
+		sendServiceError(c, err, "Failed to import resources")
+		return
+	}
+
+	utils.SendSuccess(c, "Resources imported successfully", result)
+}
+
+func (r *ResourceRouter) CheckLinks(c *gin.Context) {
+	if err := r.resourceService.RunLinkCheck(); err != nil {
+		sendServiceError(c, err, "Failed to start link check")
 		return
 	}
 
-	utils.SendSuccess(c, "Resources fetched successfully", resources)
+	utils.SendSuccess(c, "Link check started", nil)
 }
@@ -0,0 +1,39 @@
+package router
+
+import (
+	"M-AI/api/requests"
+	"M-AI/api/service"
+	"M-AI/api/utils"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+type SearchRouter struct {
+	searchService *service.SearchService
+}
+
+func NewSearchRouter(searchService *service.SearchService) *SearchRouter {
+	return &SearchRouter{searchService: searchService}
+}
+
+func (r *SearchRouter) RegisterRoutes(router *gin.RouterGroup) {
+	router.GET("/search", auth.AuthMiddleware(config.AppConfig.Auth.SecretKey), r.Search)
+}
+
+func (r *SearchRouter) Search(c *gin.Context) {
+	var req requests.SearchRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		utils.SendError(c, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	results, next, err := r.searchService.Search(req)
+	if err != nil {
+		sendServiceError(c, err, "Failed to search")
+		return
+	}
+
+	utils.SendPage(c, "Search results fetched successfully", results, next)
+}
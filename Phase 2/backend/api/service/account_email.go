@@ -0,0 +1,234 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/model"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/db"
+	"M-AI/pkg/mailer"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	verifyEmailTTL   = 48 * time.Hour
+	resetPasswordTTL = time.Hour
+	// emailsPerHour caps how many tokens of each kind a user can be sent, so
+	// the endpoints cannot be used to flood an inbox.
+	emailsPerHour = 5
+	mailTimeout   = 30 * time.Second
+)
+
+// ResendVerification sends the user a fresh verification link, replacing any
+// earlier ones.
+func (s *AuthService) ResendVerification(userID uint) error {
+	var msg mailer.Message
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		user, err := s.authRepo.GetUserByID(tx, userID)
+		if err != nil {
+			return NotFoundError("User not found", err)
+		}
+		if user.EmailVerifiedAt != nil {
+			return ConflictError("Email is already verified", errors.New("already verified"))
+		}
+		msg, err = s.verificationMessage(tx, user)
+		return err
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		s.sendMail(msg)
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to send verification email", err)
+	}
+}
+
+// VerifyEmail marks the address a verification token was sent to as
+// verified.
+func (s *AuthService) VerifyEmail(token string) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		t, err := s.tokenRepo.Consume(tx, constants.TokenVerifyEmail, auth.HashToken(token), time.Now())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return BadRequestError("Invalid or expired verification link", err)
+		} else if err != nil {
+			return err
+		}
+		return s.authRepo.SetEmailVerified(tx, t.UserID, time.Now())
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to verify email", err)
+	}
+}
+
+// RequestPasswordReset emails a reset link if an account has this address.
+// It succeeds either way, so it cannot be used to find out who has an
+// account.
+func (s *AuthService) RequestPasswordReset(email string) error {
+	var msg mailer.Message
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		user, err := s.authRepo.GetUserByEmail(tx, strings.TrimSpace(email))
+		if err != nil {
+			return nil
+		}
+		recent, err := s.tokenRepo.CountRecent(tx, user.ID, constants.TokenResetPassword, time.Now().Add(-time.Hour))
+		if err != nil || recent >= emailsPerHour {
+			return err
+		}
+
+		token, err := s.issueToken(tx, user.ID, constants.TokenResetPassword, resetPasswordTTL)
+		if err != nil {
+			return err
+		}
+		msg = mailer.Message{
+			To:      user.Email,
+			Subject: "Reset your M-AI password",
+			Body: fmt.Sprintf("Hi %s,\n\nSomeone asked to reset the password for your M-AI account. "+
+				"If it was you, choose a new password here:\n\n%s\n\n"+
+				"The link expires in an hour. If you didn't ask for this, you can ignore this email.\n",
+				user.Name, appLink("/reset-password", token)),
+		}
+		return nil
+	})
+	if err != nil {
+		return InternalError("Failed to request password reset", err)
+	}
+	if msg.To != "" {
+		s.sendMail(msg)
+	}
+	return nil
+}
+
+// ResetPassword sets a new password using a reset token, signs the user out
+// everywhere and revokes their API keys. Following the link proves they own
+// the address, so it is marked verified too, and any sign-in lockout is
+// lifted.
+func (s *AuthService) ResetPassword(token, newPassword string) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		now := time.Now()
+		t, err := s.tokenRepo.Consume(tx, constants.TokenResetPassword, auth.HashToken(token), now)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return BadRequestError("Invalid or expired reset link", err)
+		} else if err != nil {
+			return err
+		}
+
+		hashed, err := auth.HashPassword(newPassword, config.AppConfig.Auth.SecretKey, config.AppConfig.Auth.Salt)
+		if err != nil {
+			return err
+		}
+		if err := s.authRepo.SetPassword(tx, t.UserID, hashed); err != nil {
+			return err
+		}
+		if err := s.tokenRepo.Invalidate(tx, t.UserID, constants.TokenResetPassword, now); err != nil {
+			return err
+		}
+		if err := s.authRepo.SetEmailVerified(tx, t.UserID, now); err != nil {
+			return err
+		}
+		if _, err := s.sessionRepo.RevokeAll(tx, t.UserID, 0, now); err != nil {
+			return err
+		}
+		if err := s.apiKeyRepo.RevokeAll(tx, t.UserID, now); err != nil {
+			return err
+		}
+		user, err := s.authRepo.GetUserByID(tx, t.UserID)
+		if err != nil {
+			return err
+		}
+		return s.recordPasswordReset(tx, user)
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to reset password", err)
+	}
+}
+
+func (s *AuthService) verificationMessage(tx *gorm.DB, user model.User) (mailer.Message, error) {
+	recent, err := s.tokenRepo.CountRecent(tx, user.ID, constants.TokenVerifyEmail, time.Now().Add(-time.Hour))
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	if recent >= emailsPerHour {
+		return mailer.Message{}, BadRequestError("Too many verification emails, try again later", errors.New("email limit"))
+	}
+	if err := s.tokenRepo.Invalidate(tx, user.ID, constants.TokenVerifyEmail, time.Now()); err != nil {
+		return mailer.Message{}, err
+	}
+
+	token, err := s.issueToken(tx, user.ID, constants.TokenVerifyEmail, verifyEmailTTL)
+	if err != nil {
+		return mailer.Message{}, err
+	}
+	return mailer.Message{
+		To:      user.Email,
+		Subject: "Verify your M-AI email address",
+		Body: fmt.Sprintf("Hi %s,\n\nWelcome to M-AI! Please confirm your email address:\n\n%s\n\n"+
+			"The link expires in 48 hours.\n",
+			user.Name, appLink("/verify-email", token)),
+	}, nil
+}
+
+func (s *AuthService) issueToken(tx *gorm.DB, userID uint, purpose string, ttl time.Duration) (string, error) {
+	token, hash, err := auth.NewToken()
+	if err != nil {
+		return "", err
+	}
+	err = s.tokenRepo.Create(tx, &model.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: hash,
+		ExpiresAt: time.Now().Add(ttl),
+	})
+	return token, err
+}
+
+// sendMail delivers msg in the background so slow mail servers do not hold
+// up requests, and so response times do not reveal whether an account
+// exists. Failures are logged.
+func (s *AuthService) sendMail(msg mailer.Message) {
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mailTimeout)
+		defer cancel()
+		if err := s.mailer.Send(ctx, msg); err != nil {
+			log.Printf("mail: sending %q to %s: %v", msg.Subject, msg.To, err)
+		}
+	}()
+}
+
+func appLink(path, token string) string {
+	return appURL(path) + "?token=" + url.QueryEscape(token)
+}
+
+// appURL is the address of a page on the frontend.
+func appURL(path string) string {
+	base := strings.TrimRight(config.AppConfig.Mail.AppURL, "/")
+	if base == "" {
+		base = "http://localhost:3000"
+	}
+	return base + path
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/internal/config"
+	"M-AI/pkg/db"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// AchievementService awards badges from the declarative rules in the
+// achievement table. Rules are read on every event, so badges added to the
+// table or to config take effect without a code change.
+type AchievementService struct {
+	repo          *repository.AchievementRepository
+	streakService *StudyStreakService
+	db            *gorm.DB
+}
+
+func NewAchievementService(db *gorm.DB, repo *repository.AchievementRepository, streakService *StudyStreakService) *AchievementService {
+	return &AchievementService{repo: repo, streakService: streakService, db: db}
+}
+
+// SyncDefinitions writes the badges defined in config to the achievement
+// table, updating any with the same code.
+func (s *AchievementService) SyncDefinitions(defs []config.AchievementDefinition) error {
+	if len(defs) == 0 {
+		return nil
+	}
+	for _, d := range defs {
+		if !constants.IsValidAchievementMetric(d.Metric) {
+			return fmt.Errorf("achievement %q: unknown metric %q", d.Code, d.Metric)
+		}
+	}
+
+	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		for _, d := range defs {
+			err := s.repo.UpsertDefinition(tx, &model.Achievement{
+				Code:        d.Code,
+				Name:        d.Name,
+				Description: d.Description,
+				Icon:        d.Icon,
+				Event:       d.Event,
+				Metric:      d.Metric,
+				Threshold:   d.Threshold,
+				Topic:       d.Topic,
+				Active:      true,
+			})
+			if err != nil {
+				return fmt.Errorf("achievement %q: %w", d.Code, err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *AchievementService) ListForUser(userID uint) ([]dto.Achievement, error) {
+	var result []dto.Achievement
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		achievements, err := s.repo.ListForUser(tx, userID)
+		result = achievements
+		return err
+	})
+	if err != nil {
+		return nil, InternalError("Failed to load achievements", err)
+	}
+	return result, nil
+}
+
+// Publish evaluates the rules against an event once the action that raised
+// it has been committed. Failures are logged rather than returned: a badge
+// going missing must never fail the submission that earned it.
+func (s *AchievementService) Publish(event dto.AchievementEvent) {
+	if err := s.evaluate(event); err != nil {
+		log.Printf("achievements: %s for user %d: %v", event.Type, event.UserID, err)
+	}
+}
+
+func (s *AchievementService) evaluate(event dto.AchievementEvent) error {
+	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		rules, err := s.repo.ListActive(tx)
+		if err != nil {
+			return err
+		}
+		earned, err := s.repo.GetEarnedIDs(tx, event.UserID)
+		if err != nil {
+			return err
+		}
+
+		for _, rule := range rules {
+			if earned[rule.ID] || (rule.Event != "" && rule.Event != event.Type) {
+				continue
+			}
+			value, ok, err := s.measure(tx, rule, event)
+			if err != nil {
+				return fmt.Errorf("achievement %q: %w", rule.Code, err)
+			}
+			if !ok || value < rule.Threshold {
+				continue
+			}
+			if err := s.repo.Award(tx, event.UserID, rule.ID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// measure returns the rule's metric for the event's user, and false when the
+// metric does not apply to this event.
+func (s *AchievementService) measure(tx *gorm.DB, rule model.Achievement, event dto.AchievementEvent) (int, bool, error) {
+	switch rule.Metric {
+	case constants.MetricQuizScore:
+		return event.Score, event.Type == constants.EventQuizCompleted, nil
+	case constants.MetricQuizzesCompleted:
+		n, err := s.repo.CountQuizzesCompleted(tx, event.UserID)
+		return n, true, err
+	case constants.MetricCorrectStreak:
+		n, err := s.repo.CorrectStreak(tx, event.UserID, rule.Topic)
+		return n, true, err
+	case constants.MetricCorrectAnswers:
+		n, err := s.repo.CountCorrectAnswers(tx, event.UserID, rule.Topic)
+		return n, true, err
+	case constants.MetricProblemsSolved:
+		n, err := s.repo.CountProblemsSolved(tx, event.UserID)
+		return n, true, err
+	case constants.MetricStudyStreak:
+		streak, err := s.streakService.streakFor(tx, event.UserID, time.Now())
+		return streak.Current, true, err
+	default:
+		log.Printf("achievements: %q has unknown metric %q", rule.Code, rule.Metric)
+		return 0, false, nil
+	}
+}
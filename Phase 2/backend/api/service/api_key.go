@@ -0,0 +1,146 @@
+package service
+
+import (
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/db"
+	"errors"
+	"gorm.io/gorm"
+	"slices"
+	"strings"
+	"time"
+)
+
+// maxAPIKeys caps how many active keys a user may hold.
+const maxAPIKeys = 25
+
+// CreateAPIKey issues a personal API key. The key is only returned here; just
+// its hash is stored.
+func (s *AuthService) CreateAPIKey(userID uint, req requests.CreateAPIKeyRequest) (dto.CreatedAPIKey, error) {
+	now := time.Now()
+	if req.ExpiresAt != nil && !req.ExpiresAt.After(now) {
+		return dto.CreatedAPIKey{}, ValidationError("expires_at must be in the future")
+	}
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return dto.CreatedAPIKey{}, ValidationError("name must not be blank")
+	}
+
+	key, hash, prefix, err := auth.NewAPIKey()
+	if err != nil {
+		return dto.CreatedAPIKey{}, InternalError("Failed to create API key", err)
+	}
+	scopes := slices.Clone(req.Scopes)
+	slices.Sort(scopes)
+	record := model.APIKey{
+		UserID:    userID,
+		Name:      name,
+		Prefix:    prefix,
+		KeyHash:   hash,
+		Scopes:    slices.Compact(scopes),
+		ExpiresAt: req.ExpiresAt,
+	}
+
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		count, err := s.apiKeyRepo.CountActive(tx, userID, now)
+		if err != nil {
+			return err
+		}
+		if count >= maxAPIKeys {
+			return ConflictError("You already have the maximum number of API keys", errors.New("too many API keys"))
+		}
+		return s.apiKeyRepo.Create(tx, &record)
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return dto.CreatedAPIKey{APIKey: apiKeyDTO(record), Key: key}, nil
+	case errors.As(err, &serr):
+		return dto.CreatedAPIKey{}, serr
+	default:
+		return dto.CreatedAPIKey{}, InternalError("Failed to create API key", err)
+	}
+}
+
+func (s *AuthService) ListAPIKeys(userID uint) ([]dto.APIKey, error) {
+	result := []dto.APIKey{}
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		keys, err := s.apiKeyRepo.ListActive(tx, userID, time.Now())
+		for _, key := range keys {
+			result = append(result, apiKeyDTO(key))
+		}
+		return err
+	})
+	if err != nil {
+		return nil, InternalError("Failed to fetch API keys", err)
+	}
+	return result, nil
+}
+
+func (s *AuthService) RevokeAPIKey(userID, keyID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		revoked, err := s.apiKeyRepo.Revoke(tx, userID, keyID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return NotFoundError("API key not found", errors.New("API key not found"))
+		}
+		return nil
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to revoke API key", err)
+	}
+}
+
+// ResolveAPIKey implements auth.APIKeyResolver. The principal takes the
+// owner's current role, so role changes apply to their keys straight away.
+// Keys stop working while their owner's role requires two-factor
+// authentication the owner has not set up.
+func (s *AuthService) ResolveAPIKey(key string) (auth.Principal, error) {
+	var principal auth.Principal
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		now := time.Now()
+		owner, err := s.apiKeyRepo.GetOwner(tx, auth.HashToken(key), now)
+		if err != nil {
+			return err
+		}
+		missing, err := s.secondFactorMissing(tx, owner.UserID, owner.Role)
+		if err != nil {
+			return err
+		}
+		if missing {
+			return errors.New("owner has not set up required two-factor authentication")
+		}
+		principal = auth.Principal{
+			UserID:   owner.UserID,
+			Role:     owner.Role,
+			APIKeyID: owner.KeyID,
+			Scopes:   owner.Scopes,
+		}
+		return s.apiKeyRepo.Touch(tx, owner.KeyID, now)
+	})
+	return principal, err
+}
+
+func apiKeyDTO(key model.APIKey) dto.APIKey {
+	return dto.APIKey{
+		ID:         key.ID,
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt,
+		ExpiresAt:  key.ExpiresAt,
+		LastUsedAt: key.LastUsedAt,
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/api/requests"
+	"M-AI/pkg/db"
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type AssignmentService struct {
+	assignmentRepo *repository.AssignmentRepository
+	quizRepo       *repository.QuizRepository
+	questionRepo   *repository.QuestionRepository
+	classes        *ClassroomService
+	db             *gorm.DB
+}
+
+func NewAssignmentService(
+	db *gorm.DB,
+	assignmentRepo *repository.AssignmentRepository,
+	quizRepo *repository.QuizRepository,
+	questionRepo *repository.QuestionRepository,
+	classes *ClassroomService,
+) *AssignmentService {
+	return &AssignmentService{
+		assignmentRepo: assignmentRepo,
+		quizRepo:       quizRepo,
+		questionRepo:   questionRepo,
+		classes:        classes,
+		db:             db,
+	}
+}
+
+// CreateAssignment sets a quiz for a class. Only the class's teachers may do
+// so.
+func (s *AssignmentService) CreateAssignment(classID, userID uint, req requests.AssignmentRequest) (dto.QuizAssignment, error) {
+	assignment := model.QuizAssignment{
+		ClassroomID: classID,
+		QuizID:      req.QuizID,
+		AssignedBy:  userID,
+		OpenAt:      time.Now(),
+		DueAt:       req.DueAt,
+		MaxAttempts: req.MaxAttempts,
+	}
+	if req.OpenAt != nil {
+		assignment.OpenAt = *req.OpenAt
+	}
+	if !assignment.DueAt.After(assignment.OpenAt) {
+		return dto.QuizAssignment{}, ValidationError("due_at must be after open_at")
+	}
+
+	var result dto.QuizAssignment
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.classes.requireTeacher(tx, classID, userID); err != nil {
+			return err
+		}
+		if _, err := s.quizRepo.GetQuiz(tx, req.QuizID); errors.Is(err, gorm.ErrRecordNotFound) {
+			return NotFoundError("Quiz not found", err)
+		} else if err != nil {
+			return err
+		}
+		if err := s.assignmentRepo.CreateAssignment(tx, &assignment); err != nil {
+			return err
+		}
+		view, err := s.assignmentRepo.GetAssignmentView(tx, assignment.ID)
+		result = view
+		return err
+	})
+	return result, classroomError(err, "Failed to create assignment")
+}
+
+func (s *AssignmentService) UpdateAssignment(classID, assignmentID, userID uint, req requests.UpdateAssignmentRequest) (dto.QuizAssignment, error) {
+	var result dto.QuizAssignment
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		assignment, err := s.teacherAssignment(tx, classID, assignmentID, userID)
+		if err != nil {
+			return err
+		}
+
+		if req.OpenAt != nil {
+			assignment.OpenAt = *req.OpenAt
+		}
+		if req.DueAt != nil {
+			assignment.DueAt = *req.DueAt
+		}
+		if req.MaxAttempts != nil {
+			assignment.MaxAttempts = req.MaxAttempts
+			if *req.MaxAttempts == 0 {
+				assignment.MaxAttempts = nil
+			}
+		}
+		if !assignment.DueAt.After(assignment.OpenAt) {
+			return ValidationError("due_at must be after open_at")
+		}
+
+		if err := s.assignmentRepo.UpdateAssignment(tx, &assignment); err != nil {
+			return err
+		}
+		result, err = s.assignmentRepo.GetAssignmentView(tx, assignment.ID)
+		return err
+	})
+	return result, classroomError(err, "Failed to update assignment")
+}
+
+func (s *AssignmentService) DeleteAssignment(classID, assignmentID, userID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.teacherAssignment(tx, classID, assignmentID, userID); err != nil {
+			return err
+		}
+		return s.assignmentRepo.DeleteAssignment(tx, assignmentID)
+	})
+	return classroomError(err, "Failed to delete assignment")
+}
+
+// ListAssignments returns a page of a class's assignments. Teachers see every
+// assignment with the class's progress on it; students see those that have
+// opened with their own status.
+func (s *AssignmentService) ListAssignments(classID, userID uint, req requests.ListAssignmentsRequest) (dto.Page[dto.ClassAssignment], error) {
+	var result dto.Page[dto.ClassAssignment]
+	now := time.Now()
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		_, member, err := s.classes.requireMember(tx, classID, userID)
+		if err != nil {
+			return err
+		}
+		teacher := member.Role == constants.ClassTeacher
+
+		page, err := s.assignmentRepo.ListAssignments(tx, classID, userID, teacher, req.FilterRequest, req.PageRequest)
+		if err != nil {
+			return err
+		}
+
+		ids := make([]uint, 0, len(page.Items))
+		for _, a := range page.Items {
+			ids = append(ids, a.ID)
+		}
+		submissions := map[uint][]dto.AssignmentSubmission{}
+		if len(ids) > 0 {
+			student := userID
+			if teacher {
+				student = 0
+			}
+			if submissions, err = s.assignmentRepo.GetSubmissions(tx, ids, student); err != nil {
+				return err
+			}
+		}
+
+		result = dto.Page[dto.ClassAssignment]{Items: make([]dto.ClassAssignment, 0, len(page.Items)), NextCursor: page.NextCursor}
+		for _, a := range page.Items {
+			item := dto.ClassAssignment{QuizAssignment: a}
+			if teacher {
+				progress := assignmentProgress(a, submissions[a.ID], now)
+				item.Progress = &progress
+			} else {
+				var sub dto.AssignmentSubmission
+				if subs := submissions[a.ID]; len(subs) > 0 {
+					sub = subs[0]
+				}
+				status := assignmentStatus(a, sub, now)
+				item.Status = &status
+			}
+			result.Items = append(result.Items, item)
+		}
+		return nil
+	})
+
+	var serr *ServiceError
+	if errors.As(err, &serr) {
+		return result, serr
+	}
+	if err != nil {
+		return result, listError(err, "Failed to fetch assignments")
+	}
+	return result, nil
+}
+
+// GetResults returns the results matrix for an assignment: every enrolled
+// student's status and latest answer to each question, and how many students
+// got each question wrong.
+func (s *AssignmentService) GetResults(classID, assignmentID, userID uint) (dto.AssignmentResults, error) {
+	var result dto.AssignmentResults
+	now := time.Now()
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		assignment, err := s.teacherAssignment(tx, classID, assignmentID, userID)
+		if err != nil {
+			return err
+		}
+		view, err := s.assignmentRepo.GetAssignmentView(tx, assignmentID)
+		if err != nil {
+			return err
+		}
+		submissions, err := s.assignmentRepo.GetSubmissions(tx, []uint{assignmentID}, 0)
+		if err != nil {
+			return err
+		}
+		questions, err := s.questionRepo.GetByQuizID(tx, assignment.QuizID)
+		if err != nil {
+			return err
+		}
+		answers, err := s.assignmentRepo.GetLatestAnswers(tx, assignment)
+		if err != nil {
+			return err
+		}
+
+		result = buildAssignmentResults(view, submissions[assignmentID], questions, answers, now)
+		return nil
+	})
+	return result, classroomError(err, "Failed to fetch assignment results")
+}
+
+// teacherAssignment loads an assignment of a class the user teaches.
+func (s *AssignmentService) teacherAssignment(tx *gorm.DB, classID, assignmentID, userID uint) (model.QuizAssignment, error) {
+	if _, err := s.classes.requireTeacher(tx, classID, userID); err != nil {
+		return model.QuizAssignment{}, err
+	}
+	assignment, err := s.assignmentRepo.GetAssignment(tx, classID, assignmentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return assignment, NotFoundError("Assignment not found", err)
+	}
+	return assignment, err
+}
+
+func buildAssignmentResults(view dto.QuizAssignment, submissions []dto.AssignmentSubmission, questions []model.Question, answers []dto.AssignmentAnswer, now time.Time) dto.AssignmentResults {
+	result := dto.AssignmentResults{
+		Assignment: view,
+		Progress:   assignmentProgress(view, submissions, now),
+		Questions:  make([]dto.AssignmentQuestion, 0, len(questions)),
+		Students:   make([]dto.AssignmentResultRow, 0, len(submissions)),
+	}
+
+	position := make(map[uint]int, len(questions))
+	for i, q := range questions {
+		position[q.ID] = i
+		result.Questions = append(result.Questions, dto.AssignmentQuestion{
+			QuestionID: q.ID,
+			Question:   q.Question,
+			Topic:      string(q.Topic),
+		})
+	}
+
+	byStudent := make(map[uint][]*bool)
+	for _, a := range answers {
+		i, ok := position[a.QuestionID]
+		if !ok {
+			continue
+		}
+		row, ok := byStudent[a.UserID]
+		if !ok {
+			row = make([]*bool, len(questions))
+			byStudent[a.UserID] = row
+		}
+		correct := a.Correct
+		row[i] = &correct
+
+		result.Questions[i].Answered++
+		if correct {
+			result.Questions[i].Correct++
+		}
+	}
+	for i := range result.Questions {
+		q := &result.Questions[i]
+		if q.Answered > 0 {
+			d := round2(float64(q.Answered-q.Correct) * 100 / float64(q.Answered))
+			q.Difficulty = &d
+		}
+	}
+
+	for _, sub := range submissions {
+		row, ok := byStudent[sub.UserID]
+		if !ok {
+			row = make([]*bool, len(questions))
+		}
+		result.Students = append(result.Students, dto.AssignmentResultRow{
+			UserID:           sub.UserID,
+			Name:             sub.Name,
+			Email:            sub.Email,
+			AssignmentStatus: assignmentStatus(view, sub, now),
+			Answers:          row,
+		})
+	}
+	return result
+}
+
+func assignmentStatus(a dto.QuizAssignment, sub dto.AssignmentSubmission, now time.Time) dto.AssignmentStatus {
+	status := dto.AssignmentStatus{
+		Status:           constants.AssignmentAssigned,
+		Attempts:         sub.Attempts,
+		BestScore:        sub.BestScore,
+		FirstSubmittedAt: sub.FirstSubmittedAt,
+		LastSubmittedAt:  sub.LastSubmittedAt,
+	}
+	switch {
+	case sub.Attempts > 0:
+		status.Status = constants.AssignmentCompleted
+		status.Late = sub.FirstSubmittedAt != nil && sub.FirstSubmittedAt.After(a.DueAt)
+	case now.After(a.DueAt):
+		status.Status = constants.AssignmentOverdue
+	}
+	if a.MaxAttempts != nil {
+		left := max(0, *a.MaxAttempts-sub.Attempts)
+		status.AttemptsLeft = &left
+	}
+	return status
+}
+
+func assignmentProgress(a dto.QuizAssignment, submissions []dto.AssignmentSubmission, now time.Time) dto.AssignmentProgress {
+	progress := dto.AssignmentProgress{Students: len(submissions)}
+	for _, sub := range submissions {
+		status := assignmentStatus(a, sub, now)
+		switch status.Status {
+		case constants.AssignmentCompleted:
+			progress.Completed++
+			if status.Late {
+				progress.Late++
+			}
+		case constants.AssignmentOverdue:
+			progress.Overdue++
+		}
+	}
+	return progress
+}
@@ -1,25 +1,58 @@
 package service
 
 import (
+	"M-AI/api/constants"
 	"M-AI/api/dto"
 	"M-AI/api/model"
 	"M-AI/api/repository"
+	"M-AI/api/requests"
 	"M-AI/internal/config"
 	"M-AI/pkg/auth"
 	"M-AI/pkg/db"
+	"M-AI/pkg/mailer"
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
+	"strings"
+	"time"
 )
 
 type AuthService struct {
-	authRepo *repository.AuthRepository
-	db       *gorm.DB
+	authRepo      *repository.AuthRepository
+	sessionRepo   *repository.SessionRepository
+	tokenRepo     *repository.UserTokenRepository
+	loginRepo     *repository.LoginAttemptRepository
+	apiKeyRepo    *repository.APIKeyRepository
+	twoFactorRepo *repository.TwoFactorRepository
+	mailer        mailer.Mailer
+	db            *gorm.DB
 }
 
-func NewAuthService(db *gorm.DB, authRepo *repository.AuthRepository) *AuthService {
-	return &AuthService{authRepo: authRepo, db: db}
+func NewAuthService(
+	db *gorm.DB,
+	authRepo *repository.AuthRepository,
+	sessionRepo *repository.SessionRepository,
+	tokenRepo *repository.UserTokenRepository,
+	loginRepo *repository.LoginAttemptRepository,
+	apiKeyRepo *repository.APIKeyRepository,
+	twoFactorRepo *repository.TwoFactorRepository,
+	mailer mailer.Mailer,
+) *AuthService {
+	return &AuthService{
+		authRepo:      authRepo,
+		sessionRepo:   sessionRepo,
+		tokenRepo:     tokenRepo,
+		loginRepo:     loginRepo,
+		apiKeyRepo:    apiKeyRepo,
+		twoFactorRepo: twoFactorRepo,
+		mailer:        mailer,
+		db:            db,
+	}
 }
+
+// SignUp creates the account and emails a link to verify the address.
 func (s *AuthService) SignUp(user *model.User) error {
+	var msg mailer.Message
 	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
 		_, err := s.authRepo.GetUserByEmail(tx, user.Email)
 		if err == nil {
@@ -30,38 +63,76 @@ func (s *AuthService) SignUp(user *model.User) error {
 			return BadRequestError("invalid password", err)
 		}
 		user.Password = hashed
-		return s.authRepo.CreateUser(tx, user)
+		if err := s.authRepo.CreateUser(tx, user); err != nil {
+			return err
+		}
+		msg, err = s.verificationMessage(tx, *user)
+		return err
 	})
 	if err != nil {
 		return InternalError("Failed to sign up", err)
 	}
+	s.sendMail(msg)
 	return nil
 }
-func (s *AuthService) AuthenticateUser(email, password string) (dto.AuthUserDTO, error) {
+
+// AuthenticateUser checks a user's credentials and records the attempt. An
+// account with too many consecutive failures is locked for a while, and
+// attempts on it are refused without checking the password. Users with
+// two-factor authentication get a challenge to complete with
+// CompleteSecondFactor rather than being signed in.
+func (s *AuthService) AuthenticateUser(email, password, ip, userAgent string) (dto.AuthUserDTO, *dto.MFAChallenge, error) {
 	var result dto.AuthUserDTO
+	var challenge *dto.MFAChallenge
+	var lockedFor time.Duration
+	attempt := model.LoginAttempt{
+		Email:     strings.ToLower(strings.TrimSpace(email)),
+		IP:        ip,
+		UserAgent: userAgent,
+	}
 
 	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
-		user, err := s.authRepo.GetUserByEmail(tx, email)
-		if err != nil {
+		var err error
+		if lockedFor, err = s.lockedFor(tx, attempt.Email, time.Now()); err != nil {
 			return err
 		}
-
-		if !auth.VerifyPassword(password, config.AppConfig.Auth.SecretKey, config.AppConfig.Auth.Salt, user.Password) {
-			return BadRequestError("invalid credentials", errors.New("invalid credentials"))
+		if lockedFor > 0 {
+			attempt.Outcome = constants.LoginLocked
+			return s.loginRepo.Record(tx, &attempt)
 		}
 
-		result = dto.AuthUserDTO{
-			ID:    user.ID,
-			Email: user.Email,
-			Name:  user.Name,
+		attempt.Outcome = constants.LoginFailed
+		user, err := s.authRepo.GetUserByEmail(tx, strings.TrimSpace(email))
+		if err == nil {
+			attempt.UserID = &user.ID
+			if auth.VerifyPassword(password, config.AppConfig.Auth.SecretKey, config.AppConfig.Auth.Salt, user.Password) {
+				if challenge, err = s.loginChallenge(tx, user.ID, user.Role); err != nil {
+					return err
+				}
+				attempt.Outcome = constants.LoginSuccess
+				if challenge != nil {
+					attempt.Outcome = constants.LoginChallenged
+				}
+				result = dto.AuthUserDTO{
+					ID:    user.ID,
+					Email: user.Email,
+					Name:  user.Name,
+					Role:  user.Role,
+				}
+			}
 		}
-		return nil
+		return s.loginRepo.Record(tx, &attempt)
 	})
 
-	if err != nil {
-		return result, InternalError("authentication failed", errors.New("authentication failed"))
+	switch {
+	case err != nil:
+		return result, nil, InternalError("authentication failed", err)
+	case attempt.Outcome == constants.LoginLocked:
+		return result, nil, TooManyRequestsError("Too many failed sign-in attempts, please try again later", lockedFor)
+	case attempt.Outcome == constants.LoginFailed:
+		return result, nil, UnauthorizedError("Invalid email or password")
 	}
-	return result, nil
+	return result, challenge, nil
 }
 
 func (s *AuthService) GetUserByID(userID uint) (model.User, error) {
@@ -91,7 +162,10 @@ func (s *AuthService) ChangeUserName(userID uint, newName string) error {
 		return tx.Save(user).Error
 	})
 }
-func (s *AuthService) ChangeUserPassword(userID uint, oldPassword, newPassword string) error {
+
+// ChangeUserPassword sets a new password and signs the user out of every
+// other session, keeping the one that made the change.
+func (s *AuthService) ChangeUserPassword(userID, sessionID uint, oldPassword, newPassword string) error {
 	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
 		user, err := s.authRepo.GetUserByIDWithPw(tx, userID)
 		if err != nil {
@@ -105,6 +179,64 @@ func (s *AuthService) ChangeUserPassword(userID uint, oldPassword, newPassword s
 			return InternalError("invalid password", err)
 		}
 		user.Password = password
-		return tx.Save(user).Error
+		if err := tx.Save(user).Error; err != nil {
+			return err
+		}
+		_, err = s.sessionRepo.RevokeAll(tx, userID, sessionID, time.Now())
+		return err
+	})
+}
+
+// UpdateStudyGoal sets the daily goal and the IANA timezone study days are
+// counted in.
+func (s *AuthService) UpdateStudyGoal(userID uint, req requests.UpdateStudyGoalRequest) error {
+	// LoadLocation reads "" as UTC and "Local" as the server's zone; neither
+	// is a zone name the database knows, so both are refused.
+	if _, err := time.LoadLocation(req.Timezone); err != nil || req.Timezone == "" || req.Timezone == "Local" {
+		return ValidationError(fmt.Sprintf("Unknown timezone %q", req.Timezone))
+	}
+
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		return s.authRepo.UpdateStudyGoal(tx, userID, req.GoalType, req.GoalTarget, req.Timezone)
 	})
+	if err != nil {
+		return InternalError("Failed to update study goal", err)
+	}
+	return nil
+}
+
+// SetUserRole changes a user's role. The last admin cannot be demoted, so
+// there is always someone able to manage roles. The change applies to the
+// user's tokens from their next refresh.
+func (s *AuthService) SetUserRole(userID uint, role string) error {
+	if !auth.IsValidRole(role) {
+		return ValidationError(fmt.Sprintf("Unknown role %q", role))
+	}
+
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		user, err := s.authRepo.GetUserByID(tx, userID)
+		if err != nil {
+			return NotFoundError("User not found", err)
+		}
+		if user.Role == auth.RoleAdmin && role != auth.RoleAdmin {
+			admins, err := s.authRepo.CountByRole(tx, auth.RoleAdmin)
+			if err != nil {
+				return err
+			}
+			if admins <= 1 {
+				return ConflictError("Cannot demote the last admin", errors.New("last admin"))
+			}
+		}
+		return s.authRepo.SetRole(tx, userID, role)
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to update role", err)
+	}
 }
@@ -0,0 +1,386 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/api/requests"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/db"
+	"crypto/rand"
+	"errors"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// joinCodeAlphabet leaves out letters and digits that are easily misread
+	// when a code is copied from a whiteboard. Its 32 characters divide 256,
+	// so drawing from random bytes is unbiased.
+	joinCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+	joinCodeLength   = 8
+	joinCodeAttempts = 5
+)
+
+type ClassroomService struct {
+	classroomRepo *repository.ClassroomRepository
+	dashboardRepo *repository.DashboardRepository
+	authRepo      *repository.AuthRepository
+	db            *gorm.DB
+}
+
+func NewClassroomService(db *gorm.DB, classroomRepo *repository.ClassroomRepository, dashboardRepo *repository.DashboardRepository, authRepo *repository.AuthRepository) *ClassroomService {
+	return &ClassroomService{classroomRepo: classroomRepo, dashboardRepo: dashboardRepo, authRepo: authRepo, db: db}
+}
+
+func (s *ClassroomService) CreateClassroom(userID uint, req requests.ClassroomRequest) (dto.ClassroomDetail, error) {
+	var result dto.ClassroomDetail
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		code, err := s.newJoinCode(tx)
+		if err != nil {
+			return err
+		}
+		classroom := model.Classroom{
+			Name:        strings.TrimSpace(req.Name),
+			Description: strings.TrimSpace(req.Description),
+			OwnerID:     userID,
+			JoinCode:    code,
+		}
+		if err := s.classroomRepo.CreateClassroom(tx, &classroom); err != nil {
+			return err
+		}
+		owner := model.ClassroomMember{ClassroomID: classroom.ID, UserID: userID, Role: constants.ClassTeacher}
+		if err := s.classroomRepo.AddMember(tx, &owner); err != nil {
+			return err
+		}
+		result, err = s.detail(tx, classroom.ID, userID)
+		return err
+	})
+	return result, classroomError(err, "Failed to create class")
+}
+
+func (s *ClassroomService) ListClassrooms(userID uint, req requests.ListClassroomsRequest) (dto.Page[dto.ClassroomSummary], error) {
+	var result dto.Page[dto.ClassroomSummary]
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		page, err := s.classroomRepo.ListClassrooms(tx, userID, req.PageRequest)
+		result = page
+		return err
+	})
+	if err != nil {
+		return result, listError(err, "Failed to fetch classes")
+	}
+	return result, nil
+}
+
+// GetClassroom returns a class to any of its members.
+func (s *ClassroomService) GetClassroom(classID, userID uint) (dto.ClassroomDetail, error) {
+	var result dto.ClassroomDetail
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		detail, err := s.detail(tx, classID, userID)
+		result = detail
+		return err
+	})
+	return result, classroomError(err, "Failed to fetch class")
+}
+
+func (s *ClassroomService) UpdateClassroom(classID, userID uint, req requests.ClassroomRequest) (dto.ClassroomDetail, error) {
+	var result dto.ClassroomDetail
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.requireTeacher(tx, classID, userID); err != nil {
+			return err
+		}
+		err := s.classroomRepo.UpdateClassroom(tx, classID, strings.TrimSpace(req.Name), strings.TrimSpace(req.Description))
+		if err != nil {
+			return err
+		}
+		result, err = s.detail(tx, classID, userID)
+		return err
+	})
+	return result, classroomError(err, "Failed to update class")
+}
+
+// DeleteClassroom archives a class. Only its owner may do so.
+func (s *ClassroomService) DeleteClassroom(classID, userID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.requireOwner(tx, classID, userID); err != nil {
+			return err
+		}
+		return s.classroomRepo.DeleteClassroom(tx, classID)
+	})
+	return classroomError(err, "Failed to delete class")
+}
+
+// RegenerateJoinCode replaces a class's join code, so the old one can no
+// longer be used to enrol.
+func (s *ClassroomService) RegenerateJoinCode(classID, userID uint) (dto.ClassroomDetail, error) {
+	var result dto.ClassroomDetail
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.requireTeacher(tx, classID, userID); err != nil {
+			return err
+		}
+		code, err := s.newJoinCode(tx)
+		if err != nil {
+			return err
+		}
+		if err := s.classroomRepo.SetJoinCode(tx, classID, code); err != nil {
+			return err
+		}
+		result, err = s.detail(tx, classID, userID)
+		return err
+	})
+	return result, classroomError(err, "Failed to regenerate join code")
+}
+
+// JoinClassroom enrols the user as a student of the class with the given
+// join code.
+func (s *ClassroomService) JoinClassroom(userID uint, code string) (dto.ClassroomDetail, error) {
+	code = strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+
+	var result dto.ClassroomDetail
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		classroom, err := s.classroomRepo.GetClassroomByCode(tx, code)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return NotFoundError("No class has this join code", err)
+		} else if err != nil {
+			return err
+		}
+
+		if _, err := s.classroomRepo.GetMembership(tx, classroom.ID, userID); err == nil {
+			return ConflictError("You are already a member of this class", errors.New("already a member"))
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		member := model.ClassroomMember{ClassroomID: classroom.ID, UserID: userID, Role: constants.ClassStudent}
+		if err := s.classroomRepo.AddMember(tx, &member); err != nil {
+			return err
+		}
+		result, err = s.detail(tx, classroom.ID, userID)
+		return err
+	})
+	return result, classroomError(err, "Failed to join class")
+}
+
+// RemoveStudent takes a student off the roster. Teachers of the class may
+// remove anyone, and students may remove themselves to leave.
+func (s *ClassroomService) RemoveStudent(classID, userID, studentID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if studentID != userID {
+			if _, err := s.requireTeacher(tx, classID, userID); err != nil {
+				return err
+			}
+		}
+		removed, err := s.classroomRepo.RemoveMember(tx, classID, studentID, constants.ClassStudent)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return NotFoundError("Student is not enrolled in this class", errors.New("not enrolled"))
+		}
+		return nil
+	})
+	return classroomError(err, "Failed to remove student")
+}
+
+// AddTeacher makes another teacher a co-teacher of the class. Only the owner
+// may add co-teachers.
+func (s *ClassroomService) AddTeacher(classID, userID uint, email string) (dto.ClassroomDetail, error) {
+	var result dto.ClassroomDetail
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.requireOwner(tx, classID, userID); err != nil {
+			return err
+		}
+
+		teacher, err := s.authRepo.GetUserByEmail(tx, strings.TrimSpace(email))
+		if err != nil {
+			return NotFoundError("No user has this email", err)
+		}
+		if !auth.Can(teacher.Role, auth.ManageClasses) {
+			return ValidationError("Only teachers can be added as co-teachers")
+		}
+
+		if _, err := s.classroomRepo.GetMembership(tx, classID, teacher.ID); err == nil {
+			return ConflictError("This user is already a member of the class", errors.New("already a member"))
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		member := model.ClassroomMember{ClassroomID: classID, UserID: teacher.ID, Role: constants.ClassTeacher}
+		if err := s.classroomRepo.AddMember(tx, &member); err != nil {
+			return err
+		}
+		result, err = s.detail(tx, classID, userID)
+		return err
+	})
+	return result, classroomError(err, "Failed to add co-teacher")
+}
+
+// RemoveTeacher removes a co-teacher. The owner may remove any co-teacher and
+// a co-teacher may remove themselves; the owner cannot be removed.
+func (s *ClassroomService) RemoveTeacher(classID, userID, teacherID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		var classroom model.Classroom
+		var err error
+		if teacherID == userID {
+			classroom, err = s.requireTeacher(tx, classID, userID)
+		} else {
+			classroom, err = s.requireOwner(tx, classID, userID)
+		}
+		if err != nil {
+			return err
+		}
+		if teacherID == classroom.OwnerID {
+			return ConflictError("The class owner cannot be removed", errors.New("owner"))
+		}
+
+		removed, err := s.classroomRepo.RemoveMember(tx, classID, teacherID, constants.ClassTeacher)
+		if err != nil {
+			return err
+		}
+		if !removed {
+			return NotFoundError("User is not a teacher of this class", errors.New("not a teacher"))
+		}
+		return nil
+	})
+	return classroomError(err, "Failed to remove co-teacher")
+}
+
+// ListStudents returns a page of the class roster with each student's
+// dashboard stats, for the class's teachers.
+func (s *ClassroomService) ListStudents(classID, userID uint, req requests.ListClassStudentsRequest) (dto.Page[dto.ClassroomStudent], error) {
+	var result dto.Page[dto.ClassroomStudent]
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.requireTeacher(tx, classID, userID); err != nil {
+			return err
+		}
+		page, err := s.classroomRepo.ListStudents(tx, classID, req.FilterRequest, req.PageRequest)
+		if err != nil {
+			return err
+		}
+		for i := range page.Items {
+			stats, err := s.dashboardRepo.GetDashboardStats(tx, page.Items[i].UserID)
+			if err != nil {
+				return err
+			}
+			page.Items[i].Stats = stats
+		}
+		result = page
+		return nil
+	})
+
+	var serr *ServiceError
+	if errors.As(err, &serr) {
+		return result, serr
+	}
+	if err != nil {
+		return result, listError(err, "Failed to fetch students")
+	}
+	return result, nil
+}
+
+// TeachesStudent reports whether teacherID teaches a class studentID is
+// enrolled in.
+func (s *ClassroomService) TeachesStudent(teacherID, studentID uint) (bool, error) {
+	var teaches bool
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		var err error
+		teaches, err = s.classroomRepo.TeachesStudent(tx, teacherID, studentID)
+		return err
+	})
+	return teaches, err
+}
+
+func (s *ClassroomService) detail(tx *gorm.DB, classID, userID uint) (dto.ClassroomDetail, error) {
+	summary, err := s.classroomRepo.GetSummary(tx, classID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return dto.ClassroomDetail{}, NotFoundError("Class not found", err)
+	} else if err != nil {
+		return dto.ClassroomDetail{}, err
+	}
+	teachers, err := s.classroomRepo.GetTeachers(tx, classID)
+	if err != nil {
+		return dto.ClassroomDetail{}, err
+	}
+	return dto.ClassroomDetail{ClassroomSummary: summary, Teachers: teachers}, nil
+}
+
+// requireMember loads a class the user belongs to and their membership of
+// it. Classes the user does not belong to are reported as not found, so their
+// existence is not revealed.
+func (s *ClassroomService) requireMember(tx *gorm.DB, classID, userID uint) (model.Classroom, model.ClassroomMember, error) {
+	var member model.ClassroomMember
+	classroom, err := s.classroomRepo.GetClassroom(tx, classID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return classroom, member, NotFoundError("Class not found", err)
+	} else if err != nil {
+		return classroom, member, err
+	}
+
+	member, err = s.classroomRepo.GetMembership(tx, classID, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return classroom, member, NotFoundError("Class not found", err)
+	}
+	return classroom, member, err
+}
+
+func (s *ClassroomService) requireTeacher(tx *gorm.DB, classID, userID uint) (model.Classroom, error) {
+	classroom, member, err := s.requireMember(tx, classID, userID)
+	if err != nil {
+		return classroom, err
+	}
+	if member.Role != constants.ClassTeacher {
+		return classroom, ForbiddenError("Only teachers of this class can do this")
+	}
+	return classroom, nil
+}
+
+func (s *ClassroomService) requireOwner(tx *gorm.DB, classID, userID uint) (model.Classroom, error) {
+	classroom, err := s.requireTeacher(tx, classID, userID)
+	if err != nil {
+		return classroom, err
+	}
+	if classroom.OwnerID != userID {
+		return classroom, ForbiddenError("Only the owner of this class can do this")
+	}
+	return classroom, nil
+}
+
+func (s *ClassroomService) newJoinCode(tx *gorm.DB) (string, error) {
+	for range joinCodeAttempts {
+		code, err := randomJoinCode()
+		if err != nil {
+			return "", err
+		}
+		if _, err := s.classroomRepo.GetClassroomByCode(tx.Unscoped(), code); errors.Is(err, gorm.ErrRecordNotFound) {
+			return code, nil
+		} else if err != nil {
+			return "", err
+		}
+	}
+	return "", errors.New("could not find an unused join code")
+}
+
+func randomJoinCode() (string, error) {
+	buf := make([]byte, joinCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = joinCodeAlphabet[int(b)%len(joinCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+func classroomError(err error, fallback string) error {
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError(fallback, err)
+	}
+}
@@ -0,0 +1,435 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/pkg/db"
+	"errors"
+	"fmt"
+	"golang.org/x/sync/singleflight"
+	"gorm.io/gorm"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	dateLayout           = "2006-01-02"
+	dailyLeaderboardSize = 50
+)
+
+var dailyLevels = []string{constants.LevelBeginner, constants.LevelIntermediate, constants.LevelAdvanced}
+
+type dailyPick struct {
+	challenge model.DailyChallenge
+	problem   model.Problem
+}
+
+type DailyChallengeService struct {
+	repo           *repository.DailyChallengeRepository
+	problemRepo    *repository.ProblemRepository
+	userLogRepo    *repository.UserLogRepository
+	problemService *ProblemService
+	aiService      *OpenAIService
+	achievements   *AchievementService
+	db             *gorm.DB
+
+	mu    sync.Mutex
+	cache map[string]dailyPick
+	picks singleflight.Group
+}
+
+func NewDailyChallengeService(
+	db *gorm.DB,
+	repo *repository.DailyChallengeRepository,
+	problemRepo *repository.ProblemRepository,
+	userLogRepo *repository.UserLogRepository,
+	problemService *ProblemService,
+	aiService *OpenAIService,
+	achievements *AchievementService,
+) *DailyChallengeService {
+	return &DailyChallengeService{
+		repo:           repo,
+		problemRepo:    problemRepo,
+		userLogRepo:    userLogRepo,
+		problemService: problemService,
+		aiService:      aiService,
+		achievements:   achievements,
+		db:             db,
+		cache:          make(map[string]dailyPick),
+	}
+}
+
+func today() string {
+	return time.Now().UTC().Format(dateLayout)
+}
+
+// EnsureTodayChallenges picks today's problem for every level. It is run by
+// the scheduler just after midnight so the first request of the day does not
+// wait on problem generation.
+func (s *DailyChallengeService) EnsureTodayChallenges() error {
+	date := today()
+	for _, level := range dailyLevels {
+		if _, err := s.pick(level, date); err != nil {
+			return fmt.Errorf("daily challenge for %s: %w", level, err)
+		}
+	}
+	return nil
+}
+
+// pick returns the challenge for a level and date, creating it if needed.
+// Concurrent callers for the same level and date share one load, so a slow
+// problem generation holds up only that challenge.
+func (s *DailyChallengeService) pick(level, date string) (dailyPick, error) {
+	key := date + "/" + level
+
+	s.mu.Lock()
+	p, ok := s.cache[key]
+	s.mu.Unlock()
+	if ok {
+		return p, nil
+	}
+
+	v, err, _ := s.picks.Do(key, func() (interface{}, error) {
+		return s.loadPick(level, date)
+	})
+	if err != nil {
+		return dailyPick{}, err
+	}
+	p = v.(dailyPick)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k := range s.cache {
+		if !strings.HasPrefix(k, date) {
+			delete(s.cache, k)
+		}
+	}
+	s.cache[key] = p
+	return p, nil
+}
+
+// loadPick finds the stored challenge for a level and date, or creates it from
+// the problem bank, or by generating a new problem when the bank is empty.
+func (s *DailyChallengeService) loadPick(level, date string) (dailyPick, error) {
+	var result dailyPick
+	var needsProblem bool
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		challenge, err := s.repo.GetByDateAndLevel(tx, date, level)
+		if err == nil {
+			problem, err := s.problemRepo.GetProblemByID(tx, challenge.ProblemID)
+			result = dailyPick{challenge: challenge, problem: problem}
+			return err
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		problem, err := s.repo.PickProblem(tx, level, date)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			needsProblem = true
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		result, err = s.createChallenge(tx, level, date, problem)
+		return err
+	})
+	if err != nil {
+		return result, err
+	}
+
+	if needsProblem {
+		generated, err := s.aiService.GenerateProblem(level)
+		if err != nil {
+			return result, fmt.Errorf("failed to generate problem: %w", err)
+		}
+
+		err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+			problem := model.Problem{
+				Topic:      constants.TopicEnum(generated.Topic),
+				Level:      level,
+				Title:      generated.Title,
+				Question:   generated.Question,
+				Answer:     generated.Answer,
+				AnswerType: generated.AnswerType,
+				Tolerance:  generated.Tolerance,
+				Solution:   generated.Solution,
+			}
+			if problem.AnswerType != constants.AnswerTypeNumeric {
+				problem.AnswerType = constants.AnswerTypeAI
+			}
+			if err := s.problemRepo.CreateProblem(tx, &problem); err != nil {
+				return err
+			}
+			result, err = s.createChallenge(tx, level, date, problem)
+			return err
+		})
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, nil
+}
+
+func (s *DailyChallengeService) createChallenge(tx *gorm.DB, level, date string, problem model.Problem) (dailyPick, error) {
+	day, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return dailyPick{}, err
+	}
+
+	challenge, err := s.repo.CreateIfAbsent(tx, &model.DailyChallenge{
+		ChallengeDate: day,
+		Level:         level,
+		ProblemID:     problem.ID,
+	})
+	if err != nil {
+		return dailyPick{}, err
+	}
+
+	// Another instance may have won the race with a different problem.
+	if challenge.ProblemID != problem.ID {
+		problem, err = s.problemRepo.GetProblemByID(tx, challenge.ProblemID)
+		if err != nil {
+			return dailyPick{}, err
+		}
+	}
+	return dailyPick{challenge: challenge, problem: problem}, nil
+}
+
+func (s *DailyChallengeService) GetToday(userID uint, level string) (dto.DailyChallenge, error) {
+	var result dto.DailyChallenge
+	if level == "" {
+		level = constants.LevelBeginner
+	}
+
+	date := today()
+	p, err := s.pick(level, date)
+	if err != nil {
+		return result, InternalError("Failed to load daily challenge", err)
+	}
+
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		attempts, solved, err := s.repo.GetUserAttemptStats(tx, p.challenge.ID, userID)
+		if err != nil {
+			return err
+		}
+		streak, err := s.repo.GetStreak(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		result = dto.DailyChallenge{
+			Date:       date,
+			Level:      level,
+			ProblemID:  p.problem.ID,
+			Topic:      string(p.problem.Topic),
+			Title:      p.problem.Title,
+			Question:   p.problem.Question,
+			AnswerType: p.problem.AnswerType,
+			Attempts:   attempts,
+			Solved:     solved,
+			Streak:     streakView(streak, date),
+		}
+		return nil
+	})
+	if err != nil {
+		return result, InternalError("Failed to load daily challenge", err)
+	}
+	return result, nil
+}
+
+// Attempt grades an answer to today's challenge. Every attempt is logged for
+// proficiency and keeps the streak alive; the answer is only revealed once the
+// challenge is solved.
+func (s *DailyChallengeService) Attempt(userID uint, level, answer string) (dto.DailyChallengeAttemptResult, error) {
+	var result dto.DailyChallengeAttemptResult
+	if level == "" {
+		level = constants.LevelBeginner
+	}
+
+	date := today()
+	p, err := s.pick(level, date)
+	if err != nil {
+		return result, InternalError("Failed to load daily challenge", err)
+	}
+
+	alreadySolved := ConflictError("Daily challenge already solved", errors.New("already solved"))
+	// Checked before grading too, so a solved challenge costs no AI call.
+	var solved bool
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		_, solved, err = s.repo.GetUserAttemptStats(tx, p.challenge.ID, userID)
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to record attempt", err)
+	}
+	if solved {
+		return result, alreadySolved
+	}
+
+	correct, feedback, err := s.problemService.Grade(userID, p.problem, answer)
+	if err != nil {
+		return result, err
+	}
+
+	// The unique index on solved attempts stops two concurrent correct
+	// answers from both being recorded.
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		_, solved, err := s.repo.GetUserAttemptStats(tx, p.challenge.ID, userID)
+		if err != nil {
+			return err
+		}
+		if solved {
+			return alreadySolved
+		}
+		created, err := s.repo.CreateAttempt(tx, &model.DailyChallengeAttempt{
+			ChallengeID: p.challenge.ID,
+			UserID:      userID,
+			Correct:     correct,
+		})
+		if err != nil {
+			return err
+		}
+		if !created {
+			return alreadySolved
+		}
+
+		err = s.userLogRepo.Create(tx, &model.UserLog{
+			CorrectAnswer: correct,
+			UserID:        userID,
+			FromQuiz:      false,
+			ProblemID:     &p.problem.ID,
+		})
+		if err != nil {
+			return err
+		}
+
+		streak, err := s.repo.GetStreak(tx, userID)
+		if err != nil {
+			return err
+		}
+		advanceStreak(&streak, date)
+		if err := s.repo.SaveStreak(tx, &streak); err != nil {
+			return err
+		}
+
+		result = dto.DailyChallengeAttemptResult{
+			Correct:  correct,
+			Feedback: feedback,
+			Streak:   streakView(streak, date),
+		}
+		if correct {
+			result.Answer = p.problem.Answer
+			result.Solution = p.problem.Solution
+		}
+		return nil
+	})
+	var serr *ServiceError
+	switch {
+	case err == nil:
+	case errors.As(err, &serr):
+		return result, serr
+	default:
+		return result, InternalError("Failed to record attempt", err)
+	}
+	s.achievements.Publish(dto.AchievementEvent{Type: constants.EventProblemAnswered, UserID: userID})
+	return result, nil
+}
+
+func (s *DailyChallengeService) GetLeaderboard(level, date string) ([]dto.DailyLeaderboardEntry, error) {
+	if level == "" {
+		level = constants.LevelBeginner
+	}
+	if date == "" {
+		date = today()
+	}
+
+	var challenge model.DailyChallenge
+	if date == today() {
+		p, err := s.pick(level, date)
+		if err != nil {
+			return nil, InternalError("Failed to load daily challenge", err)
+		}
+		challenge = p.challenge
+	} else {
+		err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+			c, err := s.repo.GetByDateAndLevel(tx, date, level)
+			challenge = c
+			return err
+		})
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, NotFoundError("No daily challenge on that date", err)
+		}
+		if err != nil {
+			return nil, InternalError("Failed to load daily challenge", err)
+		}
+	}
+
+	var result []dto.DailyLeaderboardEntry
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		entries, err := s.repo.GetLeaderboard(tx, challenge.ID, dailyLeaderboardSize)
+		result = entries
+		return err
+	})
+	if err != nil {
+		return nil, InternalError("Failed to load leaderboard", err)
+	}
+	return result, nil
+}
+
+func (s *DailyChallengeService) GetStreak(userID uint) (dto.DailyStreak, error) {
+	var result dto.DailyStreak
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		streak, err := s.repo.GetStreak(tx, userID)
+		result = streakView(streak, today())
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to load streak", err)
+	}
+	return result, nil
+}
+
+// advanceStreak extends the streak when the last attempt was yesterday,
+// leaves it alone for a repeat attempt today and restarts it otherwise.
+func advanceStreak(streak *model.DailyStreak, date string) {
+	day, _ := time.Parse(dateLayout, date)
+	yesterday := day.AddDate(0, 0, -1).Format(dateLayout)
+
+	switch {
+	case streak.LastAttemptDate != nil && streak.LastAttemptDate.Format(dateLayout) == date:
+		return
+	case streak.LastAttemptDate != nil && streak.LastAttemptDate.Format(dateLayout) == yesterday:
+		streak.Current++
+	default:
+		streak.Current = 1
+	}
+
+	if streak.Current > streak.Longest {
+		streak.Longest = streak.Current
+	}
+	streak.LastAttemptDate = &day
+}
+
+// streakView reports a streak as broken once a full day has been missed,
+// without waiting for the next attempt to reset the stored counter.
+func streakView(streak model.DailyStreak, date string) dto.DailyStreak {
+	view := dto.DailyStreak{
+		Current:         streak.Current,
+		Longest:         streak.Longest,
+		LastAttemptDate: streak.LastAttemptDate,
+	}
+
+	day, _ := time.Parse(dateLayout, date)
+	yesterday := day.AddDate(0, 0, -1).Format(dateLayout)
+	if streak.LastAttemptDate == nil {
+		view.Current = 0
+	} else if last := streak.LastAttemptDate.Format(dateLayout); last != date && last != yesterday {
+		view.Current = 0
+	}
+	return view
+}
@@ -1,19 +1,22 @@
 package service
 
 import (
+	"M-AI/api/constants"
 	"M-AI/api/dto"
 	"M-AI/api/repository"
 	"M-AI/pkg/db"
 	"gorm.io/gorm"
+	"time"
 )
 
 type DashboardService struct {
-	repo *repository.DashboardRepository
-	db   *gorm.DB
+	repo        *repository.DashboardRepository
+	masteryRepo *repository.MasteryRepository
+	db          *gorm.DB
 }
 
-func NewDashboardService(db *gorm.DB, repo *repository.DashboardRepository) *DashboardService {
-	return &DashboardService{repo: repo, db: db}
+func NewDashboardService(db *gorm.DB, repo *repository.DashboardRepository, masteryRepo *repository.MasteryRepository) *DashboardService {
+	return &DashboardService{repo: repo, masteryRepo: masteryRepo, db: db}
 }
 
 func (s *DashboardService) GetStats(userID uint) (dto.DashboardStats, error) {
@@ -54,6 +57,16 @@ func (s *DashboardService) GetTopicProficiency(userID uint) ([]dto.TopicProficie
 		if err != nil {
 			return err
 		}
+		rows, err := s.masteryRepo.GetUserMastery(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		current := currentMastery(rows, time.Now())
+		for i := range proficiency {
+			p := current[constants.TopicEnum(proficiency[i].Topic)]
+			proficiency[i].Mastery = round2(p * 100)
+		}
 		result = proficiency
 		return nil
 	})
@@ -1,11 +1,17 @@
 package service
 
-import "errors"
+import (
+	"errors"
+	"time"
+)
 
 type ServiceError struct {
 	Code    string
 	Message string
 	Err     error
+	// RetryAfter is how long a client should wait before retrying a
+	// too_many_requests error.
+	RetryAfter time.Duration
 }
 
 func (e *ServiceError) Error() string {
@@ -24,6 +30,24 @@ func ConflictError(message string, err error) *ServiceError {
 	return &ServiceError{Code: "conflict", Message: message, Err: err}
 }
 
+func UnauthorizedError(message string) *ServiceError {
+	return &ServiceError{Code: "unauthorized", Message: message, Err: errors.New(message)}
+}
+
+func ForbiddenError(message string) *ServiceError {
+	return &ServiceError{Code: "forbidden", Message: message, Err: errors.New(message)}
+}
+
+func TooManyRequestsError(message string, retryAfter time.Duration) *ServiceError {
+	return &ServiceError{Code: "too_many_requests", Message: message, Err: errors.New(message), RetryAfter: retryAfter}
+}
+
+// UnavailableError reports that a service this one depends on, such as the
+// AI grader, failed, so the request can be retried later.
+func UnavailableError(message string, err error) *ServiceError {
+	return &ServiceError{Code: "unavailable", Message: message, Err: err}
+}
+
 func InternalError(message string, err error) *ServiceError {
 	return &ServiceError{Code: "internal_error", Message: message, Err: err}
 }
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/pkg/ratelimit"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	latexFracPattern = regexp.MustCompile(`\\d?frac\{([^{}]*)\}\{([^{}]*)\}`)
+	numberPattern    = regexp.MustCompile(`^([-+]?(?:\d+\.?\d*|\.\d+))(?:/([-+]?(?:\d+\.?\d*|\.\d+)))?`)
+)
+
+// normalizeAnswer strips formatting that should not affect an exact match:
+// case, whitespace, maths delimiters and a trailing full stop.
+func normalizeAnswer(answer string) string {
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	answer = strings.NewReplacer("$", "", `\(`, "", `\)`, "", "−", "-").Replace(answer)
+	answer = strings.Join(strings.Fields(answer), "")
+	return strings.TrimSuffix(answer, ".")
+}
+
+// parseNumber reads the leading number of an answer, accepting fractions
+// ("3/4", "\frac{3}{4}"), thousands separators and trailing units or "%".
+func parseNumber(answer string) (float64, bool) {
+	s := normalizeAnswer(answer)
+	s = latexFracPattern.ReplaceAllString(s, "$1/$2")
+	s = strings.NewReplacer(",", "", "£", "", "{", "", "}", "").Replace(s)
+
+	m := numberPattern.FindStringSubmatch(s)
+	if m == nil {
+		return 0, false
+	}
+
+	value, err := strconv.ParseFloat(m[1], 64)
+	if err != nil {
+		return 0, false
+	}
+	if m[2] != "" {
+		den, err := strconv.ParseFloat(m[2], 64)
+		if err != nil || den == 0 {
+			return 0, false
+		}
+		value /= den
+	}
+	return value, true
+}
+
+func gradeExact(expected, given string) bool {
+	return normalizeAnswer(expected) == normalizeAnswer(given)
+}
+
+func gradeNumeric(expected, given string, tolerance float64) bool {
+	want, ok := parseNumber(expected)
+	if !ok {
+		return gradeExact(expected, given)
+	}
+	got, ok := parseNumber(given)
+	if !ok {
+		return false
+	}
+	if tolerance <= 0 {
+		tolerance = 1e-9
+	}
+	return math.Abs(want-got) <= tolerance
+}
+
+// gradeLocally grades without calling out to the AI service. AI-graded
+// problems fall back to numeric comparison when the expected answer is a
+// number and to an exact match otherwise.
+func gradeLocally(answerType, expected, given string, tolerance float64) bool {
+	switch answerType {
+	case constants.AnswerTypeNumeric, constants.AnswerTypeAI:
+		return gradeNumeric(expected, given, tolerance)
+	default:
+		return gradeExact(expected, given)
+	}
+}
+
+// takeAIGradingToken spends one of the user's AI grading tokens. Problems,
+// daily challenges and mock exams share the bucket, since each call costs an
+// OpenAI request.
+func takeAIGradingToken(limit *ratelimit.Limiter, userID uint) error {
+	if ok, retryAfter := limit.Allow("grade:user:"+strconv.FormatUint(uint64(userID), 10), time.Now()); !ok {
+		return TooManyRequestsError("Too many answers to grade, please try again later", retryAfter)
+	}
+	return nil
+}
@@ -0,0 +1,16 @@
+package service
+
+import (
+	"M-AI/api/repository"
+	"errors"
+)
+
+// listError reports a bad cursor, sort or filter on a list as a validation
+// error, and anything else as fallback.
+func listError(err error, fallback string) error {
+	var lerr repository.ListError
+	if errors.As(err, &lerr) {
+		return ValidationError(lerr.Error())
+	}
+	return InternalError(fallback, err)
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"M-AI/pkg/db"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	// After lockoutThreshold consecutive failures an account is locked for
+	// lockoutBase, doubling with each further failure up to lockoutMax.
+	// Failures older than lockoutWindow are forgotten.
+	lockoutThreshold = 5
+	lockoutBase      = 30 * time.Second
+	lockoutMax       = time.Hour
+	lockoutWindow    = 24 * time.Hour
+
+	loginAuditRetention = 90 * 24 * time.Hour
+)
+
+// lockoutDelay is how long an account stays locked after its latest failure.
+func lockoutDelay(failures int64) time.Duration {
+	if failures < lockoutThreshold {
+		return 0
+	}
+	delay := lockoutBase
+	for i := int64(lockoutThreshold); i < failures && delay < lockoutMax; i++ {
+		delay *= 2
+	}
+	return min(delay, lockoutMax)
+}
+
+// lockedFor reports how much longer sign-ins to email are refused.
+func (s *AuthService) lockedFor(tx *gorm.DB, email string, now time.Time) (time.Duration, error) {
+	failures, err := s.loginRepo.RecentFailures(tx, email, now.Add(-lockoutWindow))
+	if err != nil || failures.LastFailedAt == nil {
+		return 0, err
+	}
+	until := failures.LastFailedAt.Add(lockoutDelay(failures.Count))
+	return max(until.Sub(now), 0), nil
+}
+
+// recordPasswordReset notes a password reset in the sign-in audit log, which
+// also lifts any lockout on the account.
+func (s *AuthService) recordPasswordReset(tx *gorm.DB, user model.User) error {
+	return s.loginRepo.Record(tx, &model.LoginAttempt{
+		Email:   strings.ToLower(user.Email),
+		UserID:  &user.ID,
+		Outcome: constants.LoginReset,
+	})
+}
+
+// ListLoginAttempts returns a page of the sign-in audit log for admins.
+func (s *AuthService) ListLoginAttempts(userID uint, req requests.ListLoginAttemptsRequest) (dto.Page[dto.LoginAttempt], error) {
+	req.Email = strings.ToLower(strings.TrimSpace(req.Email))
+
+	var result dto.Page[dto.LoginAttempt]
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		page, err := s.loginRepo.ListAttempts(tx, userID, req)
+		result = page
+		return err
+	})
+	if err != nil {
+		return result, listError(err, "Failed to fetch sign-in attempts")
+	}
+	return result, nil
+}
+
+// CleanupLoginAttempts drops audit entries older than the retention period.
+func (s *AuthService) CleanupLoginAttempts() error {
+	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		_, err := s.loginRepo.DeleteBefore(tx, time.Now().Add(-loginAuditRetention))
+		return err
+	})
+}
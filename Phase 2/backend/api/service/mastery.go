@@ -0,0 +1,25 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/model"
+	"M-AI/pkg/mastery"
+	"time"
+)
+
+// currentMastery returns the mastery probability for every topic as of now,
+// decayed since the last attempt. Topics never attempted sit at the prior.
+func currentMastery(rows []model.TopicMastery, now time.Time) map[constants.TopicEnum]float64 {
+	result := make(map[constants.TopicEnum]float64, len(constants.Topics))
+	for _, topic := range constants.Topics {
+		result[topic] = mastery.Initial
+	}
+	for _, row := range rows {
+		p := row.Probability
+		if row.LastAttemptAt != nil {
+			p = mastery.Decay(p, now.Sub(*row.LastAttemptAt))
+		}
+		result[row.Topic] = p
+	}
+	return result
+}
@@ -0,0 +1,532 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/api/requests"
+	"M-AI/pkg/db"
+	"M-AI/pkg/ratelimit"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// submissionGrace allows for network latency when an attempt is submitted
+// right at the deadline.
+const submissionGrace = 2 * time.Minute
+
+// defaultBoundaryShare gives each grade's boundary as a share of the total
+// marks when an exam is created without explicit boundaries. The values
+// follow recent exam board boundaries for a single paper.
+var defaultBoundaryShare = map[string]map[int]float64{
+	constants.TierFoundation: {5: 0.72, 4: 0.57, 3: 0.42, 2: 0.27, 1: 0.12},
+	constants.TierHigher:     {9: 0.78, 8: 0.66, 7: 0.53, 6: 0.40, 5: 0.28, 4: 0.17, 3: 0.11},
+}
+
+var assessmentObjectives = []string{constants.AO1, constants.AO2, constants.AO3}
+
+type MockExamService struct {
+	repo      *repository.MockExamRepository
+	aiService *OpenAIService
+	aiLimit   *ratelimit.Limiter
+	db        *gorm.DB
+}
+
+func NewMockExamService(db *gorm.DB, repo *repository.MockExamRepository, aiService *OpenAIService, aiLimit *ratelimit.Limiter) *MockExamService {
+	return &MockExamService{repo: repo, aiService: aiService, aiLimit: aiLimit, db: db}
+}
+
+func (s *MockExamService) CreateExam(userID uint, req requests.CreateMockExamRequest) (uint, error) {
+	total := 0
+	for _, q := range req.Questions {
+		if !constants.IsValidTopic(string(q.Topic)) {
+			return 0, ValidationError(fmt.Sprintf("Unknown topic %q", q.Topic))
+		}
+		total += q.Marks
+	}
+
+	boundaries, err := resolveBoundaries(req.Tier, req.Boundaries, total)
+	if err != nil {
+		return 0, err
+	}
+
+	var examID uint
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		exam := model.MockExam{
+			Title:            req.Title,
+			Description:      req.Description,
+			Tier:             req.Tier,
+			Paper:            req.Paper,
+			TimeLimitMinutes: req.TimeLimitMinutes,
+			CreatedBy:        &userID,
+		}
+		if err := s.repo.CreateExam(tx, &exam); err != nil {
+			return err
+		}
+		examID = exam.ID
+
+		var questions []model.MockExamQuestion
+		for i, q := range req.Questions {
+			answerType := q.AnswerType
+			if answerType == "" {
+				answerType = constants.AnswerTypeExact
+			}
+			questions = append(questions, model.MockExamQuestion{
+				ExamID:              exam.ID,
+				Position:            i + 1,
+				Question:            q.Question,
+				Answer:              q.Answer,
+				AnswerType:          answerType,
+				Tolerance:           q.Tolerance,
+				MarkScheme:          q.MarkScheme,
+				Marks:               q.Marks,
+				AssessmentObjective: q.AssessmentObjective,
+				Topic:               q.Topic,
+			})
+		}
+		if err := s.repo.BulkCreateQuestions(tx, questions); err != nil {
+			return err
+		}
+
+		for i := range boundaries {
+			boundaries[i].ExamID = exam.ID
+		}
+		return s.repo.BulkCreateBoundaries(tx, boundaries)
+	})
+	if err != nil {
+		return 0, InternalError("Failed to create mock exam", err)
+	}
+	return examID, nil
+}
+
+// resolveBoundaries validates explicit grade boundaries against the tier, or
+// derives default ones from the total marks.
+func resolveBoundaries(tier string, reqs []requests.GradeBoundaryRequest, total int) ([]model.MockExamBoundary, error) {
+	allowed := make(map[int]bool)
+	for _, g := range constants.TierGrades[tier] {
+		allowed[g] = true
+	}
+
+	var boundaries []model.MockExamBoundary
+	if len(reqs) == 0 {
+		for grade, share := range defaultBoundaryShare[tier] {
+			boundaries = append(boundaries, model.MockExamBoundary{
+				Grade:   grade,
+				MinMark: int(math.Ceil(share * float64(total))),
+			})
+		}
+		sort.Slice(boundaries, func(i, j int) bool {
+			return boundaries[i].Grade > boundaries[j].Grade
+		})
+		return boundaries, nil
+	}
+
+	seen := make(map[int]bool)
+	for _, b := range reqs {
+		if !allowed[b.Grade] {
+			return nil, ValidationError(fmt.Sprintf("Grade %d cannot be awarded on the %s tier", b.Grade, tier))
+		}
+		if seen[b.Grade] {
+			return nil, ValidationError(fmt.Sprintf("Duplicate boundary for grade %d", b.Grade))
+		}
+		if b.MinMark > total {
+			return nil, ValidationError(fmt.Sprintf("Boundary for grade %d exceeds the %d marks available", b.Grade, total))
+		}
+		seen[b.Grade] = true
+		boundaries = append(boundaries, model.MockExamBoundary{Grade: b.Grade, MinMark: b.MinMark})
+	}
+
+	sort.Slice(boundaries, func(i, j int) bool {
+		return boundaries[i].Grade > boundaries[j].Grade
+	})
+	for i := 1; i < len(boundaries); i++ {
+		if boundaries[i].MinMark >= boundaries[i-1].MinMark {
+			return nil, ValidationError(fmt.Sprintf(
+				"Boundary for grade %d must be lower than for grade %d",
+				boundaries[i].Grade, boundaries[i-1].Grade,
+			))
+		}
+	}
+	return boundaries, nil
+}
+
+func (s *MockExamService) ListExams(userID uint, req requests.ListMockExamsRequest) (dto.Page[dto.MockExamSummary], error) {
+	var result dto.Page[dto.MockExamSummary]
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		page, err := s.repo.ListExamsWithUserStats(tx, userID, req.Tier, req.Paper, req.FilterRequest, req.PageRequest)
+		result = page
+		return err
+	})
+	if err != nil {
+		return result, listError(err, "Failed to list mock exams")
+	}
+	return result, nil
+}
+
+func (s *MockExamService) GetExam(userID, examID uint) (dto.MockExamDetail, error) {
+	var result dto.MockExamDetail
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		exam, err := s.repo.GetExam(tx, examID)
+		if err != nil {
+			return err
+		}
+		questions, err := s.repo.GetQuestions(tx, examID)
+		if err != nil {
+			return err
+		}
+		boundaries, err := s.repo.GetBoundaries(tx, examID)
+		if err != nil {
+			return err
+		}
+
+		result.MockExamSummary = examSummary(exam, questions)
+		result.Questions = questionViews(questions)
+		for _, b := range boundaries {
+			result.Boundaries = append(result.Boundaries, dto.GradeBoundary{Grade: b.Grade, MinMark: b.MinMark})
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, NotFoundError("Mock exam not found", err)
+	}
+	if err != nil {
+		return result, InternalError("Failed to fetch mock exam", err)
+	}
+	return result, nil
+}
+
+// StartAttempt opens a timed attempt, or returns the student's attempt that
+// is already in progress for this exam.
+func (s *MockExamService) StartAttempt(userID, examID uint) (dto.MockExamAttempt, error) {
+	var result dto.MockExamAttempt
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		exam, err := s.repo.GetExam(tx, examID)
+		if err != nil {
+			return err
+		}
+		questions, err := s.repo.GetQuestions(tx, examID)
+		if err != nil {
+			return err
+		}
+
+		attempt, err := s.repo.GetOpenAttempt(tx, examID, userID, time.Now())
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			now := time.Now()
+			attempt = model.MockExamAttempt{
+				ExamID:     examID,
+				UserID:     userID,
+				StartedAt:  now,
+				Deadline:   now.Add(time.Duration(exam.TimeLimitMinutes) * time.Minute),
+				TotalMarks: totalMarks(questions),
+			}
+			err = s.repo.CreateAttempt(tx, &attempt)
+		}
+		if err != nil {
+			return err
+		}
+
+		result = dto.MockExamAttempt{
+			ID:        attempt.ID,
+			ExamID:    attempt.ExamID,
+			StartedAt: attempt.StartedAt,
+			Deadline:  attempt.Deadline,
+			Questions: questionViews(questions),
+		}
+		return nil
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, NotFoundError("Mock exam not found", err)
+	}
+	if err != nil {
+		return result, InternalError("Failed to start mock exam", err)
+	}
+	return result, nil
+}
+
+// SubmitAttempt marks every question, converts the raw mark into a grade using
+// the exam's boundaries and stores the result. Late submissions are marked
+// but flagged as over time.
+func (s *MockExamService) SubmitAttempt(userID, attemptID uint, answers map[uint]string) (dto.MockExamResult, error) {
+	var result dto.MockExamResult
+
+	var exam model.MockExam
+	var attempt model.MockExamAttempt
+	var questions []model.MockExamQuestion
+	var boundaries []model.MockExamBoundary
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		var err error
+		if attempt, err = s.repo.GetAttempt(tx, attemptID); err != nil {
+			return err
+		}
+		if attempt.UserID != userID {
+			return gorm.ErrRecordNotFound
+		}
+		if exam, err = s.repo.GetExam(tx, attempt.ExamID); err != nil {
+			return err
+		}
+		if questions, err = s.repo.GetQuestions(tx, attempt.ExamID); err != nil {
+			return err
+		}
+		boundaries, err = s.repo.GetBoundaries(tx, attempt.ExamID)
+		return err
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, NotFoundError("Attempt not found", err)
+	}
+	if err != nil {
+		return result, InternalError("Failed to submit mock exam", err)
+	}
+	if attempt.SubmittedAt != nil {
+		return result, ConflictError("Attempt already submitted", errors.New("attempt already submitted"))
+	}
+
+	// A submission that needs the AI marker spends one AI grading token, however
+	// many of its answers it marks.
+	for _, q := range questions {
+		if needsAIMarking(q, strings.TrimSpace(answers[q.ID])) {
+			if err := takeAIGradingToken(s.aiLimit, userID); err != nil {
+				return result, err
+			}
+			break
+		}
+	}
+
+	now := time.Now()
+	var marked []model.MockExamAnswer
+	raw := 0
+	for _, q := range questions {
+		answer := strings.TrimSpace(answers[q.ID])
+		awarded, feedback, err := s.mark(q, answer)
+		if err != nil {
+			return result, err
+		}
+		raw += awarded
+		marked = append(marked, model.MockExamAnswer{
+			AttemptID:    attempt.ID,
+			QuestionID:   q.ID,
+			Answer:       answer,
+			MarksAwarded: awarded,
+			Feedback:     feedback,
+		})
+	}
+
+	attempt.SubmittedAt = &now
+	attempt.RawMark = raw
+	attempt.TotalMarks = totalMarks(questions)
+	attempt.Grade = gradeFor(raw, boundaries)
+	attempt.OverTime = now.After(attempt.Deadline.Add(submissionGrace))
+
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		submitted, err := s.repo.MarkSubmitted(tx, attempt)
+		if err != nil {
+			return err
+		}
+		if !submitted {
+			return ConflictError("Attempt already submitted", errors.New("attempt already submitted"))
+		}
+		return s.repo.BulkCreateAnswers(tx, marked)
+	})
+	var serr *ServiceError
+	if errors.As(err, &serr) {
+		return result, serr
+	}
+	if err != nil {
+		return result, InternalError("Failed to save mock exam result", err)
+	}
+
+	return buildMockExamResult(exam, attempt, questions, marked), nil
+}
+
+func (s *MockExamService) GetResult(userID, attemptID uint) (dto.MockExamResult, error) {
+	var result dto.MockExamResult
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		attempt, err := s.repo.GetAttempt(tx, attemptID)
+		if err != nil {
+			return err
+		}
+		if attempt.UserID != userID {
+			return gorm.ErrRecordNotFound
+		}
+		if attempt.SubmittedAt == nil {
+			return ConflictError("Attempt has not been submitted", errors.New("attempt in progress"))
+		}
+		exam, err := s.repo.GetExam(tx, attempt.ExamID)
+		if err != nil {
+			return err
+		}
+		questions, err := s.repo.GetQuestions(tx, attempt.ExamID)
+		if err != nil {
+			return err
+		}
+		answers, err := s.repo.GetAnswers(tx, attempt.ID)
+		if err != nil {
+			return err
+		}
+
+		result = buildMockExamResult(exam, attempt, questions, answers)
+		return nil
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.As(err, &serr):
+		return result, serr
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return result, NotFoundError("Attempt not found", err)
+	default:
+		return result, InternalError("Failed to fetch mock exam result", err)
+	}
+}
+
+// mark awards full marks for a correct final answer. AI-marked questions that
+// are not obviously correct are marked against the mark scheme, which lets
+// method marks be awarded for partially correct working. If the AI marker
+// fails the submission is refused rather than scored 0, and the attempt stays
+// open for the user to submit again.
+func (s *MockExamService) mark(q model.MockExamQuestion, answer string) (int, string, error) {
+	if answer == "" {
+		return 0, "", nil
+	}
+	if gradeLocally(q.AnswerType, q.Answer, answer, q.Tolerance) {
+		return q.Marks, "", nil
+	}
+	if q.AnswerType != constants.AnswerTypeAI {
+		return 0, "", nil
+	}
+
+	awarded, feedback, err := s.aiService.MarkAnswer(q.Question, q.MarkScheme, q.Answer, answer, q.Marks)
+	if err != nil {
+		log.Printf("AI marking failed for mock exam question %d: %v", q.ID, err)
+		return 0, "", UnavailableError("Could not mark your answers right now, please submit again", err)
+	}
+	return min(max(awarded, 0), q.Marks), feedback, nil
+}
+
+// needsAIMarking reports whether mark will ask the AI marker about answer.
+func needsAIMarking(q model.MockExamQuestion, answer string) bool {
+	return answer != "" && q.AnswerType == constants.AnswerTypeAI &&
+		!gradeLocally(q.AnswerType, q.Answer, answer, q.Tolerance)
+}
+
+func gradeFor(raw int, boundaries []model.MockExamBoundary) int {
+	sort.Slice(boundaries, func(i, j int) bool {
+		return boundaries[i].Grade > boundaries[j].Grade
+	})
+	for _, b := range boundaries {
+		if raw >= b.MinMark {
+			return b.Grade
+		}
+	}
+	return 0
+}
+
+func gradeLabel(grade int) string {
+	if grade == 0 {
+		return "U"
+	}
+	return strconv.Itoa(grade)
+}
+
+func totalMarks(questions []model.MockExamQuestion) int {
+	total := 0
+	for _, q := range questions {
+		total += q.Marks
+	}
+	return total
+}
+
+func examSummary(exam model.MockExam, questions []model.MockExamQuestion) dto.MockExamSummary {
+	return dto.MockExamSummary{
+		ID:               exam.ID,
+		Title:            exam.Title,
+		Description:      exam.Description,
+		Tier:             exam.Tier,
+		Paper:            exam.Paper,
+		TimeLimitMinutes: exam.TimeLimitMinutes,
+		QuestionCount:    len(questions),
+		TotalMarks:       totalMarks(questions),
+		CreatedAt:        exam.CreatedAt,
+	}
+}
+
+func questionViews(questions []model.MockExamQuestion) []dto.MockExamQuestionView {
+	views := make([]dto.MockExamQuestionView, 0, len(questions))
+	for _, q := range questions {
+		views = append(views, dto.MockExamQuestionView{
+			ID:                  q.ID,
+			Position:            q.Position,
+			Question:            q.Question,
+			Marks:               q.Marks,
+			AssessmentObjective: q.AssessmentObjective,
+			Topic:               string(q.Topic),
+		})
+	}
+	return views
+}
+
+func buildMockExamResult(exam model.MockExam, attempt model.MockExamAttempt, questions []model.MockExamQuestion, answers []model.MockExamAnswer) dto.MockExamResult {
+	result := dto.MockExamResult{
+		AttemptID:   attempt.ID,
+		ExamID:      exam.ID,
+		Tier:        exam.Tier,
+		Paper:       exam.Paper,
+		SubmittedAt: attempt.SubmittedAt,
+		OverTime:    attempt.OverTime,
+		RawMark:     attempt.RawMark,
+		TotalMarks:  attempt.TotalMarks,
+		Grade:       attempt.Grade,
+		GradeLabel:  gradeLabel(attempt.Grade),
+	}
+	if attempt.TotalMarks > 0 {
+		result.Percentage = math.Round(float64(attempt.RawMark)*10000/float64(attempt.TotalMarks)) / 100
+	}
+
+	byQuestion := make(map[uint]model.MockExamAnswer, len(answers))
+	for _, a := range answers {
+		byQuestion[a.QuestionID] = a
+	}
+
+	awarded := make(map[string]int)
+	available := make(map[string]int)
+	for _, q := range questions {
+		a := byQuestion[q.ID]
+		awarded[q.AssessmentObjective] += a.MarksAwarded
+		available[q.AssessmentObjective] += q.Marks
+
+		result.Questions = append(result.Questions, dto.MockExamQuestionResult{
+			QuestionID:          q.ID,
+			Position:            q.Position,
+			AssessmentObjective: q.AssessmentObjective,
+			Topic:               string(q.Topic),
+			Answer:              a.Answer,
+			ExpectedAnswer:      q.Answer,
+			MarksAwarded:        a.MarksAwarded,
+			Marks:               q.Marks,
+			Feedback:            a.Feedback,
+		})
+	}
+
+	for _, ao := range assessmentObjectives {
+		if available[ao] == 0 {
+			continue
+		}
+		result.Objectives = append(result.Objectives, dto.ObjectiveBreakdown{
+			Objective:  ao,
+			Awarded:    awarded[ao],
+			Available:  available[ao],
+			Percentage: math.Round(float64(awarded[ao])*10000/float64(available[ao])) / 100,
+		})
+	}
+
+	return result
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"M-AI/api/dto"
 	"M-AI/internal/config"
 	"bufio"
 	"bytes"
@@ -34,13 +35,7 @@ type OpenAIResponse struct {
 }
 
 func (s *OpenAIService) SendPrompt(prompt string) (string, error) {
-	requestBody := map[string]interface{}{
-		"model":  "gpt-4o",
-		"stream": false,
-		"messages": []map[string]string{
-			{
-				"role": "system",
-				"content": `You are M-AI, a friendly and intelligent AI assistant designed to help students practice for their GCSE-level math exams.
+	return s.chat(`You are M-AI, a friendly and intelligent AI assistant designed to help students practice for their GCSE-level math exams.
 
 Your job is to generate a math quiz with 5 original GCSE-level math questions. Each question should test understanding of core topics like Algebra, Geometry, Probability, etc.
 
@@ -58,7 +53,8 @@ Return your response in **raw JSON** with this structure:
       "answer_b": "Option B text",
       "answer_c": "Option C text",
       "answer_d": "Option D text",
-      "topic": "One of: Number, Algebra, Ratio, Proportion and Rates of Change, Geometry and measures, Probability, Statistics"
+      "topic": "One of: Number, Algebra, Ratio, Proportion and Rates of Change, Geometry and measures, Probability, Statistics",
+      "solution": "A short worked solution, with maths wrapped in $...$"
     },
     ...
   ]
@@ -67,7 +63,110 @@ Return your response in **raw JSON** with this structure:
 You must include exactly 5 questions. Do not explain anything or include any other text.
 
 If the request is not about mathematics, respond only with:
-"I'm here to help with GCSE-level math quizzes only."`,
+"I'm here to help with GCSE-level math quizzes only."`, prompt)
+}
+
+// GradeAnswer asks the model whether a free-form answer is mathematically
+// equivalent to the expected one.
+func (s *OpenAIService) GradeAnswer(question, expected, given string) (bool, string, error) {
+	prompt := fmt.Sprintf("Question:\n%s\n\nExpected answer:\n%s\n\nStudent answer:\n%s", question, expected, given)
+
+	responseStr, err := s.chat(`You are M-AI, a GCSE maths examiner. Decide whether the student's answer is mathematically equivalent to the expected answer. Accept equivalent forms (simplified or unsimplified fractions, rearranged expressions, correct units) but reject answers that are wrong or incomplete.
+
+Respond with raw JSON only, in this structure:
+
+{
+  "correct": true,
+  "feedback": "One or two sentences for the student"
+}`, prompt)
+	if err != nil {
+		return false, "", err
+	}
+
+	var verdict struct {
+		Correct  bool   `json:"correct"`
+		Feedback string `json:"feedback"`
+	}
+	if err := json.Unmarshal([]byte(responseStr), &verdict); err != nil {
+		return false, "", err
+	}
+
+	return verdict.Correct, verdict.Feedback, nil
+}
+
+// MarkAnswer asks the model to award marks for a multi-mark exam answer
+// following the mark scheme.
+func (s *OpenAIService) MarkAnswer(question, markScheme, expected, given string, maxMarks int) (int, string, error) {
+	prompt := fmt.Sprintf(
+		"Question (%d marks):\n%s\n\nMark scheme:\n%s\n\nFinal answer:\n%s\n\nStudent answer:\n%s",
+		maxMarks, question, markScheme, expected, given,
+	)
+
+	responseStr, err := s.chat(`You are M-AI, a GCSE maths examiner marking a mock exam. Award marks strictly according to the mark scheme: method marks for correct working even when the final answer is wrong, accuracy marks only for correct results. Never award more than the marks available.
+
+Respond with raw JSON only, in this structure:
+
+{
+  "marks": 2,
+  "feedback": "One or two sentences explaining where marks were gained or lost"
+}`, prompt)
+	if err != nil {
+		return 0, "", err
+	}
+
+	var verdict struct {
+		Marks    int    `json:"marks"`
+		Feedback string `json:"feedback"`
+	}
+	if err := json.Unmarshal([]byte(responseStr), &verdict); err != nil {
+		return 0, "", err
+	}
+
+	return verdict.Marks, verdict.Feedback, nil
+}
+
+// GenerateProblem asks the model for a single practice problem at the given
+// level with an answer that can be graded automatically.
+func (s *OpenAIService) GenerateProblem(level string) (dto.AIProblem, error) {
+	var problem dto.AIProblem
+
+	responseStr, err := s.chat(`You are M-AI, a friendly and intelligent AI assistant designed to help students practice for their GCSE-level math exams.
+
+Your job is to write one original GCSE-level math problem that works as a "problem of the day". It should take a few minutes to solve and have a single final answer.
+
+### Response Format (JSON only):
+
+Return your response in **raw JSON** with this structure:
+
+{
+  "title": "Short title of the problem",
+  "question": "The full problem text, with maths wrapped in $...$",
+  "answer": "The final answer only, e.g. 12.5 or x = 3",
+  "answer_type": "numeric if the answer is a single number, otherwise exact",
+  "tolerance": 0.01,
+  "topic": "One of: Number, Algebra, Ratio, Proportion and Rates of Change, Geometry and measures, Probability, Statistics",
+  "solution": "A short worked solution, with maths wrapped in $...$"
+}
+
+Do not explain anything or include any other text.`, fmt.Sprintf("Write a problem of the day at %s difficulty.", level))
+	if err != nil {
+		return problem, err
+	}
+
+	if err := json.Unmarshal([]byte(responseStr), &problem); err != nil {
+		return problem, err
+	}
+	return problem, nil
+}
+
+func (s *OpenAIService) chat(systemPrompt, prompt string) (string, error) {
+	requestBody := map[string]interface{}{
+		"model":  "gpt-4o",
+		"stream": false,
+		"messages": []map[string]string{
+			{
+				"role":    "system",
+				"content": systemPrompt,
 			},
 			{
 				"role":    "user",
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/pkg/db"
+	"math"
+	"sort"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// improvementStep is how far a topic's mastery is raised when estimating
+	// the grade it could unlock.
+	improvementStep = 0.25
+	topicsToFocus   = 3
+)
+
+type topicMastery struct {
+	mean     float64
+	variance float64
+	attempts int
+}
+
+// GetPredictedGrade estimates the grade the user is on track for from their
+// topic mastery, the same estimate the dashboard shows, combining the topics
+// by their share of marks on the tier. The interval narrows as a topic gets
+// more attempts. When no tier is given the Higher prediction is used if it
+// reaches a 4, and Foundation otherwise. The prediction is left empty until
+// the user has answered something, and only counts as having enough data
+// once every topic on the tier has repository.MinTopicAttempts answers.
+func (s *DashboardService) GetPredictedGrade(userID uint, tier string) (dto.PredictedGrade, error) {
+	var result dto.PredictedGrade
+
+	var rows []model.TopicMastery
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		var err error
+		rows, err = s.masteryRepo.GetUserMastery(tx, userID)
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to load topic mastery", err)
+	}
+
+	mastery := topicMasteries(rows, time.Now())
+	attempts := 0
+	for _, m := range mastery {
+		attempts += m.attempts
+	}
+	if attempts == 0 {
+		return dto.PredictedGrade{Tier: tier, TopicsToFocus: []dto.GradeTopicImpact{}}, nil
+	}
+
+	if tier == "" {
+		tier = constants.TierHigher
+		if predictGrade(tier, mastery).Grade < 4 {
+			tier = constants.TierFoundation
+		}
+	}
+
+	result = predictGrade(tier, mastery)
+	result.Attempts = attempts
+	result.EnoughData = true
+	for topic := range constants.TopicShare[tier] {
+		if mastery[topic].attempts < repository.MinTopicAttempts {
+			result.EnoughData = false
+		}
+	}
+	return result, nil
+}
+
+// topicMasteries reads each topic's current mastery, with a variance that
+// treats it as a proportion observed over the topic's attempts.
+func topicMasteries(rows []model.TopicMastery, now time.Time) map[constants.TopicEnum]topicMastery {
+	attempts := make(map[constants.TopicEnum]int, len(rows))
+	for _, row := range rows {
+		attempts[row.Topic] = row.Attempts
+	}
+
+	result := make(map[constants.TopicEnum]topicMastery, len(constants.Topics))
+	for topic, p := range currentMastery(rows, now) {
+		n := attempts[topic]
+		result[topic] = topicMastery{
+			mean:     p,
+			variance: p * (1 - p) / float64(n+2),
+			attempts: n,
+		}
+	}
+	return result
+}
+
+func predictGrade(tier string, mastery map[constants.TopicEnum]topicMastery) dto.PredictedGrade {
+	shares := constants.TopicShare[tier]
+
+	var score, variance float64
+	for topic, share := range shares {
+		m := mastery[topic]
+		score += share * m.mean
+		variance += share * share * m.variance
+	}
+	margin := 1.96 * math.Sqrt(variance)
+
+	result := dto.PredictedGrade{
+		Tier:      tier,
+		Grade:     shareGrade(tier, score),
+		GradeLow:  shareGrade(tier, score-margin),
+		GradeHigh: shareGrade(tier, score+margin),
+		Score:     math.Round(score*10000) / 100,
+	}
+
+	for topic, share := range shares {
+		m := mastery[topic]
+		improved := math.Min(1, m.mean+improvementStep)
+		gain := share * (improved - m.mean)
+		result.TopicsToFocus = append(result.TopicsToFocus, dto.GradeTopicImpact{
+			Topic:           string(topic),
+			Mastery:         math.Round(m.mean*10000) / 100,
+			Share:           share * 100,
+			Attempts:        m.attempts,
+			PotentialGain:   math.Round(gain*10000) / 100,
+			GradeIfImproved: shareGrade(tier, score+gain),
+		})
+	}
+	sort.Slice(result.TopicsToFocus, func(i, j int) bool {
+		a, b := result.TopicsToFocus[i], result.TopicsToFocus[j]
+		if a.PotentialGain != b.PotentialGain {
+			return a.PotentialGain > b.PotentialGain
+		}
+		return a.Topic < b.Topic
+	})
+	if len(result.TopicsToFocus) > topicsToFocus {
+		result.TopicsToFocus = result.TopicsToFocus[:topicsToFocus]
+	}
+	return result
+}
+
+// shareGrade converts a share of the available marks to a grade using the
+// tier's default mock exam boundaries.
+func shareGrade(tier string, share float64) int {
+	best := 0
+	for grade, min := range defaultBoundaryShare[tier] {
+		if share >= min && grade > best {
+			best = grade
+		}
+	}
+	return best
+}
@@ -1,20 +1,43 @@
 package service
 
 import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
 	"M-AI/api/model"
 	"M-AI/api/repository"
+	"M-AI/api/requests"
 	"M-AI/pkg/db"
+	"M-AI/pkg/ratelimit"
 	"errors"
 	"gorm.io/gorm"
+	"log"
 )
 
 type ProblemService struct {
-	problemRepo *repository.ProblemRepository
-	db          *gorm.DB
+	problemRepo  *repository.ProblemRepository
+	userLogRepo  *repository.UserLogRepository
+	aiService    *OpenAIService
+	aiLimit      *ratelimit.Limiter
+	achievements *AchievementService
+	db           *gorm.DB
 }
 
-func NewProblemService(db *gorm.DB, problemRepo *repository.ProblemRepository) *ProblemService {
-	return &ProblemService{problemRepo: problemRepo, db: db}
+func NewProblemService(
+	db *gorm.DB,
+	problemRepo *repository.ProblemRepository,
+	userLogRepo *repository.UserLogRepository,
+	aiService *OpenAIService,
+	aiLimit *ratelimit.Limiter,
+	achievements *AchievementService,
+) *ProblemService {
+	return &ProblemService{
+		problemRepo:  problemRepo,
+		userLogRepo:  userLogRepo,
+		aiService:    aiService,
+		aiLimit:      aiLimit,
+		achievements: achievements,
+		db:           db,
+	}
 }
 
 func (s *ProblemService) CreateProblem(problem *model.Problem) error {
@@ -27,3 +50,155 @@ func (s *ProblemService) CreateProblem(problem *model.Problem) error {
 	}
 	return nil
 }
+
+func (s *ProblemService) ListProblems(userID uint, req requests.ListProblemsRequest) (dto.Page[dto.ProblemWithStats], error) {
+	var result dto.Page[dto.ProblemWithStats]
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		problems, err := s.problemRepo.ListProblemsWithUserStats(tx, userID, req.FilterRequest, req.PageRequest)
+		if err != nil {
+			return err
+		}
+		result = problems
+		return nil
+	})
+
+	if err != nil {
+		return result, listError(err, "Failed to list problems")
+	}
+	return result, nil
+}
+
+func (s *ProblemService) GetProblem(userID, problemID uint) (dto.ProblemWithStats, error) {
+	var result dto.ProblemWithStats
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		problem, err := s.problemRepo.GetProblemWithUserStats(tx, userID, problemID)
+		if err != nil {
+			return err
+		}
+		result = problem
+		return nil
+	})
+
+	if err != nil {
+		return result, InternalError("Failed to fetch problem", err)
+	}
+	if result.ID == 0 {
+		return result, NotFoundError("Problem not found", errors.New("problem not found"))
+	}
+	return result, nil
+}
+
+// SubmitAnswer grades an answer to a stored problem and records the attempt
+// as a non-quiz user log so it counts towards topic proficiency. The answer is
+// only revealed once the submission is correct, and problems the user gave up
+// on take no more submissions.
+func (s *ProblemService) SubmitAnswer(userID, problemID uint, answer string) (dto.ProblemAnswerResult, error) {
+	var result dto.ProblemAnswerResult
+
+	var problem model.Problem
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		p, err := s.problemRepo.GetPracticeProblem(tx, problemID)
+		if err != nil {
+			return err
+		}
+		problem = p
+		gaveUp, err := s.problemRepo.HasGivenUp(tx, userID, problemID)
+		if err != nil {
+			return err
+		}
+		if gaveUp {
+			return ConflictError("You gave up on this problem", errors.New("problem given up"))
+		}
+		return nil
+	})
+	var serr *ServiceError
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return result, NotFoundError("Problem not found", err)
+	case errors.As(err, &serr):
+		return result, serr
+	case err != nil:
+		return result, InternalError("Failed to fetch problem", err)
+	}
+
+	correct, feedback, err := s.Grade(userID, problem, answer)
+	if err != nil {
+		return result, err
+	}
+
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		gaveUp, err := s.problemRepo.HasGivenUp(tx, userID, problemID)
+		if err != nil {
+			return err
+		}
+		if gaveUp {
+			return ConflictError("You gave up on this problem", errors.New("problem given up"))
+		}
+		return s.userLogRepo.Create(tx, &model.UserLog{
+			CorrectAnswer: correct,
+			UserID:        userID,
+			FromQuiz:      false,
+			ProblemID:     &problem.ID,
+		})
+	})
+	if errors.As(err, &serr) {
+		return result, serr
+	}
+	if err != nil {
+		return result, InternalError("Failed to record answer", err)
+	}
+	s.achievements.Publish(dto.AchievementEvent{Type: constants.EventProblemAnswered, UserID: userID})
+
+	result = dto.ProblemAnswerResult{Correct: correct, Feedback: feedback}
+	if correct {
+		result.Answer = problem.Answer
+		result.Solution = problem.Solution
+	}
+	return result, nil
+}
+
+// GiveUp reveals a problem's answer and solution. The problem is then locked
+// for the user, so the answer cannot be copied back in as a correct one.
+func (s *ProblemService) GiveUp(userID, problemID uint) (dto.ProblemAnswerResult, error) {
+	var result dto.ProblemAnswerResult
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		problem, err := s.problemRepo.GetPracticeProblem(tx, problemID)
+		if err != nil {
+			return err
+		}
+		result = dto.ProblemAnswerResult{Answer: problem.Answer, Solution: problem.Solution}
+		return s.problemRepo.GiveUp(tx, userID, problemID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return result, NotFoundError("Problem not found", err)
+	}
+	if err != nil {
+		return result, InternalError("Failed to give up on problem", err)
+	}
+	return result, nil
+}
+
+// Grade checks a user's answer against a problem using its answer type,
+// returning AI feedback when the AI grader was consulted. The AI grader is
+// rate limited per user.
+func (s *ProblemService) Grade(userID uint, problem model.Problem, answer string) (bool, string, error) {
+	if problem.AnswerType != constants.AnswerTypeAI {
+		return gradeLocally(problem.AnswerType, problem.Answer, answer, problem.Tolerance), "", nil
+	}
+
+	if gradeLocally(problem.AnswerType, problem.Answer, answer, problem.Tolerance) {
+		return true, "", nil
+	}
+
+	if err := takeAIGradingToken(s.aiLimit, userID); err != nil {
+		return false, "", err
+	}
+	// An outage is not a wrong answer, so nothing is recorded and the user
+	// can resubmit.
+	correct, feedback, err := s.aiService.GradeAnswer(problem.Question, problem.Answer, answer)
+	if err != nil {
+		log.Printf("AI grading failed for problem %d: %v", problem.ID, err)
+		return false, "", UnavailableError("Could not grade your answer right now, please try again", err)
+	}
+	return correct, feedback, nil
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/requests"
+	"M-AI/pkg/db"
+	"fmt"
+	"math"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	bucketDay   = "day"
+	bucketWeek  = "week"
+	bucketMonth = "month"
+
+	maxProgressBuckets = 400
+)
+
+// Default number of buckets shown and averaged over for each bucket size.
+var (
+	progressSpan   = map[string]int{bucketDay: 30, bucketWeek: 12, bucketMonth: 12}
+	progressWindow = map[string]int{bucketDay: 7, bucketWeek: 4, bucketMonth: 3}
+)
+
+type progressRange struct {
+	bucket string
+	from   time.Time
+	to     time.Time
+	window int
+	starts []time.Time
+}
+
+// GetProgress returns questions answered, accuracy and quizzes completed per
+// bucket, with rolling averages over the preceding window of buckets.
+func (s *DashboardService) GetProgress(userID uint, req requests.ProgressRequest) (dto.ProgressSeries, error) {
+	var result dto.ProgressSeries
+
+	rng, err := resolveProgressRange(req)
+	if err != nil {
+		return result, err
+	}
+
+	var answers, quizzes []dto.ProgressRow
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		answers, err = s.repo.GetAnswerProgress(tx, userID, rng.bucket, rng.from, rng.to)
+		if err != nil {
+			return err
+		}
+		quizzes, err = s.repo.GetQuizProgress(tx, userID, rng.bucket, rng.from, rng.to)
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to load progress", err)
+	}
+
+	answerRows := indexProgressRows(answers)
+	quizRows := indexProgressRows(quizzes)
+
+	result = rng.series()
+	for i, start := range rng.starts {
+		a := answerRows[start.Unix()]
+		point := dto.ProgressPoint{
+			Start:             start,
+			QuestionsAnswered: a.Answered,
+			Correct:           a.Correct,
+			Accuracy:          percentage(a.Correct, a.Total),
+			QuizzesCompleted:  quizRows[start.Unix()].Answered,
+		}
+
+		var correct, total, answered, quizTotal int64
+		lo := max(0, i-rng.window+1)
+		for _, prev := range rng.starts[lo : i+1] {
+			correct += answerRows[prev.Unix()].Correct
+			total += answerRows[prev.Unix()].Total
+			answered += answerRows[prev.Unix()].Answered
+			quizTotal += quizRows[prev.Unix()].Answered
+		}
+		n := float64(i + 1 - lo)
+		point.RollingAccuracy = percentage(correct, total)
+		point.RollingQuestionsAnswered = round2(float64(answered) / n)
+		point.RollingQuizzesCompleted = round2(float64(quizTotal) / n)
+
+		result.Points = append(result.Points, point)
+	}
+	return result, nil
+}
+
+// GetTopicProgress returns accuracy per topic per bucket, optionally for a
+// single topic.
+func (s *DashboardService) GetTopicProgress(userID uint, req requests.ProgressRequest) (dto.TopicProgress, error) {
+	var result dto.TopicProgress
+
+	if req.Topic != "" && !constants.IsValidTopic(req.Topic) {
+		return result, ValidationError(fmt.Sprintf("Unknown topic %q", req.Topic))
+	}
+	rng, err := resolveProgressRange(req)
+	if err != nil {
+		return result, err
+	}
+
+	var rows []dto.ProgressRow
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		rows, err = s.repo.GetTopicProgress(tx, userID, rng.bucket, rng.from, rng.to)
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to load topic progress", err)
+	}
+
+	byTopic := make(map[string][]dto.ProgressRow)
+	for _, row := range rows {
+		byTopic[row.Topic] = append(byTopic[row.Topic], row)
+	}
+
+	series := rng.series()
+	result = dto.TopicProgress{Bucket: series.Bucket, From: series.From, To: series.To, Window: series.Window}
+	for _, topic := range constants.Topics {
+		if req.Topic != "" && string(topic) != req.Topic {
+			continue
+		}
+
+		topicRows := indexProgressRows(byTopic[string(topic)])
+		entry := dto.TopicProgressSeries{Topic: string(topic)}
+		for i, start := range rng.starts {
+			r := topicRows[start.Unix()]
+			var correct, total int64
+			lo := max(0, i-rng.window+1)
+			for _, prev := range rng.starts[lo : i+1] {
+				correct += topicRows[prev.Unix()].Correct
+				total += topicRows[prev.Unix()].Total
+			}
+			entry.Points = append(entry.Points, dto.TopicProgressPoint{
+				Start:           start,
+				Correct:         r.Correct,
+				Total:           r.Total,
+				Accuracy:        percentage(r.Correct, r.Total),
+				RollingAccuracy: percentage(correct, total),
+			})
+		}
+		result.Topics = append(result.Topics, entry)
+	}
+	return result, nil
+}
+
+func resolveProgressRange(req requests.ProgressRequest) (progressRange, error) {
+	rng := progressRange{bucket: req.Bucket, window: req.Window}
+	if rng.bucket == "" {
+		rng.bucket = bucketDay
+	}
+	if rng.window == 0 {
+		rng.window = progressWindow[rng.bucket]
+	}
+
+	last := time.Now().UTC()
+	if req.To != "" {
+		last, _ = time.Parse(dateLayout, req.To)
+	}
+	last = bucketStart(last, rng.bucket)
+
+	first := stepBucket(last, rng.bucket, 1-progressSpan[rng.bucket])
+	if req.From != "" {
+		from, _ := time.Parse(dateLayout, req.From)
+		first = bucketStart(from, rng.bucket)
+	}
+	if first.After(last) {
+		return rng, ValidationError("from must not be after to")
+	}
+
+	for t := first; !t.After(last); t = stepBucket(t, rng.bucket, 1) {
+		if len(rng.starts) == maxProgressBuckets {
+			return rng, ValidationError(fmt.Sprintf("Date range spans more than %d buckets", maxProgressBuckets))
+		}
+		rng.starts = append(rng.starts, t)
+	}
+	rng.from = first
+	rng.to = stepBucket(last, rng.bucket, 1)
+	return rng, nil
+}
+
+func (r progressRange) series() dto.ProgressSeries {
+	return dto.ProgressSeries{
+		Bucket: r.bucket,
+		From:   r.from.Format(dateLayout),
+		To:     r.to.AddDate(0, 0, -1).Format(dateLayout),
+		Window: r.window,
+	}
+}
+
+// bucketStart truncates t to the start of its bucket, matching Postgres
+// date_trunc: weeks start on Monday.
+func bucketStart(t time.Time, bucket string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch bucket {
+	case bucketWeek:
+		offset := (int(day.Weekday()) + 6) % 7
+		return day.AddDate(0, 0, -offset)
+	case bucketMonth:
+		return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+	default:
+		return day
+	}
+}
+
+func stepBucket(t time.Time, bucket string, n int) time.Time {
+	switch bucket {
+	case bucketWeek:
+		return t.AddDate(0, 0, 7*n)
+	case bucketMonth:
+		return t.AddDate(0, n, 0)
+	default:
+		return t.AddDate(0, 0, n)
+	}
+}
+
+func indexProgressRows(rows []dto.ProgressRow) map[int64]dto.ProgressRow {
+	index := make(map[int64]dto.ProgressRow, len(rows))
+	for _, row := range rows {
+		b := row.Bucket
+		key := time.Date(b.Year(), b.Month(), b.Day(), 0, 0, 0, 0, time.UTC).Unix()
+		index[key] = row
+	}
+	return index
+}
+
+// percentage returns nil rather than zero for an empty bucket so charts can
+// tell "no activity" apart from "all wrong".
+func percentage(correct, total int64) *float64 {
+	if total == 0 {
+		return nil
+	}
+	p := round2(float64(correct) * 100 / float64(total))
+	return &p
+}
+
+func round2(v float64) float64 {
+	return math.Round(v*100) / 100
+}
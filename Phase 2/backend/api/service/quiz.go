@@ -8,11 +8,13 @@ import (
 	"M-AI/api/requests"
 	"M-AI/pkg/db"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"gorm.io/gorm"
 	"math"
 	"sort"
 	"strings"
+	"time"
 )
 
 type QuizService struct {
@@ -20,7 +22,10 @@ type QuizService struct {
 	quizLogRepo  *repository.QuizLogRepository
 	userLogRepo  *repository.UserLogRepository
 	questionRepo *repository.QuestionRepository
+	masteryRepo  *repository.MasteryRepository
+	assignments  *repository.AssignmentRepository
 	aiService    *OpenAIService
+	achievements *AchievementService
 	db           *gorm.DB
 }
 
@@ -30,24 +35,31 @@ func NewQuizService(
 	quizLogRepo *repository.QuizLogRepository,
 	userLogRepo *repository.UserLogRepository,
 	questionRepo *repository.QuestionRepository,
+	masteryRepo *repository.MasteryRepository,
+	assignments *repository.AssignmentRepository,
 	aiService *OpenAIService,
+	achievements *AchievementService,
 ) *QuizService {
 	return &QuizService{
 		quizRepo:     quizRepo,
 		quizLogRepo:  quizLogRepo,
 		userLogRepo:  userLogRepo,
 		questionRepo: questionRepo,
+		masteryRepo:  masteryRepo,
+		assignments:  assignments,
 		aiService:    aiService,
+		achievements: achievements,
 		db:           db,
 	}
 }
 
-func (s *QuizService) CreateQuizWithQuestions(req requests.CreateQuizRequest) error {
+func (s *QuizService) CreateQuizWithQuestions(userID uint, req requests.CreateQuizRequest) error {
 	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
 		quiz := model.Quiz{
 			Title:       req.Title,
 			Description: req.Description,
 			Level:       req.Level,
+			CreatedBy:   &userID,
 		}
 
 		if err := s.quizRepo.CreateQuiz(tx, &quiz); err != nil {
@@ -65,6 +77,7 @@ func (s *QuizService) CreateQuizWithQuestions(req requests.CreateQuizRequest) er
 				AnswerC:  q.AnswerC,
 				AnswerD:  q.AnswerD,
 				Topic:    q.Topic,
+				Solution: q.Solution,
 			})
 		}
 
@@ -76,27 +89,61 @@ func (s *QuizService) CreateQuizWithQuestions(req requests.CreateQuizRequest) er
 	})
 }
 
-func (s *QuizService) ListQuizzesWithUserStats(userID uint, search string, filter string) ([]dto.QuizWithStats, error) {
-	var result []dto.QuizWithStats
+func (s *QuizService) ListQuizzesWithUserStats(userID uint, req requests.ListQuizzesRequest) (dto.Page[dto.QuizWithStats], error) {
+	var result dto.Page[dto.QuizWithStats]
+
+	if req.Filter == "completed" && req.Status == "" {
+		req.Status = "completed"
+	}
 
 	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
-		stats, err := s.quizRepo.ListQuizzesWithUserStats(tx, userID, search, filter)
+		page, err := s.quizRepo.ListQuizzesWithUserStats(tx, userID, strings.TrimSpace(req.Search), req.Assigned, req.FilterRequest, req.PageRequest)
 		if err != nil {
 			return err
 		}
-		result = stats
+		result = page
 		return nil
 	})
 
 	if err != nil {
-		return nil, InternalError("Failed to list quizzes", err)
+		return result, listError(err, "Failed to list quizzes")
 	}
 
 	return result, nil
 }
 
+// GetQuiz returns a quiz with its questions and the user's latest score.
+func (s *QuizService) GetQuiz(userID, quizID uint) (dto.QuizWithStats, error) {
+	var result dto.QuizWithStats
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		quiz, err := s.quizRepo.GetQuizByIDWithStats(tx, userID, quizID)
+		result = quiz
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to fetch quiz", err)
+	}
+	if result.ID == 0 {
+		return result, NotFoundError("Quiz not found", errors.New("quiz not found"))
+	}
+	return result, nil
+}
+
+// CompleteQuiz grades a submission. It is refused if the quiz is assigned to
+// the user and they have used every attempt the assignment allows.
 func (s *QuizService) CompleteQuiz(submission dto.QuizSubmission) error {
-	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+	var score int
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if err := s.assignments.LockAttempts(tx, submission.UserID, submission.QuizID); err != nil {
+			return err
+		}
+		_, err := s.assignments.GetExhaustedAssignment(tx, submission.UserID, submission.QuizID, time.Now())
+		if err == nil {
+			return ConflictError("You have used all your attempts on this assignment", errors.New("attempt limit reached"))
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
 		questions, err := s.questionRepo.GetByQuizID(tx, submission.QuizID)
 		if err != nil {
 			return err
@@ -125,7 +172,6 @@ func (s *QuizService) CompleteQuiz(submission dto.QuizSubmission) error {
 		}
 
 		total := len(questions)
-		score := 0
 		if total > 0 {
 			score = int(float64(correctCount) / float64(total) * 100)
 		}
@@ -148,35 +194,40 @@ func (s *QuizService) CompleteQuiz(submission dto.QuizSubmission) error {
 
 		return nil
 	})
+	if err != nil {
+		return err
+	}
+
+	s.achievements.Publish(dto.AchievementEvent{
+		Type:   constants.EventQuizCompleted,
+		UserID: submission.UserID,
+		Score:  score,
+	})
+	return nil
 }
 
 func (s *QuizService) GenerateQuizFromPrompt(req dto.AIQuizRequest) (dto.QuizWithStats, error) {
 	var q dto.QuizWithStats
 
 	if req.PromptType == "struggle-areas" {
-		proficiency, err := s.quizRepo.GetTopicProficiency(s.db, req.UserID)
+		var rows []model.TopicMastery
+		err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+			var err error
+			rows, err = s.masteryRepo.GetUserMastery(tx, req.UserID)
+			return err
+		})
 		if err != nil {
-			return q, fmt.Errorf("failed to get topic proficiency: %w", err)
-		}
-
-		if len(proficiency) == 0 {
-			return q, fmt.Errorf("no topic data found for user")
+			return q, fmt.Errorf("failed to get topic mastery: %w", err)
 		}
 
-		sort.Slice(proficiency, func(i, j int) bool {
-			return proficiency[i].Score < proficiency[j].Score
-		})
-
-		// Step 1: Calculate total weakness weight
+		// Step 1: Weight each topic by how far it is from mastery. Topics the
+		// user has never attempted sit at the prior and are weighted heavily.
 		var totalWeight float64
 		weights := make(map[string]float64)
 
-		for _, p := range proficiency {
-			weight := 1 - p.Score // Lower score → higher weight
-			if weight < 0 {
-				weight = 0
-			}
-			weights[p.Topic] = weight
+		for topic, p := range currentMastery(rows, time.Now()) {
+			weight := 1 - p
+			weights[string(topic)] = weight
 			totalWeight += weight
 		}
 
@@ -247,6 +298,7 @@ func (s *QuizService) GenerateQuizFromPrompt(req dto.AIQuizRequest) (dto.QuizWit
 			Title:       req.Title,
 			Description: req.Description,
 			Level:       req.Level,
+			CreatedBy:   &req.UserID,
 		}
 
 		if err := tx.Create(&quiz).Error; err != nil {
@@ -264,6 +316,7 @@ func (s *QuizService) GenerateQuizFromPrompt(req dto.AIQuizRequest) (dto.QuizWit
 				AnswerC:  q.AnswerC,
 				AnswerD:  q.AnswerD,
 				Topic:    constants.TopicEnum(q.Topic),
+				Solution: q.Solution,
 			})
 		}
 
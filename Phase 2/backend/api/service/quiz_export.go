@@ -0,0 +1,113 @@
+package service
+
+import (
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"M-AI/pkg/db"
+	"M-AI/pkg/worksheet"
+	"bytes"
+	"errors"
+	"math/rand"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+var optionLabels = []string{"A", "B", "C", "D"}
+
+func (s *QuizService) ExportQuizPDF(userID, quizID uint, req requests.ExportQuizRequest) ([]byte, error) {
+	var doc worksheet.Document
+
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		quiz, err := s.quizRepo.GetQuizByIDWithStats(tx, userID, quizID)
+		if err != nil {
+			return err
+		}
+		if quiz.ID == 0 {
+			return NotFoundError("Quiz not found", errors.New("quiz not found"))
+		}
+
+		questions := quiz.Questions
+		if req.Seed != 0 {
+			questions = shuffleQuestions(questions, req.Seed)
+		}
+
+		doc = worksheet.Document{
+			Title:       quiz.Title,
+			Description: quiz.Description,
+			Level:       quiz.Level,
+			CreatedAt:   quiz.CreatedAt,
+		}
+		if req.Seed != 0 {
+			doc.Version = strconv.FormatInt(req.Seed, 10)
+		}
+
+		for _, q := range questions {
+			doc.Items = append(doc.Items, worksheet.Item{
+				Prompt: q.Question,
+				Options: []worksheet.Option{
+					{Label: "A", Text: q.AnswerA},
+					{Label: "B", Text: q.AnswerB},
+					{Label: "C", Text: q.AnswerC},
+					{Label: "D", Text: q.AnswerD},
+				},
+			})
+
+			switch req.Answers {
+			case "key":
+				doc.Key = append(doc.Key, worksheet.KeyEntry{Answer: strings.ToUpper(q.Answer)})
+			case "solutions":
+				doc.Key = append(doc.Key, worksheet.KeyEntry{Answer: strings.ToUpper(q.Answer), Solution: q.Solution})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		var serr *ServiceError
+		if errors.As(err, &serr) {
+			return nil, serr
+		}
+		return nil, InternalError("Failed to load quiz", err)
+	}
+
+	var buf bytes.Buffer
+	if err := worksheet.Render(doc, &buf); err != nil {
+		return nil, InternalError("Failed to render worksheet", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// shuffleQuestions returns a copy of questions with both the question order
+// and each question's options permuted by seed, relabelling the answer to
+// match. The same seed always produces the same worksheet version.
+func shuffleQuestions(questions []model.Question, seed int64) []model.Question {
+	rng := rand.New(rand.NewSource(seed))
+
+	out := make([]model.Question, len(questions))
+	copy(out, questions)
+	for i := len(out) - 1; i > 0; i-- {
+		j := rng.Intn(i + 1)
+		out[i], out[j] = out[j], out[i]
+	}
+
+	for i := range out {
+		q := &out[i]
+		options := []string{q.AnswerA, q.AnswerB, q.AnswerC, q.AnswerD}
+		order := []int{0, 1, 2, 3}
+		for k := len(order) - 1; k > 0; k-- {
+			j := rng.Intn(k + 1)
+			order[k], order[j] = order[j], order[k]
+		}
+
+		answer := strings.ToUpper(strings.TrimSpace(q.Answer))
+		q.AnswerA, q.AnswerB, q.AnswerC, q.AnswerD = options[order[0]], options[order[1]], options[order[2]], options[order[3]]
+		for pos, from := range order {
+			if optionLabels[from] == answer {
+				q.Answer = optionLabels[pos]
+			}
+		}
+	}
+
+	return out
+}
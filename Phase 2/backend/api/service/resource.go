@@ -1,36 +1,252 @@
 package service
 
 import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
 	"M-AI/api/model"
 	"M-AI/api/repository"
+	"M-AI/api/requests"
 	"M-AI/pkg/db"
+	"M-AI/pkg/linkcheck"
 	"errors"
+	"fmt"
 	"gorm.io/gorm"
+	"sort"
+	"strings"
+	"sync"
+	"time"
 )
 
+const defaultRecommendations = 10
+
+var levelRank = map[string]int{
+	constants.LevelBeginner:     0,
+	constants.LevelIntermediate: 1,
+	constants.LevelAdvanced:     2,
+}
+
 type ResourceService struct {
 	resourceRepo *repository.ResourceRepository
+	masteryRepo  *repository.MasteryRepository
+	checker      *linkcheck.Checker
 	db           *gorm.DB
+
+	// linkCheck is held while a link check runs, so scheduled and manual
+	// checks never probe the same batch twice.
+	linkCheck sync.Mutex
 }
 
-func NewResourceService(db *gorm.DB, resourceRepo *repository.ResourceRepository) *ResourceService {
-	return &ResourceService{resourceRepo: resourceRepo, db: db}
+func NewResourceService(db *gorm.DB, resourceRepo *repository.ResourceRepository, masteryRepo *repository.MasteryRepository, checker *linkcheck.Checker) *ResourceService {
+	return &ResourceService{resourceRepo: resourceRepo, masteryRepo: masteryRepo, checker: checker, db: db}
 }
 
-func (s *ResourceService) GetResources(search, level string) ([]model.Resource, error) {
-	var result []model.Resource
+func (s *ResourceService) GetResources(userID uint, req requests.ListResourcesRequest) (dto.Page[model.Resource], error) {
+	var result dto.Page[model.Resource]
 
 	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
-		resources, err := s.resourceRepo.GetResources(tx, search, level)
+		page, err := s.resourceRepo.GetResources(tx, userID, strings.TrimSpace(req.Search), req.FilterRequest, req.PageRequest)
+		if err != nil {
+			return err
+		}
+		activity, err := s.resourceRepo.GetActivity(tx, userID)
 		if err != nil {
 			return err
 		}
-		result = resources
+		page.Items = withState(page.Items, activity)
+		result = page
 		return nil
 	})
 
 	if err != nil {
-		return nil, InternalError("Failed to fetch resources", errors.New("resource fetch error"))
+		return result, listError(err, "Failed to fetch resources")
+	}
+	return result, nil
+}
+
+// GetSavedResources returns the user's bookmarks, most recently saved first.
+func (s *ResourceService) GetSavedResources(userID uint, req requests.SavedResourcesRequest) (dto.Page[model.Resource], error) {
+	var result dto.Page[model.Resource]
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		page, err := s.resourceRepo.GetSavedResources(tx, userID, req.FilterRequest, req.PageRequest)
+		if err != nil {
+			return err
+		}
+		activity, err := s.resourceRepo.GetActivity(tx, userID)
+		if err != nil {
+			return err
+		}
+		page.Items = withState(page.Items, activity)
+		result = page
+		return nil
+	})
+	if err != nil {
+		return result, listError(err, "Failed to fetch saved resources")
 	}
 	return result, nil
 }
+
+func (s *ResourceService) RecordOpen(userID, resourceID uint) error {
+	return s.updateActivity(resourceID, "Failed to record resource open", func(tx *gorm.DB) error {
+		return s.resourceRepo.RecordOpen(tx, userID, resourceID, time.Now())
+	})
+}
+
+// SetSaved adds or removes a bookmark on a resource for the user.
+func (s *ResourceService) SetSaved(userID, resourceID uint, saved bool) error {
+	return s.updateActivity(resourceID, "Failed to update saved resource", func(tx *gorm.DB) error {
+		return s.resourceRepo.SetSaved(tx, userID, resourceID, stateTime(saved))
+	})
+}
+
+// SetCompleted marks a resource as completed by the user, or undoes it.
+func (s *ResourceService) SetCompleted(userID, resourceID uint, completed bool) error {
+	return s.updateActivity(resourceID, "Failed to update resource completion", func(tx *gorm.DB) error {
+		return s.resourceRepo.SetCompleted(tx, userID, resourceID, stateTime(completed))
+	})
+}
+
+func (s *ResourceService) updateActivity(resourceID uint, fallback string, update func(tx *gorm.DB) error) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.resourceRepo.GetResourceByID(tx, resourceID); err != nil {
+			return err
+		}
+		return update(tx)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFoundError("Resource not found", err)
+	}
+	if err != nil {
+		return InternalError(fallback, err)
+	}
+	return nil
+}
+
+func stateTime(set bool) *time.Time {
+	if !set {
+		return nil
+	}
+	now := time.Now()
+	return &now
+}
+
+func withState(resources []model.Resource, activity map[uint]model.ResourceActivity) []model.Resource {
+	for i := range resources {
+		resources[i].State = activity[resources[i].ID].State()
+	}
+	return resources
+}
+
+// Recommend ranks resources for the user. Resources covering their weakest
+// topics by mastery score highest, those at their level are preferred, and a
+// resource opened n times before has its score divided by n+1 so fresh
+// material comes first. Topics never practised count at the prior mastery;
+// topics mastery is not tracked for are ignored. Resources the user has
+// marked completed are left out. When no level is given it is inferred from
+// the user's mastery.
+func (s *ResourceService) Recommend(userID uint, req requests.RecommendedResourcesRequest) (dto.Page[dto.RecommendedResource], error) {
+	var page dto.Page[dto.RecommendedResource]
+	if req.Limit == 0 {
+		req.Limit = defaultRecommendations
+	}
+	offset, limit, err := repository.OffsetPage(req.PageRequest)
+	if err != nil {
+		return page, listError(err, "Failed to load recommendations")
+	}
+	level := req.Level
+
+	var resources []model.Resource
+	var masteryRows []model.TopicMastery
+	var activity map[uint]model.ResourceActivity
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		var err error
+		if resources, err = s.resourceRepo.GetVisibleResources(tx); err != nil {
+			return err
+		}
+		if masteryRows, err = s.masteryRepo.GetUserMastery(tx, userID); err != nil {
+			return err
+		}
+		activity, err = s.resourceRepo.GetActivity(tx, userID)
+		return err
+	})
+	if err != nil {
+		return page, InternalError("Failed to load recommendations", err)
+	}
+
+	mastery := currentMastery(masteryRows, time.Now())
+	if level == "" {
+		level = inferLevel(masteryRows, mastery)
+	}
+
+	result := make([]dto.RecommendedResource, 0, len(resources))
+	for _, res := range resources {
+		if activity[res.ID].CompletedAt != nil {
+			continue
+		}
+		res.State = activity[res.ID].State()
+		rec := dto.RecommendedResource{Resource: res}
+
+		weakest, weakness := "", 0.0
+		for _, t := range res.Topic {
+			p, ok := mastery[constants.TopicEnum(t)]
+			if !ok {
+				continue
+			}
+			if w := 1 - p; w > weakness {
+				weakest, weakness = t, w
+			}
+		}
+		if weakest != "" {
+			rec.Reasons = append(rec.Reasons, fmt.Sprintf("Covers %s (%.0f%% mastered)", weakest, (1-weakness)*100))
+		}
+
+		fit := 0.0
+		if rank, ok := levelRank[strings.ToLower(res.Level)]; ok {
+			distance := rank - levelRank[level]
+			if distance < 0 {
+				distance = -distance
+			}
+			fit = 1 - float64(distance)/2
+			if distance == 0 {
+				rec.Reasons = append(rec.Reasons, "Matches your "+level+" level")
+			}
+		}
+
+		rec.Score = 0.6*weakness + 0.4*fit
+		if count := activity[res.ID].OpenCount; count > 0 {
+			rec.Opened = true
+			rec.Score /= float64(1 + count)
+			rec.Reasons = append(rec.Reasons, "Already opened")
+		}
+		rec.Score = round2(rec.Score * 100)
+		result = append(result, rec)
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Score != result[j].Score {
+			return result[i].Score > result[j].Score
+		}
+		return result[i].ID < result[j].ID
+	})
+	page.Items, page.NextCursor = repository.NextOffsetCursor(result[min(offset, len(result)):], offset, limit)
+	return page, nil
+}
+
+// inferLevel maps the user's average mastery over the topics they have
+// practised to a level, starting everyone new at beginner.
+func inferLevel(rows []model.TopicMastery, mastery map[constants.TopicEnum]float64) string {
+	if len(rows) == 0 {
+		return constants.LevelBeginner
+	}
+	var sum float64
+	for _, row := range rows {
+		sum += mastery[row.Topic]
+	}
+	switch avg := sum / float64(len(rows)); {
+	case avg < 0.45:
+		return constants.LevelBeginner
+	case avg < 0.75:
+		return constants.LevelIntermediate
+	default:
+		return constants.LevelAdvanced
+	}
+}
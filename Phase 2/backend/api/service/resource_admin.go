@@ -0,0 +1,386 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"M-AI/pkg/db"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+	"gorm.io/gorm"
+)
+
+const (
+	// linkFailureLimit is how many checks in a row a link must fail before
+	// the resource is hidden from students.
+	linkFailureLimit = 2
+	linkCheckBatch   = 500
+	linkCheckWorkers = 4
+	maxImportRows    = 1000
+)
+
+// csvColumns is the header expected on CSV imports. Topics are separated by
+// semicolons.
+var csvColumns = []string{"title", "link", "level", "topics", "description", "link_description"}
+
+func (s *ResourceService) ListAllResources(userID uint, req requests.AdminListResourcesRequest) (dto.Page[model.Resource], error) {
+	var result dto.Page[model.Resource]
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		page, err := s.resourceRepo.GetAllResources(tx, userID, req.Broken, req.FilterRequest, req.PageRequest)
+		result = page
+		return err
+	})
+	if err != nil {
+		return result, listError(err, "Failed to fetch resources")
+	}
+	return result, nil
+}
+
+func (s *ResourceService) CreateResource(userID uint, req requests.ResourceRequest) (model.Resource, error) {
+	resource, err := resourceFromRequest(req)
+	if err != nil {
+		return resource, err
+	}
+	resource.CreatedBy = &userID
+
+	created := []model.Resource{resource}
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.resourceRepo.GetResourceByLink(tx, resource.Link); err == nil {
+			return ConflictError("A resource with this link already exists", errors.New("duplicate link"))
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		return s.resourceRepo.CreateResources(tx, created)
+	})
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return created[0], nil
+	case errors.As(err, &serr):
+		return resource, serr
+	default:
+		return resource, InternalError("Failed to create resource", err)
+	}
+}
+
+// UpdateResource replaces a resource's details. Changing the link resets its
+// health so the next check starts afresh.
+func (s *ResourceService) UpdateResource(resourceID uint, req requests.ResourceRequest) (model.Resource, error) {
+	update, err := resourceFromRequest(req)
+	if err != nil {
+		return update, err
+	}
+
+	var result model.Resource
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		resource, err := s.resourceRepo.GetResourceByID(tx, resourceID)
+		if err != nil {
+			return err
+		}
+
+		if update.Link != resource.Link {
+			other, err := s.resourceRepo.GetResourceByLink(tx, update.Link)
+			if err == nil && other.ID != resource.ID {
+				return ConflictError("A resource with this link already exists", errors.New("duplicate link"))
+			} else if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+			resource.LinkStatus = 0
+			resource.LinkError = ""
+			resource.LinkFailures = 0
+			resource.LinkBroken = false
+			resource.LinkCheckedAt = nil
+		}
+
+		resource.Title = update.Title
+		resource.Link = update.Link
+		resource.Level = update.Level
+		resource.Topic = update.Topic
+		resource.Description = update.Description
+		resource.LinkDescription = update.LinkDescription
+		result = resource
+		return s.resourceRepo.UpdateResource(tx, &resource)
+	})
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.As(err, &serr):
+		return result, serr
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		return result, NotFoundError("Resource not found", err)
+	default:
+		return result, InternalError("Failed to update resource", err)
+	}
+}
+
+func (s *ResourceService) DeleteResource(resourceID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		if _, err := s.resourceRepo.GetResourceByID(tx, resourceID); err != nil {
+			return err
+		}
+		return s.resourceRepo.DeleteResource(tx, resourceID)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return NotFoundError("Resource not found", err)
+	}
+	if err != nil {
+		return InternalError("Failed to delete resource", err)
+	}
+	return nil
+}
+
+// ImportResources creates resources from a CSV or YAML document. Every row is
+// validated before anything is written, so a bad row rejects the whole
+// import. Rows whose link already exists, in the database or earlier in the
+// document, are skipped.
+func (s *ResourceService) ImportResources(userID uint, format string, body []byte) (dto.ResourceImportResult, error) {
+	result := dto.ResourceImportResult{Skipped: []string{}}
+
+	var rows []requests.ResourceRequest
+	var err error
+	switch format {
+	case "csv":
+		rows, err = parseResourceCSV(body)
+	case "yaml":
+		err = yaml.Unmarshal(body, &rows)
+	default:
+		return result, ValidationError(fmt.Sprintf("Unsupported import format %q", format))
+	}
+	if err != nil {
+		return result, BadRequestError("Could not parse import: "+err.Error(), err)
+	}
+	if len(rows) == 0 {
+		return result, ValidationError("Import contains no resources")
+	}
+	if len(rows) > maxImportRows {
+		return result, ValidationError(fmt.Sprintf("Import is limited to %d resources", maxImportRows))
+	}
+
+	resources := make([]model.Resource, 0, len(rows))
+	links := make([]string, 0, len(rows))
+	for i, row := range rows {
+		resource, err := resourceFromRequest(row)
+		if err != nil {
+			return result, ValidationError(fmt.Sprintf("Row %d: %s", i+1, err.Error()))
+		}
+		resource.CreatedBy = &userID
+		resources = append(resources, resource)
+		links = append(links, resource.Link)
+	}
+
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		existing, err := s.resourceRepo.GetExistingLinks(tx, links)
+		if err != nil {
+			return err
+		}
+
+		fresh := make([]model.Resource, 0, len(resources))
+		for _, resource := range resources {
+			if existing[resource.Link] {
+				result.Skipped = append(result.Skipped, resource.Link)
+				continue
+			}
+			existing[resource.Link] = true
+			fresh = append(fresh, resource)
+		}
+		if len(fresh) == 0 {
+			return nil
+		}
+		result.Created = len(fresh)
+		return s.resourceRepo.CreateResources(tx, fresh)
+	})
+	if err != nil {
+		return dto.ResourceImportResult{}, InternalError("Failed to import resources", err)
+	}
+	return result, nil
+}
+
+// CheckLinks probes the least recently checked resources and records the
+// outcome, hiding any whose link has now failed linkFailureLimit times in a
+// row. It runs on a schedule and is skipped if a check is already running.
+func (s *ResourceService) CheckLinks() error {
+	if !s.linkCheck.TryLock() {
+		return nil
+	}
+	defer s.linkCheck.Unlock()
+	_, err := s.checkLinks(context.Background())
+	return err
+}
+
+// RunLinkCheck starts a link check for an admin and returns straight away.
+// Probing a full batch can take minutes, far longer than a request should;
+// the results are recorded on each resource as they come in.
+func (s *ResourceService) RunLinkCheck() error {
+	if !s.linkCheck.TryLock() {
+		return ConflictError("A link check is already running", errors.New("link check running"))
+	}
+	go func() {
+		defer s.linkCheck.Unlock()
+		summary, err := s.checkLinks(context.Background())
+		if err != nil {
+			log.Printf("link check: %v", err)
+			return
+		}
+		log.Printf("link check: checked %d resources, %d broken", summary.Checked, summary.Broken)
+	}()
+	return nil
+}
+
+func (s *ResourceService) checkLinks(ctx context.Context) (dto.LinkCheckSummary, error) {
+	var summary dto.LinkCheckSummary
+
+	var resources []model.Resource
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		var err error
+		resources, err = s.resourceRepo.GetResourcesToCheck(tx, linkCheckBatch)
+		return err
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	var mu sync.Mutex
+	var firstErr error
+	jobs := make(chan model.Resource)
+	var wg sync.WaitGroup
+	for range linkCheckWorkers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for resource := range jobs {
+				res := s.checker.Check(ctx, resource.Link)
+				// A probe cut short by cancellation says nothing about the
+				// link, so it must not count as a failure.
+				if ctx.Err() != nil {
+					continue
+				}
+				var broken bool
+				err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+					var err error
+					broken, err = s.resourceRepo.RecordLinkCheck(tx, resource.ID, res.Status, res.Err, res.OK, res.CheckedAt, linkFailureLimit)
+					return err
+				})
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+				}
+				summary.Checked++
+				if broken {
+					summary.Broken++
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+	for _, resource := range resources {
+		if ctx.Err() != nil {
+			break
+		}
+		jobs <- resource
+	}
+	close(jobs)
+	wg.Wait()
+
+	return summary, firstErr
+}
+
+// resourceFromRequest validates a request and normalises its level and
+// topics. It is used for single resources and for every row of an import.
+func resourceFromRequest(req requests.ResourceRequest) (model.Resource, error) {
+	resource := model.Resource{
+		Title:           strings.TrimSpace(req.Title),
+		Link:            strings.TrimSpace(req.Link),
+		Level:           strings.ToLower(strings.TrimSpace(req.Level)),
+		Description:     strings.TrimSpace(req.Description),
+		LinkDescription: strings.TrimSpace(req.LinkDescription),
+	}
+
+	if resource.Title == "" {
+		return resource, ValidationError("Title is required")
+	}
+	if u, err := url.Parse(resource.Link); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return resource, ValidationError(fmt.Sprintf("Invalid link %q", resource.Link))
+	}
+	if !constants.IsValidLevel(resource.Level) {
+		return resource, ValidationError(fmt.Sprintf("Unknown level %q", req.Level))
+	}
+
+	seen := make(map[string]bool)
+	for _, topic := range req.Topics {
+		topic = strings.TrimSpace(topic)
+		if topic == "" || seen[topic] {
+			continue
+		}
+		if !constants.IsValidTopic(topic) {
+			return resource, ValidationError(fmt.Sprintf("Unknown topic %q", topic))
+		}
+		seen[topic] = true
+		resource.Topic = append(resource.Topic, topic)
+	}
+	if len(resource.Topic) == 0 {
+		return resource, ValidationError("At least one topic is required")
+	}
+	return resource, nil
+}
+
+func parseResourceCSV(body []byte) ([]requests.ResourceRequest, error) {
+	reader := csv.NewReader(bytes.NewReader(body))
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	column := make(map[string]int, len(header))
+	for i, name := range header {
+		column[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvColumns[:4] {
+		if _, ok := column[name]; !ok {
+			return nil, fmt.Errorf("missing %q column", name)
+		}
+	}
+
+	field := func(record []string, name string) string {
+		if i, ok := column[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	var rows []requests.ResourceRequest
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		rows = append(rows, requests.ResourceRequest{
+			Title:           field(record, "title"),
+			Link:            field(record, "link"),
+			Level:           field(record, "level"),
+			Topics:          strings.Split(field(record, "topics"), ";"),
+			Description:     field(record, "description"),
+			LinkDescription: field(record, "link_description"),
+		})
+	}
+	return rows, nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"M-AI/api/dto"
+	"M-AI/api/repository"
+	"M-AI/api/requests"
+	"M-AI/pkg/db"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type SearchService struct {
+	repo *repository.SearchRepository
+	db   *gorm.DB
+}
+
+func NewSearchService(db *gorm.DB, repo *repository.SearchRepository) *SearchService {
+	return &SearchService{repo: repo, db: db}
+}
+
+// Search returns a page of results across every searchable type, with the
+// cursor for the next page.
+func (s *SearchService) Search(req requests.SearchRequest) (dto.SearchResults, string, error) {
+	result := dto.SearchResults{
+		Query:   strings.TrimSpace(req.Query),
+		Results: []dto.SearchResult{},
+	}
+	if result.Query == "" {
+		return result, "", ValidationError("Search query must not be empty")
+	}
+	offset, limit, err := repository.OffsetPage(req.PageRequest)
+	if err != nil {
+		return result, "", listError(err, "Failed to search")
+	}
+
+	var hits []dto.SearchResult
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		rows, err := s.repo.Search(tx, result.Query, req.Type, limit+1, offset)
+		hits = rows
+		return err
+	})
+	if err != nil {
+		return result, "", InternalError("Failed to search", err)
+	}
+
+	var next string
+	if len(hits) > 0 {
+		result.Total = hits[0].Total
+		result.Results, next = repository.NextOffsetCursor(hits, offset, limit)
+	}
+	return result, next, nil
+}
@@ -0,0 +1,257 @@
+package service
+
+import (
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/db"
+	"errors"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// refreshReuseGrace is how long after a rotation the old refresh token is
+	// refused with a conflict rather than treated as stolen, so two tabs
+	// refreshing at once do not sign the user out; the loser retries with the
+	// cookies the winner received.
+	refreshReuseGrace = 30 * time.Second
+	// sessionRetention is how long ended sessions are kept before cleanup.
+	sessionRetention = 7 * 24 * time.Hour
+)
+
+func accessTokenTTL() time.Duration {
+	if ttl := config.AppConfig.Auth.AccessTokenTTL; ttl > 0 {
+		return ttl
+	}
+	return auth.AccessTokenTTL
+}
+
+func refreshTokenTTL() time.Duration {
+	if ttl := config.AppConfig.Auth.RefreshTokenTTL; ttl > 0 {
+		return ttl
+	}
+	return auth.RefreshTokenTTL
+}
+
+// StartSession records a new signed-in device for the user and issues its
+// first access and refresh tokens.
+func (s *AuthService) StartSession(user dto.AuthUserDTO, userAgent, ip string) (dto.SessionTokens, error) {
+	var result dto.SessionTokens
+
+	refresh, hash, err := auth.NewToken()
+	if err != nil {
+		return result, InternalError("Failed to start session", err)
+	}
+	now := time.Now()
+	session := model.UserSession{
+		UserID:     user.ID,
+		TokenHash:  hash,
+		UserAgent:  userAgent,
+		IP:         ip,
+		LastUsedAt: now,
+		ExpiresAt:  now.Add(refreshTokenTTL()),
+	}
+
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		return s.sessionRepo.Create(tx, &session)
+	})
+	if err != nil {
+		return result, InternalError("Failed to start session", err)
+	}
+	return s.issueTokens(session, user.Role, refresh, now)
+}
+
+// RefreshSession exchanges a refresh token for new access and refresh tokens.
+// Each refresh token works once; presenting one that has already been
+// rotated out revokes the whole session, since it means the token leaked,
+// unless it was rotated moments ago by a concurrent refresh.
+func (s *AuthService) RefreshSession(refreshToken, userAgent, ip string) (dto.SessionTokens, error) {
+	var result dto.SessionTokens
+	invalid := UnauthorizedError("Session expired, please log in again")
+
+	hash := auth.HashToken(refreshToken)
+	now := time.Now()
+	next, nextHash, err := auth.NewToken()
+	if err != nil {
+		return result, InternalError("Failed to refresh session", err)
+	}
+
+	var session model.UserSession
+	var role string
+	var reused bool
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		session, err = s.sessionRepo.GetByTokenHash(tx, hash)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			previous, err := s.sessionRepo.GetByPreviousHash(tx, hash)
+			if err != nil {
+				return invalid
+			}
+			if previous.RevokedAt != nil {
+				return invalid
+			}
+			if now.Sub(previous.LastUsedAt) > refreshReuseGrace {
+				reused = true
+				_, err = s.sessionRepo.Revoke(tx, previous.UserID, previous.ID, now)
+				return err
+			}
+			return ConflictError("Session was just refreshed, please retry", errors.New("refresh token already rotated"))
+		} else if err != nil {
+			return err
+		}
+		if session.RevokedAt != nil || !session.ExpiresAt.After(now) {
+			return invalid
+		}
+
+		user, err := s.authRepo.GetUserByID(tx, session.UserID)
+		if err != nil {
+			return invalid
+		}
+		role = user.Role
+		missing, err := s.secondFactorMissing(tx, user.ID, role)
+		if err != nil {
+			return err
+		}
+		if missing {
+			return UnauthorizedError("Your account now requires two-factor authentication, please log in again")
+		}
+
+		session.TokenHash = nextHash
+		session.LastUsedAt = now
+		session.ExpiresAt = now.Add(refreshTokenTTL())
+		rotated, err := s.sessionRepo.Rotate(tx, session.ID, hash, nextHash, now, session.ExpiresAt)
+		if err != nil {
+			return err
+		}
+		if !rotated {
+			return invalid
+		}
+		return nil
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil && reused:
+		log.Printf("Refresh token reused for session %d; session revoked", session.ID)
+		return result, invalid
+	case err == nil:
+		return s.issueTokens(session, role, next, now)
+	case errors.As(err, &serr):
+		return result, serr
+	default:
+		return result, InternalError("Failed to refresh session", err)
+	}
+}
+
+// EndSession revokes the session a refresh token belongs to. Unknown tokens
+// are ignored, so logging out always succeeds.
+func (s *AuthService) EndSession(refreshToken string) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		session, err := s.sessionRepo.GetByTokenHash(tx, auth.HashToken(refreshToken))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil
+		} else if err != nil {
+			return err
+		}
+		_, err = s.sessionRepo.Revoke(tx, session.UserID, session.ID, time.Now())
+		return err
+	})
+	if err != nil {
+		return InternalError("Failed to end session", err)
+	}
+	return nil
+}
+
+// ListSessions returns the user's active sessions, flagging the one making
+// the request.
+func (s *AuthService) ListSessions(userID, currentID uint) ([]dto.Session, error) {
+	result := []dto.Session{}
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		sessions, err := s.sessionRepo.ListActive(tx, userID, time.Now())
+		if err != nil {
+			return err
+		}
+		for _, session := range sessions {
+			result = append(result, dto.Session{
+				ID:         session.ID,
+				UserAgent:  session.UserAgent,
+				IP:         session.IP,
+				CreatedAt:  session.CreatedAt,
+				LastUsedAt: session.LastUsedAt,
+				ExpiresAt:  session.ExpiresAt,
+				Current:    session.ID == currentID,
+			})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, InternalError("Failed to fetch sessions", err)
+	}
+	return result, nil
+}
+
+// RevokeSession signs one of the user's devices out. Its access token keeps
+// working until it expires, but it can no longer be refreshed.
+func (s *AuthService) RevokeSession(userID, sessionID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		revoked, err := s.sessionRepo.Revoke(tx, userID, sessionID, time.Now())
+		if err != nil {
+			return err
+		}
+		if !revoked {
+			return NotFoundError("Session not found", errors.New("session not found"))
+		}
+		return nil
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to revoke session", err)
+	}
+}
+
+// RevokeOtherSessions signs the user out everywhere except the current
+// session, and returns how many sessions were ended.
+func (s *AuthService) RevokeOtherSessions(userID, currentID uint) (int64, error) {
+	var count int64
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		n, err := s.sessionRepo.RevokeAll(tx, userID, currentID, time.Now())
+		count = n
+		return err
+	})
+	if err != nil {
+		return 0, InternalError("Failed to revoke sessions", err)
+	}
+	return count, nil
+}
+
+// CleanupSessions deletes sessions that ended more than a week ago.
+func (s *AuthService) CleanupSessions() error {
+	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		_, err := s.sessionRepo.DeleteEnded(tx, time.Now().Add(-sessionRetention))
+		return err
+	})
+}
+
+func (s *AuthService) issueTokens(session model.UserSession, role, refreshToken string, now time.Time) (dto.SessionTokens, error) {
+	ttl := accessTokenTTL()
+	access, err := auth.GenerateToken(session.UserID, role, session.ID, ttl, config.AppConfig.Auth.SecretKey)
+	if err != nil {
+		return dto.SessionTokens{}, InternalError("Failed to generate token", err)
+	}
+	return dto.SessionTokens{
+		SessionID:        session.ID,
+		AccessToken:      access,
+		AccessExpiresAt:  now.Add(ttl),
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: session.ExpiresAt,
+	}, nil
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/db"
+	"M-AI/pkg/sso"
+	"context"
+	"errors"
+	"gorm.io/gorm"
+	"log"
+	"net/url"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ssoStateTTL is how long a user has to finish signing in at the provider.
+const ssoStateTTL = 10 * time.Minute
+
+type ssoProvider struct {
+	config.OIDCProvider
+	client *sso.Provider
+}
+
+type SSOService struct {
+	authRepo     *repository.AuthRepository
+	identityRepo *repository.UserIdentityRepository
+	auth         *AuthService
+	providers    map[string]ssoProvider
+	db           *gorm.DB
+}
+
+func NewSSOService(
+	db *gorm.DB,
+	authRepo *repository.AuthRepository,
+	identityRepo *repository.UserIdentityRepository,
+	authService *AuthService,
+	providers map[string]config.OIDCProvider,
+) *SSOService {
+	s := &SSOService{
+		authRepo:     authRepo,
+		identityRepo: identityRepo,
+		auth:         authService,
+		providers:    make(map[string]ssoProvider, len(providers)),
+		db:           db,
+	}
+	for name, p := range providers {
+		s.providers[name] = ssoProvider{
+			OIDCProvider: p,
+			client: sso.NewProvider(sso.Config{
+				Issuer:       p.Issuer,
+				ClientID:     p.ClientID,
+				ClientSecret: p.ClientSecret,
+				RedirectURL:  p.RedirectURL,
+				Scopes:       p.Scopes,
+			}),
+		}
+	}
+	return s
+}
+
+// Providers lists the providers users can sign in with, for login buttons.
+func (s *SSOService) Providers() []dto.SSOProvider {
+	result := make([]dto.SSOProvider, 0, len(s.providers))
+	for name, p := range s.providers {
+		displayName := p.DisplayName
+		if displayName == "" {
+			displayName = name
+		}
+		result = append(result, dto.SSOProvider{Name: name, DisplayName: displayName})
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].DisplayName < result[j].DisplayName })
+	return result
+}
+
+// BeginLogin starts signing in with a provider. It returns the provider's
+// sign-in page and the sealed state the client must bring back to the
+// callback. returnTo is the frontend path to land on afterwards.
+func (s *SSOService) BeginLogin(ctx context.Context, provider, returnTo string) (string, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return "", "", NotFoundError("Unknown sign-in provider", errors.New("unknown provider "+provider))
+	}
+
+	state, err := sso.NewState(provider, safeReturnPath(returnTo))
+	if err != nil {
+		return "", "", InternalError("Failed to start sign-in", err)
+	}
+	sealed, err := state.Seal(config.AppConfig.Auth.SecretKey, ssoStateTTL)
+	if err != nil {
+		return "", "", InternalError("Failed to start sign-in", err)
+	}
+	authURL, err := p.client.AuthURL(ctx, state)
+	if err != nil {
+		return "", "", InternalError("Sign-in provider is unavailable", err)
+	}
+	return authURL, sealed, nil
+}
+
+// CompleteLogin finishes signing in when the provider sends the user back. It
+// checks the returned state against the sealed one, redeems the code, and
+// starts a session for the linked account. It returns the session and the
+// frontend page to send the user to. Users who owe a second factor get no
+// session; the page is then the frontend's second sign-in step, carrying the
+// challenge token.
+func (s *SSOService) CompleteLogin(ctx context.Context, provider, sealed, returnedState, code, userAgent, ip string) (dto.SessionTokens, string, error) {
+	p, ok := s.providers[provider]
+	if !ok {
+		return dto.SessionTokens{}, "", NotFoundError("Unknown sign-in provider", errors.New("unknown provider "+provider))
+	}
+
+	state, err := sso.OpenState(sealed, config.AppConfig.Auth.SecretKey)
+	if err != nil || state.Provider != provider || returnedState == "" || state.State != returnedState {
+		return dto.SessionTokens{}, "", BadRequestError("Sign-in expired or was tampered with, please try again", err)
+	}
+
+	identity, err := p.client.Exchange(ctx, state, code)
+	if err != nil {
+		log.Printf("Sign-in with %s failed: %v", provider, err)
+		return dto.SessionTokens{}, "", UnauthorizedError("Sign-in with the provider failed")
+	}
+	if p.TrustEmail && identity.Email != "" {
+		identity.EmailVerified = true
+	}
+
+	user, challenge, err := s.linkedUser(provider, p, identity, userAgent, ip)
+	if err != nil {
+		return dto.SessionTokens{}, "", err
+	}
+	if challenge != nil {
+		query := url.Values{
+			"mfa_token": {challenge.MFAToken},
+			"setup":     {strconv.FormatBool(challenge.SetupRequired)},
+			"next":      {state.ReturnTo},
+		}
+		return dto.SessionTokens{}, appURL("/login/2fa") + "?" + query.Encode(), nil
+	}
+	tokens, err := s.auth.StartSession(user, userAgent, ip)
+	return tokens, appURL(state.ReturnTo), err
+}
+
+// FailureURL is the frontend login page, showing why signing in failed.
+func (s *SSOService) FailureURL(message string) string {
+	return appURL("/login") + "?sso_error=" + url.QueryEscape(message)
+}
+
+// linkedUser finds or creates the account for an identity. A known identity
+// signs in to the account it is linked to. Otherwise it is linked to the
+// account with the same verified email, or a new account if the provider
+// allows it. The provider stands in for the password, so the account's second
+// factor is still asked for.
+func (s *SSOService) linkedUser(name string, p ssoProvider, identity sso.Identity, userAgent, ip string) (dto.AuthUserDTO, *dto.MFAChallenge, error) {
+	var result dto.AuthUserDTO
+	var challenge *dto.MFAChallenge
+	email := strings.ToLower(strings.TrimSpace(identity.Email))
+
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		now := time.Now()
+		var user model.User
+
+		linked, err := s.identityRepo.Get(tx, identity.Issuer, identity.Subject)
+		switch {
+		case err == nil:
+			if user, err = s.authRepo.GetUserByID(tx, linked.UserID); err != nil {
+				return ForbiddenError("The account linked to this sign-in no longer exists")
+			}
+			if err := s.identityRepo.RecordLogin(tx, linked.ID, email, now); err != nil {
+				return err
+			}
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		default:
+			if email == "" || !identity.EmailVerified {
+				return ForbiddenError("The provider did not confirm your email address")
+			}
+			if !emailDomainAllowed(email, p.AllowedDomains) {
+				return ForbiddenError("Accounts with this email domain cannot sign in here")
+			}
+			if user, err = s.userForEmail(tx, p, email, identity.Name, now); err != nil {
+				return err
+			}
+			if err := s.identityRepo.Create(tx, &model.UserIdentity{
+				UserID:      user.ID,
+				Provider:    name,
+				Issuer:      identity.Issuer,
+				Subject:     identity.Subject,
+				Email:       email,
+				LastLoginAt: &now,
+			}); err != nil {
+				return err
+			}
+			log.Printf("Linked %s identity %s to user %d", name, identity.Subject, user.ID)
+		}
+
+		result = dto.AuthUserDTO{ID: user.ID, Email: user.Email, Name: user.Name, Role: user.Role}
+		if challenge, err = s.auth.loginChallenge(tx, user.ID, user.Role); err != nil {
+			return err
+		}
+		outcome := constants.LoginSuccess
+		if challenge != nil {
+			outcome = constants.LoginChallenged
+		}
+		return s.auth.loginRepo.Record(tx, &model.LoginAttempt{
+			Email:     strings.ToLower(user.Email),
+			UserID:    &user.ID,
+			IP:        ip,
+			UserAgent: userAgent,
+			Outcome:   outcome,
+		})
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return result, challenge, nil
+	case errors.As(err, &serr):
+		return result, nil, serr
+	default:
+		return result, nil, InternalError("Failed to sign in", err)
+	}
+}
+
+// userForEmail returns the account with email, creating it if the provider
+// allows. An existing account is only linked if its owner verified the
+// address; otherwise whoever registered it first, perhaps with someone
+// else's address, would keep its password and share the account.
+func (s *SSOService) userForEmail(tx *gorm.DB, p ssoProvider, email, name string, now time.Time) (model.User, error) {
+	user, err := s.authRepo.FindUserByEmailFold(tx, email)
+	if err == nil {
+		if user.EmailVerifiedAt == nil {
+			return user, ForbiddenError("An unverified account uses this email address; verify it and sign in with your password first")
+		}
+		return user, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, err
+	}
+	if !p.CreateUsers {
+		return user, ForbiddenError("No account uses this email address")
+	}
+
+	// The account has no usable password until the user sets one through a
+	// password reset.
+	password, _, err := auth.NewToken()
+	if err != nil {
+		return user, err
+	}
+	hashed, err := auth.HashPassword(password, config.AppConfig.Auth.SecretKey, config.AppConfig.Auth.Salt)
+	if err != nil {
+		return user, err
+	}
+	if name = strings.TrimSpace(name); name == "" {
+		name, _, _ = strings.Cut(email, "@")
+	}
+	user = model.User{
+		Name:            name,
+		Email:           email,
+		Password:        hashed,
+		Role:            auth.RoleStudent,
+		EmailVerifiedAt: &now,
+	}
+	return user, s.authRepo.CreateUser(tx, &user)
+}
+
+func emailDomainAllowed(email string, domains []string) bool {
+	if len(domains) == 0 {
+		return true
+	}
+	_, domain, _ := strings.Cut(email, "@")
+	return slices.ContainsFunc(domains, func(d string) bool { return strings.EqualFold(d, domain) })
+}
+
+// safeReturnPath keeps returnTo only if it is a path on the frontend, so the
+// login cannot be used to redirect users to another site.
+func safeReturnPath(returnTo string) string {
+	if !strings.HasPrefix(returnTo, "/") || strings.HasPrefix(returnTo, "//") || strings.ContainsAny(returnTo, "\\\r\n") {
+		return "/"
+	}
+	return returnTo
+}
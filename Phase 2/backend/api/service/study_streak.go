@@ -0,0 +1,205 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/repository"
+	"M-AI/pkg/db"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+const (
+	// A freeze token is earned for every freezeEarnEvery days of streak, up
+	// to maxFreezeTokens held at once. A missed day spends one automatically.
+	freezeEarnEvery = 7
+	maxFreezeTokens = 2
+	// reminderHour is the local hour after which a streak with today's goal
+	// still unmet is reminded about.
+	reminderHour    = 18
+	frozenDaysShown = 30
+)
+
+type StudyStreakService struct {
+	repo             *repository.StudyStreakRepository
+	authRepo         *repository.AuthRepository
+	notificationRepo *repository.NotificationRepository
+	db               *gorm.DB
+}
+
+func NewStudyStreakService(
+	db *gorm.DB,
+	repo *repository.StudyStreakRepository,
+	authRepo *repository.AuthRepository,
+	notificationRepo *repository.NotificationRepository,
+) *StudyStreakService {
+	return &StudyStreakService{
+		repo:             repo,
+		authRepo:         authRepo,
+		notificationRepo: notificationRepo,
+		db:               db,
+	}
+}
+
+func (s *StudyStreakService) GetStreak(userID uint) (dto.StudyStreak, error) {
+	var result dto.StudyStreak
+
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		var err error
+		result, err = s.streakFor(tx, userID, time.Now())
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to load study streak", err)
+	}
+	return result, nil
+}
+
+// streakFor computes the user's streak within tx, for callers that already
+// hold a transaction.
+func (s *StudyStreakService) streakFor(tx *gorm.DB, userID uint, now time.Time) (dto.StudyStreak, error) {
+	user, err := s.authRepo.GetUserByID(tx, userID)
+	if err != nil {
+		return dto.StudyStreak{}, err
+	}
+	return s.compute(tx, dto.StreakCandidate{
+		UserID:          user.ID,
+		Timezone:        user.Timezone,
+		DailyGoalType:   user.DailyGoalType,
+		DailyGoalTarget: user.DailyGoalTarget,
+	}, now)
+}
+
+// QueueStreakReminders queues a reminder for every user whose streak ends
+// tonight unless they meet their goal. It runs hourly so each user is caught
+// soon after reminderHour in their own timezone; the dedupe key keeps it to
+// one reminder per local day.
+func (s *StudyStreakService) QueueStreakReminders() error {
+	now := time.Now()
+	return db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		candidates, err := s.repo.GetStreakCandidates(tx, now.Add(-72*time.Hour))
+		if err != nil {
+			return err
+		}
+
+		for _, c := range candidates {
+			if now.In(studyLocation(c.Timezone)).Hour() < reminderHour {
+				continue
+			}
+			streak, err := s.compute(tx, c, now)
+			if err != nil {
+				return err
+			}
+			if !streak.AtRisk {
+				continue
+			}
+
+			remaining := streak.GoalTarget - streak.TodayValue
+			err = s.notificationRepo.Queue(tx, &model.Notification{
+				UserID:    c.UserID,
+				Kind:      constants.NotificationStreakAtRisk,
+				Title:     fmt.Sprintf("Keep your %d day streak going", streak.Current),
+				Body:      fmt.Sprintf("%d more %s today to reach your goal.", remaining, streak.GoalType),
+				DedupeKey: streak.Today,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (s *StudyStreakService) compute(tx *gorm.DB, c dto.StreakCandidate, now time.Time) (dto.StudyStreak, error) {
+	loc := studyLocation(c.Timezone)
+	days, err := s.repo.GetStudyDays(tx, c.UserID, loc.String())
+	if err != nil {
+		return dto.StudyStreak{}, err
+	}
+
+	goalType, target := c.DailyGoalType, c.DailyGoalTarget
+	if goalType == "" {
+		goalType = constants.GoalQuestions
+	}
+	if target <= 0 {
+		target = 1
+	}
+
+	result := replayStreak(days, goalType, target, now.In(loc))
+	result.Timezone = loc.String()
+	return result, nil
+}
+
+// replayStreak walks every local day from the first activity to today. Days
+// that meet the goal extend the streak and earn freeze tokens; a missed day
+// spends a token if one is held and otherwise ends the streak. Today only
+// counts once the goal is met, so an unfinished today never breaks a streak.
+func replayStreak(days []dto.StudyDay, goalType string, target int, now time.Time) dto.StudyStreak {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	result := dto.StudyStreak{
+		GoalType:   goalType,
+		GoalTarget: target,
+		Today:      today.Format(dateLayout),
+	}
+
+	values := make(map[string]int, len(days))
+	for _, d := range days {
+		v := d.Questions
+		if goalType == constants.GoalMinutes {
+			v = int(d.Minutes)
+		}
+		values[d.Day.Format(dateLayout)] = v
+	}
+
+	var frozen []string
+	meet := func() {
+		result.Current++
+		if result.Current > result.Longest {
+			result.Longest = result.Current
+		}
+		if result.Current%freezeEarnEvery == 0 && result.FreezeTokens < maxFreezeTokens {
+			result.FreezeTokens++
+		}
+	}
+
+	if len(days) > 0 {
+		first := days[0].Day
+		day := time.Date(first.Year(), first.Month(), first.Day(), 0, 0, 0, 0, time.UTC)
+		for ; day.Before(today); day = day.AddDate(0, 0, 1) {
+			key := day.Format(dateLayout)
+			switch {
+			case values[key] >= target:
+				meet()
+			case result.Current > 0 && result.FreezeTokens > 0:
+				result.FreezeTokens--
+				frozen = append(frozen, key)
+			default:
+				result.Current = 0
+			}
+		}
+	}
+
+	result.TodayValue = values[result.Today]
+	result.GoalMet = result.TodayValue >= target
+	if result.GoalMet {
+		meet()
+	}
+	result.AtRisk = result.Current > 0 && !result.GoalMet
+
+	if len(frozen) > frozenDaysShown {
+		frozen = frozen[len(frozen)-frozenDaysShown:]
+	}
+	result.FrozenDays = frozen
+	return result
+}
+
+func studyLocation(timezone string) *time.Location {
+	loc, err := time.LoadLocation(timezone)
+	if err != nil || timezone == "" {
+		return time.UTC
+	}
+	return loc
+}
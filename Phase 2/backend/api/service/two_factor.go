@@ -0,0 +1,441 @@
+package service
+
+import (
+	"M-AI/api/constants"
+	"M-AI/api/dto"
+	"M-AI/api/model"
+	"M-AI/api/requests"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/db"
+	"errors"
+	"fmt"
+	"gorm.io/gorm"
+	"strings"
+	"time"
+)
+
+const (
+	// mfaTokenTTL is how long a user who passed their password has for the
+	// second sign-in step.
+	mfaTokenTTL       = 5 * time.Minute
+	recoveryCodeCount = 10
+	totpIssuer        = "M-AI"
+	qrCodeSize        = 256
+)
+
+// loginChallenge decides whether a user who passed their password also owes
+// a second factor, and returns the challenge for it if so. Users whose role
+// requires two-factor authentication but who have not set it up are asked to
+// enrol before they get a session.
+func (s *AuthService) loginChallenge(tx *gorm.DB, userID uint, role string) (*dto.MFAChallenge, error) {
+	state, err := s.twoFactorRepo.GetState(tx, userID, role)
+	if err != nil || (!state.Enabled && !state.Required) {
+		return nil, err
+	}
+	setup := !state.Enabled
+	token, err := auth.GenerateMFAToken(userID, setup, mfaTokenTTL, config.AppConfig.Auth.SecretKey)
+	if err != nil {
+		return nil, err
+	}
+	return &dto.MFAChallenge{
+		MFARequired:   true,
+		SetupRequired: setup,
+		MFAToken:      token,
+		ExpiresAt:     time.Now().Add(mfaTokenTTL),
+	}, nil
+}
+
+// secondFactorMissing reports whether the user's role requires two-factor
+// authentication they have not set up, so their existing sessions and API
+// keys stop working once an admin enforces it.
+func (s *AuthService) secondFactorMissing(tx *gorm.DB, userID uint, role string) (bool, error) {
+	state, err := s.twoFactorRepo.GetState(tx, userID, role)
+	return state.Required && !state.Enabled, err
+}
+
+// CompleteSecondFactor is the second sign-in step. It checks a code from the
+// user's authenticator, or uses up a recovery code, and returns the user to
+// start a session for. A user enrolling while signing in confirms their
+// authenticator with the code and also gets their recovery codes. Wrong codes
+// count towards the account lockout like wrong passwords.
+func (s *AuthService) CompleteSecondFactor(req requests.TwoFactorLoginRequest, ip, userAgent string) (dto.AuthUserDTO, []string, error) {
+	var result dto.AuthUserDTO
+	var codes []string
+	expired := UnauthorizedError("Sign-in expired, please log in again")
+
+	userID, setup, err := auth.ExtractMFAToken(req.MFAToken, config.AppConfig.Auth.SecretKey)
+	if err != nil {
+		return result, nil, expired
+	}
+
+	var lockedFor time.Duration
+	attempt := model.LoginAttempt{
+		UserID:    &userID,
+		IP:        ip,
+		UserAgent: userAgent,
+	}
+	err = db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		now := time.Now()
+		user, err := s.authRepo.GetUserByID(tx, userID)
+		if err != nil {
+			return expired
+		}
+		attempt.Email = strings.ToLower(user.Email)
+		if lockedFor, err = s.lockedFor(tx, attempt.Email, now); err != nil {
+			return err
+		}
+		if lockedFor > 0 {
+			attempt.Outcome = constants.LoginLocked
+			return s.loginRepo.Record(tx, &attempt)
+		}
+
+		attempt.Outcome = constants.LoginFailed
+		var ok bool
+		if setup {
+			codes, ok, err = s.confirmTOTP(tx, userID, req.Code, now)
+		} else {
+			ok, err = s.checkSecondFactor(tx, userID, req.Code, req.RecoveryCode, now)
+		}
+		if err != nil {
+			return err
+		}
+		if ok {
+			attempt.Outcome = constants.LoginSuccess
+			result = dto.AuthUserDTO{
+				ID:    user.ID,
+				Email: user.Email,
+				Name:  user.Name,
+				Role:  user.Role,
+			}
+		}
+		return s.loginRepo.Record(tx, &attempt)
+	})
+
+	var serr *ServiceError
+	switch {
+	case errors.As(err, &serr):
+		return result, nil, serr
+	case err != nil:
+		return result, nil, InternalError("Failed to verify code", err)
+	case attempt.Outcome == constants.LoginLocked:
+		return result, nil, TooManyRequestsError("Too many failed sign-in attempts, please try again later", lockedFor)
+	case attempt.Outcome == constants.LoginFailed:
+		return result, nil, UnauthorizedError("Invalid two-factor code")
+	}
+	return result, codes, nil
+}
+
+// BeginLoginEnrolment is BeginTOTPEnrolment for a user signing in whose role
+// requires two-factor authentication they have not set up yet.
+func (s *AuthService) BeginLoginEnrolment(mfaToken string) (dto.TOTPEnrolment, error) {
+	userID, setup, err := auth.ExtractMFAToken(mfaToken, config.AppConfig.Auth.SecretKey)
+	if err != nil || !setup {
+		return dto.TOTPEnrolment{}, UnauthorizedError("Sign-in expired, please log in again")
+	}
+	return s.BeginTOTPEnrolment(userID)
+}
+
+// BeginTOTPEnrolment generates a new authenticator secret for the user. It
+// does not count until confirmed with a code from the app; starting again
+// replaces an unconfirmed secret.
+func (s *AuthService) BeginTOTPEnrolment(userID uint) (dto.TOTPEnrolment, error) {
+	var result dto.TOTPEnrolment
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		user, err := s.authRepo.GetUserByID(tx, userID)
+		if err != nil {
+			return NotFoundError("User not found", err)
+		}
+		key, err := auth.NewTOTPKey(totpIssuer, user.Email)
+		if err != nil {
+			return err
+		}
+		sealed, err := auth.EncryptSecret(key.Secret(), config.AppConfig.Auth.SecretKey)
+		if err != nil {
+			return err
+		}
+		saved, err := s.twoFactorRepo.SavePendingTOTP(tx, userID, sealed, time.Now())
+		if err != nil {
+			return err
+		}
+		if !saved {
+			return ConflictError("Two-factor authentication is already on", errors.New("totp already confirmed"))
+		}
+
+		qrCode, err := auth.QRCode(key, qrCodeSize)
+		if err != nil {
+			return err
+		}
+		result = dto.TOTPEnrolment{Secret: key.Secret(), OTPAuthURL: key.URL(), QRCode: qrCode}
+		return nil
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return result, nil
+	case errors.As(err, &serr):
+		return result, serr
+	default:
+		return result, InternalError("Failed to set up two-factor authentication", err)
+	}
+}
+
+// ConfirmTOTPEnrolment turns two-factor authentication on once the user
+// shows their app generates the right codes, and returns their recovery
+// codes. This is the only time the codes are shown.
+func (s *AuthService) ConfirmTOTPEnrolment(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		totp, err := s.twoFactorRepo.GetTOTP(tx, userID)
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			return BadRequestError("Start setting up two-factor authentication first", err)
+		case err != nil:
+			return err
+		case totp.ConfirmedAt != nil:
+			return ConflictError("Two-factor authentication is already on", errors.New("totp already confirmed"))
+		}
+
+		var ok bool
+		codes, ok, err = s.confirmTOTP(tx, userID, code, time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return BadRequestError("Invalid two-factor code", errors.New("invalid totp code"))
+		}
+		return nil
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return codes, nil
+	case errors.As(err, &serr):
+		return nil, serr
+	default:
+		return nil, InternalError("Failed to set up two-factor authentication", err)
+	}
+}
+
+// DisableTwoFactor turns two-factor authentication off, given a current code
+// or a recovery code. Users whose role requires it cannot turn it off.
+func (s *AuthService) DisableTwoFactor(userID uint, req requests.DisableTwoFactorRequest) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		user, err := s.authRepo.GetUserByID(tx, userID)
+		if err != nil {
+			return NotFoundError("User not found", err)
+		}
+		state, err := s.twoFactorRepo.GetState(tx, userID, user.Role)
+		switch {
+		case err != nil:
+			return err
+		case !state.Enabled:
+			return BadRequestError("Two-factor authentication is not on", errors.New("totp not enabled"))
+		case state.Required:
+			return ForbiddenError("Your role requires two-factor authentication")
+		}
+
+		ok, err := s.checkSecondFactor(tx, userID, req.Code, req.RecoveryCode, time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return BadRequestError("Invalid two-factor code", errors.New("invalid totp code"))
+		}
+		_, err = s.twoFactorRepo.DeleteTOTP(tx, userID)
+		return err
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to turn off two-factor authentication", err)
+	}
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes, given a current
+// code from their authenticator. The old codes stop working.
+func (s *AuthService) RegenerateRecoveryCodes(userID uint, code string) ([]string, error) {
+	var codes []string
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		ok, err := s.checkSecondFactor(tx, userID, code, "", time.Now())
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return BadRequestError("Invalid two-factor code", errors.New("invalid totp code"))
+		}
+		codes, err = s.replaceRecoveryCodes(tx, userID)
+		return err
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return codes, nil
+	case errors.As(err, &serr):
+		return nil, serr
+	default:
+		return nil, InternalError("Failed to create recovery codes", err)
+	}
+}
+
+func (s *AuthService) TwoFactorStatus(userID uint) (dto.TwoFactorStatus, error) {
+	var result dto.TwoFactorStatus
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		user, err := s.authRepo.GetUserByID(tx, userID)
+		if err != nil {
+			return err
+		}
+		state, err := s.twoFactorRepo.GetState(tx, userID, user.Role)
+		if err != nil {
+			return err
+		}
+		result.Enabled, result.Required = state.Enabled, state.Required
+		if !state.Enabled {
+			return nil
+		}
+
+		totp, err := s.twoFactorRepo.GetTOTP(tx, userID)
+		if err != nil {
+			return err
+		}
+		result.EnabledAt = totp.ConfirmedAt
+		result.RecoveryCodesLeft, err = s.twoFactorRepo.CountRecoveryCodes(tx, userID)
+		return err
+	})
+	if err != nil {
+		return result, InternalError("Failed to fetch two-factor status", err)
+	}
+	return result, nil
+}
+
+// ResetTwoFactor removes a user's authenticator for an admin, for users who
+// lost both it and their recovery codes. If their role requires two-factor
+// authentication they set it up again at their next sign-in.
+func (s *AuthService) ResetTwoFactor(userID uint) error {
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		deleted, err := s.twoFactorRepo.DeleteTOTP(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !deleted {
+			return NotFoundError("User has no two-factor authentication", errors.New("totp not found"))
+		}
+		return nil
+	})
+
+	var serr *ServiceError
+	switch {
+	case err == nil:
+		return nil
+	case errors.As(err, &serr):
+		return serr
+	default:
+		return InternalError("Failed to reset two-factor authentication", err)
+	}
+}
+
+func (s *AuthService) ListTwoFactorPolicies() ([]dto.TwoFactorPolicy, error) {
+	result := []dto.TwoFactorPolicy{}
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		policies, err := s.twoFactorRepo.ListPolicies(tx)
+		for _, p := range policies {
+			result = append(result, dto.TwoFactorPolicy{Role: p.Role, Required: p.Required, UpdatedAt: p.UpdatedAt})
+		}
+		return err
+	})
+	if err != nil {
+		return nil, InternalError("Failed to fetch two-factor policies", err)
+	}
+	return result, nil
+}
+
+// SetTwoFactorPolicy sets whether a role requires two-factor authentication.
+// Users of the role without it are asked to set it up at their next sign-in,
+// and cannot refresh their sessions or use API keys until they do.
+func (s *AuthService) SetTwoFactorPolicy(adminID uint, role string, required bool) error {
+	if !auth.IsValidRole(role) {
+		return ValidationError(fmt.Sprintf("Unknown role %q", role))
+	}
+	err := db.TransactionExecutor(s.db, func(tx *gorm.DB) error {
+		return s.twoFactorRepo.SetPolicy(tx, role, required, adminID, time.Now())
+	})
+	if err != nil {
+		return InternalError("Failed to update two-factor policy", err)
+	}
+	return nil
+}
+
+// confirmTOTP checks code against the user's authenticator. If it has not
+// been confirmed yet, a match confirms it and issues recovery codes.
+func (s *AuthService) confirmTOTP(tx *gorm.DB, userID uint, code string, now time.Time) ([]string, bool, error) {
+	totp, err := s.twoFactorRepo.GetTOTP(tx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, false, nil
+	} else if err != nil {
+		return nil, false, err
+	}
+
+	ok, err := s.checkTOTP(tx, totp, code, now)
+	if err != nil || !ok || totp.ConfirmedAt != nil {
+		return nil, ok, err
+	}
+	if err := s.twoFactorRepo.ConfirmTOTP(tx, userID, now); err != nil {
+		return nil, false, err
+	}
+	codes, err := s.replaceRecoveryCodes(tx, userID)
+	return codes, err == nil, err
+}
+
+// checkSecondFactor checks a code from the user's confirmed authenticator
+// or, when code is empty, uses up one of their recovery codes.
+func (s *AuthService) checkSecondFactor(tx *gorm.DB, userID uint, code, recoveryCode string, now time.Time) (bool, error) {
+	if code == "" {
+		if recoveryCode == "" {
+			return false, nil
+		}
+		hash := auth.HashRecoveryCode(recoveryCode, config.AppConfig.Auth.SecretKey)
+		return s.twoFactorRepo.UseRecoveryCode(tx, userID, hash, now)
+	}
+
+	totp, err := s.twoFactorRepo.GetTOTP(tx, userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && totp.ConfirmedAt == nil) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return s.checkTOTP(tx, totp, code, now)
+}
+
+// checkTOTP checks a code from an authenticator and uses it up, so the same
+// code cannot be replayed.
+func (s *AuthService) checkTOTP(tx *gorm.DB, totp model.UserTOTP, code string, now time.Time) (bool, error) {
+	secret, err := auth.DecryptSecret(totp.Secret, config.AppConfig.Auth.SecretKey)
+	if err != nil {
+		return false, err
+	}
+	step, ok := auth.ValidateTOTP(secret, code, now)
+	if !ok {
+		return false, nil
+	}
+	return s.twoFactorRepo.UseStep(tx, totp.UserID, step)
+}
+
+func (s *AuthService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	codes, err := auth.NewRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, code := range codes {
+		hashes[i] = auth.HashRecoveryCode(code, config.AppConfig.Auth.SecretKey)
+	}
+	return codes, s.twoFactorRepo.ReplaceRecoveryCodes(tx, userID, hashes)
+}
@@ -5,10 +5,13 @@ import (
 	"net/http"
 )
 
+// APIResponse is the envelope for every response. NextCursor is set on pages
+// of a list that have more rows to fetch.
 type APIResponse struct {
-	Status  string      `json:"status"`
-	Message string      `json:"message,omitempty"`
-	Data    interface{} `json:"data,omitempty"`
+	Status     string      `json:"status"`
+	Message    string      `json:"message,omitempty"`
+	Data       interface{} `json:"data,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
 }
 
 func SendSuccess(c *gin.Context, message string, data any) {
@@ -19,6 +22,15 @@ func SendSuccess(c *gin.Context, message string, data any) {
 	})
 }
 
+func SendPage(c *gin.Context, message string, data any, nextCursor string) {
+	c.JSON(http.StatusOK, APIResponse{
+		Status:     "success",
+		Message:    message,
+		Data:       data,
+		NextCursor: nextCursor,
+	})
+}
+
 func SendError(c *gin.Context, httpStatus int, message string) {
 	c.JSON(httpStatus, APIResponse{
 		Status:  "error",
@@ -11,6 +11,7 @@ import (
 func main() {
 	config.LoadConfig("./internal/config")
 	db.InitDB()
+	db.Migrate()
 
 	fmt.Printf("Server will run on port: %s\n", config.AppConfig.Server.Port)
 	fmt.Printf("Database host: %s, port: %db\n",
@@ -0,0 +1,54 @@
+// Command bootstrap-admin promotes an existing user to admin so the first
+// admin can be created without one already in place. It refuses once any
+// admin exists unless -force is given; after that, admins manage roles
+// through PUT /admin/users/:id/role.
+package main
+
+import (
+	"M-AI/api/repository"
+	"M-AI/internal/config"
+	"M-AI/pkg/auth"
+	"M-AI/pkg/db"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+func main() {
+	email := flag.String("email", "", "email of the user to promote")
+	force := flag.Bool("force", false, "promote even if an admin already exists")
+	flag.Parse()
+
+	if *email == "" {
+		log.Fatal("Usage: bootstrap-admin -email user@example.com [-force]")
+	}
+
+	config.LoadConfig("./internal/config")
+	db.InitDB()
+	db.Migrate()
+
+	users := repository.NewAuthRepository()
+	err := db.TransactionExecutor(db.DB, func(tx *gorm.DB) error {
+		admins, err := users.CountByRole(tx, auth.RoleAdmin)
+		if err != nil {
+			return err
+		}
+		if admins > 0 && !*force {
+			return errors.New("an admin already exists; pass -force to promote another")
+		}
+
+		user, err := users.GetUserByEmail(tx, *email)
+		if err != nil {
+			return fmt.Errorf("%s: %w", *email, err)
+		}
+		return users.SetRole(tx, user.ID, auth.RoleAdmin)
+	})
+	if err != nil {
+		log.Fatalf("Failed to promote admin: %v", err)
+	}
+
+	log.Printf("Promoted %s to admin; they must log in again to pick up the role", *email)
+}
@@ -0,0 +1,254 @@
+// Command mock-oidc is a minimal OpenID Connect provider for trying single
+// sign-on locally. Its sign-in page asks for any email and name and signs
+// that user in without a password. It supports the authorization code flow
+// with PKCE (S256), which is all the API uses.
+//
+//	go run ./cmd/mock-oidc -addr :9400
+//
+// then open http://localhost:8080/api/v1/auth/oidc/mock with the "mock"
+// provider from config.yaml.
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"html/template"
+	"log"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const keyID = "mock"
+
+type grant struct {
+	clientID      string
+	redirectURI   string
+	challenge     string
+	nonce         string
+	email         string
+	name          string
+	emailVerified bool
+	expires       time.Time
+}
+
+type provider struct {
+	issuer       string
+	clientID     string
+	clientSecret string
+	key          *rsa.PrivateKey
+
+	mu     sync.Mutex
+	grants map[string]grant
+}
+
+func main() {
+	addr := flag.String("addr", ":9400", "address to listen on")
+	issuer := flag.String("issuer", "http://localhost:9400", "issuer URL, as configured in the API")
+	clientID := flag.String("client-id", "m-ai", "client ID the API uses")
+	clientSecret := flag.String("client-secret", "mock-secret", "client secret the API uses")
+	flag.Parse()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		log.Fatalf("Failed to generate signing key: %v", err)
+	}
+	p := &provider{
+		issuer:       *issuer,
+		clientID:     *clientID,
+		clientSecret: *clientSecret,
+		key:          key,
+		grants:       make(map[string]grant),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("GET /jwks", p.jwks)
+	mux.HandleFunc("GET /authorize", p.signInPage)
+	mux.HandleFunc("POST /authorize", p.authorize)
+	mux.HandleFunc("POST /token", p.token)
+
+	log.Printf("Mock OIDC provider %s listening on %s", *issuer, *addr)
+	log.Fatal(http.ListenAndServe(*addr, mux))
+}
+
+func (p *provider) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                p.issuer,
+		"authorization_endpoint":                p.issuer + "/authorize",
+		"token_endpoint":                        p.issuer + "/token",
+		"jwks_uri":                              p.issuer + "/jwks",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"scopes_supported":                      []string{"openid", "email", "profile"},
+	})
+}
+
+func (p *provider) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := p.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": keyID,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+var signInTemplate = template.Must(template.New("sign-in").Parse(`<!doctype html>
+<title>Mock SSO</title>
+<h1>Mock SSO sign-in</h1>
+<form method="post">
+	{{range $k, $v := .Query}}<input type="hidden" name="{{$k}}" value="{{index $v 0}}">{{end}}
+	<p><label>Email <input name="email" value="student@school.test" required></label></p>
+	<p><label>Name <input name="name" value="Test Student"></label></p>
+	<p><label><input type="checkbox" name="email_verified" value="true" checked> Email verified</label></p>
+	<p><button>Sign in</button> <button name="deny" value="1">Deny</button></p>
+</form>`))
+
+func (p *provider) signInPage(w http.ResponseWriter, r *http.Request) {
+	if err := p.checkAuthRequest(r.URL.Query()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	signInTemplate.Execute(w, map[string]any{"Query": r.URL.Query()})
+}
+
+func (p *provider) authorize(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	form := r.PostForm
+	if err := p.checkAuthRequest(form); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	redirect, _ := url.Parse(form.Get("redirect_uri"))
+	query := redirect.Query()
+	query.Set("state", form.Get("state"))
+	if form.Get("deny") != "" {
+		query.Set("error", "access_denied")
+	} else {
+		code := randomString()
+		p.mu.Lock()
+		p.grants[code] = grant{
+			clientID:      form.Get("client_id"),
+			redirectURI:   form.Get("redirect_uri"),
+			challenge:     form.Get("code_challenge"),
+			nonce:         form.Get("nonce"),
+			email:         form.Get("email"),
+			name:          form.Get("name"),
+			emailVerified: form.Get("email_verified") == "true",
+			expires:       time.Now().Add(time.Minute),
+		}
+		p.mu.Unlock()
+		query.Set("code", code)
+	}
+	redirect.RawQuery = query.Encode()
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *provider) checkAuthRequest(q url.Values) error {
+	switch {
+	case q.Get("client_id") != p.clientID:
+		return errors.New("unknown client_id")
+	case q.Get("response_type") != "code":
+		return errors.New("response_type must be code")
+	case q.Get("redirect_uri") == "":
+		return errors.New("redirect_uri is required")
+	case q.Get("code_challenge") == "" || q.Get("code_challenge_method") != "S256":
+		return errors.New("a PKCE S256 code_challenge is required")
+	}
+	return nil
+}
+
+func (p *provider) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		tokenError(w, "invalid_request")
+		return
+	}
+	clientID, secret, ok := r.BasicAuth()
+	if !ok {
+		clientID, secret = r.PostForm.Get("client_id"), r.PostForm.Get("client_secret")
+	}
+	if clientID != p.clientID || secret != p.clientSecret {
+		tokenError(w, "invalid_client")
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	p.mu.Lock()
+	g, ok := p.grants[code]
+	delete(p.grants, code)
+	p.mu.Unlock()
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	switch {
+	case r.PostForm.Get("grant_type") != "authorization_code",
+		!ok, time.Now().After(g.expires),
+		g.clientID != clientID,
+		g.redirectURI != r.PostForm.Get("redirect_uri"),
+		base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge:
+		tokenError(w, "invalid_grant")
+		return
+	}
+
+	now := time.Now()
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":            p.issuer,
+		"sub":            "mock-" + g.email,
+		"aud":            clientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"nonce":          g.nonce,
+		"email":          g.email,
+		"email_verified": g.emailVerified,
+		"name":           g.name,
+	})
+	idToken.Header["kid"] = keyID
+	signed, err := idToken.SignedString(p.key)
+	if err != nil {
+		tokenError(w, "server_error")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   300,
+		"id_token":     signed,
+	})
+}
+
+func tokenError(w http.ResponseWriter, code string) {
+	writeJSON(w, http.StatusBadRequest, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	buf := make([]byte, 24)
+	rand.Read(buf)
+	return base64.RawURLEncoding.EncodeToString(buf)
+}
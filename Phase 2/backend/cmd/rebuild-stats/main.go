@@ -0,0 +1,43 @@
+// Command rebuild-stats recomputes the dashboard totals in user_stats and
+// user_topic_stats from the attempt history, to backfill them or repair
+// drift. Pass -user to rebuild a single user.
+package main
+
+import (
+	"M-AI/api/repository"
+	"M-AI/internal/config"
+	"M-AI/pkg/db"
+	"flag"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+func main() {
+	userID := flag.Uint("user", 0, "rebuild only this user ID")
+	flag.Parse()
+
+	config.LoadConfig("./internal/config")
+	db.InitDB()
+	db.Migrate()
+
+	var target *uint
+	if *userID != 0 {
+		id := *userID
+		target = &id
+	}
+
+	stats := &repository.StatsRepository{}
+	err := db.TransactionExecutor(db.DB, func(tx *gorm.DB) error {
+		return stats.Rebuild(tx, target)
+	})
+	if err != nil {
+		log.Fatalf("Failed to rebuild stats: %v", err)
+	}
+
+	if target != nil {
+		log.Printf("Rebuilt stats for user %d", *target)
+	} else {
+		log.Println("Rebuilt stats for all users")
+	}
+}
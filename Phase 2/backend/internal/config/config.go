@@ -3,11 +3,16 @@ package config
 import (
 	"github.com/spf13/viper"
 	"log"
+	"time"
 )
 
 type Config struct {
 	Server struct {
 		Port string `mapstructure:"port"`
+		// TrustedProxies are the addresses or CIDRs of reverse proxies whose
+		// X-Forwarded-For header gives the client's IP. Leave it empty when
+		// clients connect directly, so they cannot pick their own IP.
+		TrustedProxies []string `mapstructure:"trusted_proxies"`
 	} `mapstructure:"server"`
 
 	Database struct {
@@ -22,11 +27,66 @@ type Config struct {
 	Auth struct {
 		SecretKey string `mapstructure:"secret_key"`
 		Salt      string `mapstructure:"salt"`
+		// Lifetimes of access tokens and of idle sessions, such as "15m" or
+		// "720h". Unset values fall back to the defaults in pkg/auth.
+		AccessTokenTTL  time.Duration `mapstructure:"access_token_ttl"`
+		RefreshTokenTTL time.Duration `mapstructure:"refresh_token_ttl"`
 	}
 
 	OpenAi struct {
 		ApiKey string `mapstructure:"api_key"`
 	}
+
+	// Mail configures outgoing email. Driver is smtp, file or log; the file
+	// and log drivers record messages instead of sending them. AppURL is the
+	// frontend address that links in emails point to.
+	Mail struct {
+		Driver   string `mapstructure:"driver"`
+		From     string `mapstructure:"from"`
+		Host     string `mapstructure:"host"`
+		Port     int    `mapstructure:"port"`
+		Username string `mapstructure:"username"`
+		Password string `mapstructure:"password"`
+		File     string `mapstructure:"file"`
+		AppURL   string `mapstructure:"app_url"`
+	} `mapstructure:"mail"`
+
+	// OIDC lists the single sign-on providers by the name used in
+	// /auth/oidc/:provider.
+	OIDC map[string]OIDCProvider `mapstructure:"oidc"`
+
+	// Achievements are badge definitions kept in the achievement table on
+	// startup, alongside any defined directly in the database.
+	Achievements []AchievementDefinition `mapstructure:"achievements"`
+}
+
+// OIDCProvider is an OpenID Connect provider users can sign in with. They
+// are matched to accounts by verified email; CreateUsers lets people without
+// an account sign up on their first login. AllowedDomains, if set, limits
+// which email domains may sign in. TrustEmail treats email as verified when
+// the provider does not say, which is only safe for providers that manage
+// their users' addresses, such as a school's own tenant.
+type OIDCProvider struct {
+	DisplayName    string   `mapstructure:"display_name"`
+	Issuer         string   `mapstructure:"issuer"`
+	ClientID       string   `mapstructure:"client_id"`
+	ClientSecret   string   `mapstructure:"client_secret"`
+	RedirectURL    string   `mapstructure:"redirect_url"`
+	Scopes         []string `mapstructure:"scopes"`
+	AllowedDomains []string `mapstructure:"allowed_domains"`
+	CreateUsers    bool     `mapstructure:"create_users"`
+	TrustEmail     bool     `mapstructure:"trust_email"`
+}
+
+type AchievementDefinition struct {
+	Code        string `mapstructure:"code"`
+	Name        string `mapstructure:"name"`
+	Description string `mapstructure:"description"`
+	Icon        string `mapstructure:"icon"`
+	Event       string `mapstructure:"event"`
+	Metric      string `mapstructure:"metric"`
+	Threshold   int    `mapstructure:"threshold"`
+	Topic       string `mapstructure:"topic"`
 }
 
 var AppConfig Config
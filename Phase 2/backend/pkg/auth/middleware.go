@@ -4,35 +4,109 @@ import (
 	"errors"
 	"github.com/gin-gonic/gin"
 	"net/http"
+	"strings"
 )
 
 type ContextKey string
 
 const (
-	UserIDKey ContextKey = "user_id"
-	RoleKey   ContextKey = "role"
+	UserIDKey    ContextKey = "user_id"
+	PrincipalKey ContextKey = "principal"
 )
 
+var ErrNoCredentials = errors.New("no credentials")
+
+// AuthMiddleware authenticates the caller with an access token or API key,
+// sent in an "Authorization: Bearer" header or, for access tokens, the token
+// cookie. API keys are refused for requests their scopes do not cover.
 func AuthMiddleware(secretKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		token, err := c.Cookie("token")
-		if err != nil {
+		p, err := Authenticate(c, secretKey)
+		if errors.Is(err, ErrNoCredentials) {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Missing authentication token"})
 			c.Abort()
 			return
 		}
-
-		userID, err := ExtractUserID(token, secretKey)
 		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired token"})
 			c.Abort()
 			return
 		}
-		c.Set("user_id", userID)
+		if !p.Allows(c.Request.Method) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "This API key needs the " + requiredScope(c.Request.Method) + " scope"})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
 
+// Authenticate returns the caller's principal and remembers it on the
+// context, so later middleware does not repeat the work.
+func Authenticate(c *gin.Context, secretKey string) (Principal, error) {
+	if p, ok := GetPrincipal(c); ok {
+		return p, nil
+	}
+
+	token := bearerToken(c)
+	if token == "" {
+		token, _ = c.Cookie("token")
+	}
+
+	var p Principal
+	switch {
+	case token == "":
+		return p, ErrNoCredentials
+	case strings.HasPrefix(token, APIKeyPrefix):
+		if apiKeys == nil {
+			return p, errors.New("API keys are not enabled")
+		}
+		var err error
+		if p, err = apiKeys.ResolveAPIKey(token); err != nil {
+			return p, err
+		}
+	default:
+		userID, role, sessionID, err := ExtractSession(token, secretKey)
+		if err != nil {
+			return p, err
+		}
+		p = Principal{UserID: uint(userID), Role: role, SessionID: sessionID}
+	}
+
+	c.Set(string(PrincipalKey), p)
+	return p, nil
+}
+
+// Identify returns the caller's user ID without requiring authentication, so
+// it can be used by middleware that runs before AuthMiddleware.
+func Identify(c *gin.Context, secretKey string) (uint, bool) {
+	p, err := Authenticate(c, secretKey)
+	if err != nil {
+		return 0, false
+	}
+	return p.UserID, true
+}
+
+// RequireSession refuses API keys, for routes that manage the caller's
+// credentials. It must run after AuthMiddleware.
+func RequireSession() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if p, _ := GetPrincipal(c); p.IsAPIKey() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "API keys cannot be used here"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetSessionID returns the session the caller's access token belongs to, or
+// zero for API keys and tokens that predate session tracking.
+func GetSessionID(c *gin.Context) uint {
+	p, _ := GetPrincipal(c)
+	return p.SessionID
+}
+
 func GetUserID(r *http.Request) (string, error) {
 	userID, ok := r.Context().Value(UserIDKey).(string)
 	if !ok {
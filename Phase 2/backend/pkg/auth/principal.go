@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// API key scopes. Read covers GET and HEAD requests, write everything else.
+const (
+	ScopeRead  = "read"
+	ScopeWrite = "write"
+)
+
+var Scopes = []string{ScopeRead, ScopeWrite}
+
+// APIKeyPrefix starts every API key, so they can be told apart from access
+// tokens in an Authorization header.
+const APIKeyPrefix = "mai_"
+
+// Principal is the authenticated caller of a request.
+type Principal struct {
+	UserID uint
+	Role   string
+	// SessionID is the session an access token was issued for, or zero for
+	// API keys and tokens that predate session tracking.
+	SessionID uint
+	// APIKeyID is set when the caller authenticated with an API key, whose
+	// scopes limit what it may do.
+	APIKeyID uint
+	Scopes   []string
+}
+
+func (p Principal) IsAPIKey() bool {
+	return p.APIKeyID != 0
+}
+
+// Allows reports whether the principal may make a request with method.
+// Access tokens allow everything their role does; API keys only what their
+// scopes cover.
+func (p Principal) Allows(method string) bool {
+	if !p.IsAPIKey() {
+		return true
+	}
+	return slices.Contains(p.Scopes, requiredScope(method))
+}
+
+func requiredScope(method string) string {
+	if method == http.MethodGet || method == http.MethodHead {
+		return ScopeRead
+	}
+	return ScopeWrite
+}
+
+// GetPrincipal returns the caller AuthMiddleware authenticated.
+func GetPrincipal(c *gin.Context) (Principal, bool) {
+	p, ok := c.Get(string(PrincipalKey))
+	if !ok {
+		return Principal{}, false
+	}
+	principal, ok := p.(Principal)
+	return principal, ok
+}
+
+// APIKeyResolver looks up the principal an API key authenticates, failing
+// for unknown, expired and revoked keys.
+type APIKeyResolver interface {
+	ResolveAPIKey(key string) (Principal, error)
+}
+
+var apiKeys APIKeyResolver
+
+// UseAPIKeys enables API key authentication in AuthMiddleware.
+func UseAPIKeys(resolver APIKeyResolver) {
+	apiKeys = resolver
+}
+
+// bearerToken returns the credential in an "Authorization: Bearer" header.
+func bearerToken(c *gin.Context) string {
+	scheme, token, ok := strings.Cut(c.GetHeader("Authorization"), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(token)
+}
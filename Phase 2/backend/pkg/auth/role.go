@@ -0,0 +1,70 @@
+package auth
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	RoleStudent = "student"
+	RoleTeacher = "teacher"
+	RoleAdmin   = "admin"
+)
+
+var Roles = []string{RoleStudent, RoleTeacher, RoleAdmin}
+
+func IsValidRole(role string) bool {
+	return slices.Contains(Roles, role)
+}
+
+// Permission is something a route or service needs the caller's role to
+// allow. Routes should require permissions rather than naming roles, so the
+// policy below is the one place that decides who can do what.
+type Permission string
+
+const (
+	CreateContent   Permission = "content:create"
+	ManageResources Permission = "resources:manage"
+	ViewAnalytics   Permission = "analytics:view"
+	ManageUsers     Permission = "users:manage"
+	ManageClasses   Permission = "classes:manage"
+)
+
+var policy = map[Permission][]string{
+	CreateContent:   {RoleTeacher, RoleAdmin},
+	ManageResources: {RoleAdmin},
+	ViewAnalytics:   {RoleTeacher, RoleAdmin},
+	ManageUsers:     {RoleAdmin},
+	ManageClasses:   {RoleTeacher, RoleAdmin},
+}
+
+func Can(role string, permission Permission) bool {
+	return slices.Contains(policy[permission], role)
+}
+
+// GetRole returns the role of the caller AuthMiddleware authenticated.
+func GetRole(c *gin.Context) string {
+	p, _ := GetPrincipal(c)
+	return p.Role
+}
+
+// RequireRole lets the request through only if the caller has one of roles.
+// It must run after AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !slices.Contains(roles, GetRole(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "You do not have permission to do this"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// Require lets the request through only if the caller's role grants
+// permission.
+func Require(permission Permission) gin.HandlerFunc {
+	return RequireRole(policy[permission]...)
+}
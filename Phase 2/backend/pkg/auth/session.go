@@ -0,0 +1,38 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+)
+
+// NewToken returns a random token, for refresh tokens and links sent by
+// email, and the hash to store for it. The token itself is only ever given to
+// the client.
+func NewToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashToken(token), nil
+}
+
+// HashToken hashes a random token for storage. Tokens carry enough entropy
+// that a fast hash is sufficient, and it lets them be looked up by hash.
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// NewAPIKey returns a random API key, the hash to store for it and the
+// prefix to show in place of it.
+func NewAPIKey() (key, hash, prefix string, err error) {
+	token, _, err := NewToken()
+	if err != nil {
+		return "", "", "", err
+	}
+	key = APIKeyPrefix + token
+	return key, HashToken(key), key[:len(APIKeyPrefix)+8], nil
+}
@@ -6,12 +6,21 @@ import (
 	"time"
 )
 
-const TokenExpiryDuration = time.Hour * 24
+const (
+	// AccessTokenTTL and RefreshTokenTTL are used when the config leaves the
+	// lifetimes unset.
+	AccessTokenTTL  = 15 * time.Minute
+	RefreshTokenTTL = 30 * 24 * time.Hour
+)
 
-func GenerateToken(userID uint, secretKey string) (string, error) {
+// GenerateToken issues an access token for a session. Access tokens are
+// short-lived; clients renew them with the session's refresh token.
+func GenerateToken(userID uint, role string, sessionID uint, ttl time.Duration, secretKey string) (string, error) {
 	claims := jwt.MapClaims{
 		"user_id": userID,
-		"exp":     time.Now().Add(TokenExpiryDuration).Unix(),
+		"role":    role,
+		"sid":     sessionID,
+		"exp":     time.Now().Add(ttl).Unix(),
 		"iat":     time.Now().Unix(),
 	}
 
@@ -45,15 +54,64 @@ func ValidateToken(tokenString string, secretKey string) (*jwt.MapClaims, error)
 }
 
 func ExtractUserID(tokenString, secretKey string) (float64, error) {
+	userID, _, err := ExtractUser(tokenString, secretKey)
+	return userID, err
+}
+
+// ExtractUser returns the user ID and role in a token. Tokens issued before
+// roles were added carry none and are treated as students.
+func ExtractUser(tokenString, secretKey string) (float64, string, error) {
+	userID, role, _, err := ExtractSession(tokenString, secretKey)
+	return userID, role, err
+}
+
+// ExtractSession is ExtractUser that also returns the session the token was
+// issued for, or zero for tokens issued before sessions were tracked.
+func ExtractSession(tokenString, secretKey string) (float64, string, uint, error) {
 	claims, err := ValidateToken(tokenString, secretKey)
 	if err != nil {
-		return 0, err
+		return 0, "", 0, err
 	}
 
 	userID, ok := (*claims)["user_id"].(float64)
 	if !ok {
-		return 0, errors.New("user_id not found in token")
+		return 0, "", 0, errors.New("user_id not found in token")
 	}
 
-	return userID, nil
+	role, _ := (*claims)["role"].(string)
+	if !IsValidRole(role) {
+		role = RoleStudent
+	}
+
+	sessionID, _ := (*claims)["sid"].(float64)
+	return userID, role, uint(sessionID), nil
+}
+
+// GenerateMFAToken issues the token a user who passed their password brings
+// to the second sign-in step. It carries no user_id claim, so it cannot be
+// used as an access token. setup marks a user who must enrol first.
+func GenerateMFAToken(userID uint, setup bool, ttl time.Duration, secretKey string) (string, error) {
+	claims := jwt.MapClaims{
+		"mfa_user": userID,
+		"setup":    setup,
+		"exp":      time.Now().Add(ttl).Unix(),
+		"iat":      time.Now().Unix(),
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+}
+
+// ExtractMFAToken returns the user and setup flag of a token from
+// GenerateMFAToken.
+func ExtractMFAToken(tokenString, secretKey string) (uint, bool, error) {
+	claims, err := ValidateToken(tokenString, secretKey)
+	if err != nil {
+		return 0, false, err
+	}
+
+	userID, ok := (*claims)["mfa_user"].(float64)
+	if !ok {
+		return 0, false, errors.New("mfa_user not found in token")
+	}
+	setup, _ := (*claims)["setup"].(bool)
+	return uint(userID), setup, nil
 }
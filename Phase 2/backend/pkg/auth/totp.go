@@ -0,0 +1,134 @@
+package auth
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"github.com/pquerna/otp"
+	"github.com/pquerna/otp/totp"
+	"image/png"
+	"strings"
+	"time"
+)
+
+// totpPeriod is the standard 30 second step authenticator apps use.
+const totpPeriod = 30
+
+var totpOpts = totp.ValidateOpts{Period: totpPeriod, Digits: otp.DigitsSix, Algorithm: otp.AlgorithmSHA1}
+
+// NewTOTPKey generates a TOTP secret for account. Its URL is the otpauth://
+// provisioning URI authenticator apps read from the QR code.
+func NewTOTPKey(issuer, account string) (*otp.Key, error) {
+	return totp.Generate(totp.GenerateOpts{
+		Issuer:      issuer,
+		AccountName: account,
+		Period:      totpPeriod,
+		Digits:      otp.DigitsSix,
+		Algorithm:   otp.AlgorithmSHA1,
+	})
+}
+
+// QRCode renders a provisioning URI as a PNG data URI, for an <img> tag.
+func QRCode(key *otp.Key, size int) (string, error) {
+	img, err := key.Image(size, size)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return "", err
+	}
+	return "data:image/png;base64," + base64.StdEncoding.EncodeToString(buf.Bytes()), nil
+}
+
+// ValidateTOTP checks a code against secret, allowing a step of clock drift
+// either way. It returns the time step the code belongs to, so callers can
+// refuse a code that has already been used.
+func ValidateTOTP(secret, code string, now time.Time) (int64, bool) {
+	code = strings.TrimSpace(code)
+	step := now.Unix() / totpPeriod
+	for _, offset := range []int64{0, -1, 1} {
+		at := time.Unix((step+offset)*totpPeriod, 0)
+		expected, err := totp.GenerateCodeCustom(secret, at, totpOpts)
+		if err != nil {
+			return 0, false
+		}
+		if subtle.ConstantTimeCompare([]byte(expected), []byte(code)) == 1 {
+			return step + offset, true
+		}
+	}
+	return 0, false
+}
+
+var recoveryEncoding = base32.NewEncoding("abcdefghijklmnopqrstuvwxyz234567").WithPadding(base32.NoPadding)
+
+// NewRecoveryCodes returns n one-time codes, formatted as "xxxxx-xxxxx".
+func NewRecoveryCodes(n int) ([]string, error) {
+	codes := make([]string, n)
+	buf := make([]byte, 7)
+	for i := range codes {
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		code := recoveryEncoding.EncodeToString(buf)[:10]
+		codes[i] = code[:5] + "-" + code[5:]
+	}
+	return codes, nil
+}
+
+// HashRecoveryCode hashes a recovery code for storage. Codes are short, so
+// they are keyed with the server's secret rather than hashed plainly, and
+// normalised so dashes, spaces and case do not matter.
+func HashRecoveryCode(code, secretKey string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	h := hmac.New(sha256.New, []byte(secretKey))
+	h.Write([]byte("recovery:" + code))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// EncryptSecret encrypts a TOTP secret for storage with a key derived from
+// the server's secret, so a copy of the database alone cannot generate codes.
+func EncryptSecret(secret, secretKey string) (string, error) {
+	gcm, err := secretCipher(secretKey)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(gcm.Seal(nonce, nonce, []byte(secret), nil)), nil
+}
+
+func DecryptSecret(sealed, secretKey string) (string, error) {
+	gcm, err := secretCipher(secretKey)
+	if err != nil {
+		return "", err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("sealed secret is too short")
+	}
+	secret, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	return string(secret), err
+}
+
+func secretCipher(secretKey string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("totp:" + secretKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
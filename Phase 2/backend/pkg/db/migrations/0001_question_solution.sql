@@ -0,0 +1,2 @@
+-- Worked solutions printed on quiz answer sheets.
+ALTER TABLE question ADD COLUMN IF NOT EXISTS solution TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,9 @@
+-- Practice problems carry a level and a gradeable answer.
+ALTER TABLE problem ADD COLUMN IF NOT EXISTS level TEXT NOT NULL DEFAULT 'beginner';
+ALTER TABLE problem ADD COLUMN IF NOT EXISTS answer TEXT NOT NULL DEFAULT '';
+ALTER TABLE problem ADD COLUMN IF NOT EXISTS answer_type TEXT NOT NULL DEFAULT 'exact';
+ALTER TABLE problem ADD COLUMN IF NOT EXISTS tolerance DOUBLE PRECISION NOT NULL DEFAULT 0;
+ALTER TABLE problem ADD COLUMN IF NOT EXISTS solution TEXT NOT NULL DEFAULT '';
+
+CREATE INDEX IF NOT EXISTS idx_problem_topic_level ON problem (topic, level) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_user_log_user_problem ON user_log (user_id, problem_id) WHERE problem_id IS NOT NULL;
@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS daily_challenge (
+	id             BIGSERIAL PRIMARY KEY,
+	created_at     TIMESTAMPTZ,
+	updated_at     TIMESTAMPTZ,
+	deleted_at     TIMESTAMPTZ,
+	challenge_date DATE NOT NULL,
+	level          TEXT NOT NULL,
+	problem_id     BIGINT NOT NULL REFERENCES problem (id),
+	UNIQUE (challenge_date, level)
+);
+
+CREATE TABLE IF NOT EXISTS daily_challenge_attempt (
+	id           BIGSERIAL PRIMARY KEY,
+	created_at   TIMESTAMPTZ,
+	updated_at   TIMESTAMPTZ,
+	deleted_at   TIMESTAMPTZ,
+	challenge_id BIGINT NOT NULL REFERENCES daily_challenge (id),
+	user_id      BIGINT NOT NULL,
+	correct      BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_daily_challenge_attempt_challenge ON daily_challenge_attempt (challenge_id, user_id);
+
+CREATE TABLE IF NOT EXISTS daily_streak (
+	user_id           BIGINT PRIMARY KEY,
+	current           INTEGER NOT NULL DEFAULT 0,
+	longest           INTEGER NOT NULL DEFAULT 0,
+	last_attempt_date DATE,
+	updated_at        TIMESTAMPTZ
+);
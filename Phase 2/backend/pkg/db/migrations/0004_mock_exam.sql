@@ -0,0 +1,73 @@
+CREATE TABLE IF NOT EXISTS mock_exam (
+	id                 BIGSERIAL PRIMARY KEY,
+	created_at         TIMESTAMPTZ,
+	updated_at         TIMESTAMPTZ,
+	deleted_at         TIMESTAMPTZ,
+	title              TEXT NOT NULL,
+	description        TEXT NOT NULL DEFAULT '',
+	tier               TEXT NOT NULL,
+	paper              TEXT NOT NULL,
+	time_limit_minutes INTEGER NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS mock_exam_question (
+	id                   BIGSERIAL PRIMARY KEY,
+	created_at           TIMESTAMPTZ,
+	updated_at           TIMESTAMPTZ,
+	deleted_at           TIMESTAMPTZ,
+	exam_id              BIGINT NOT NULL REFERENCES mock_exam (id),
+	position             INTEGER NOT NULL,
+	question             TEXT NOT NULL,
+	answer               TEXT NOT NULL,
+	answer_type          TEXT NOT NULL DEFAULT 'exact',
+	tolerance            DOUBLE PRECISION NOT NULL DEFAULT 0,
+	mark_scheme          TEXT NOT NULL DEFAULT '',
+	marks                INTEGER NOT NULL,
+	assessment_objective TEXT NOT NULL,
+	topic                topic_enum NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_mock_exam_question_exam ON mock_exam_question (exam_id, position);
+
+CREATE TABLE IF NOT EXISTS mock_exam_boundary (
+	id         BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	deleted_at TIMESTAMPTZ,
+	exam_id    BIGINT NOT NULL REFERENCES mock_exam (id),
+	grade      INTEGER NOT NULL,
+	min_mark   INTEGER NOT NULL,
+	UNIQUE (exam_id, grade)
+);
+
+CREATE TABLE IF NOT EXISTS mock_exam_attempt (
+	id           BIGSERIAL PRIMARY KEY,
+	created_at   TIMESTAMPTZ,
+	updated_at   TIMESTAMPTZ,
+	deleted_at   TIMESTAMPTZ,
+	exam_id      BIGINT NOT NULL REFERENCES mock_exam (id),
+	user_id      BIGINT NOT NULL,
+	started_at   TIMESTAMPTZ NOT NULL,
+	deadline     TIMESTAMPTZ NOT NULL,
+	submitted_at TIMESTAMPTZ,
+	raw_mark     INTEGER NOT NULL DEFAULT 0,
+	total_marks  INTEGER NOT NULL DEFAULT 0,
+	grade        INTEGER NOT NULL DEFAULT 0,
+	over_time    BOOLEAN NOT NULL DEFAULT FALSE
+);
+
+CREATE INDEX IF NOT EXISTS idx_mock_exam_attempt_user ON mock_exam_attempt (user_id, exam_id);
+
+CREATE TABLE IF NOT EXISTS mock_exam_answer (
+	id            BIGSERIAL PRIMARY KEY,
+	created_at    TIMESTAMPTZ,
+	updated_at    TIMESTAMPTZ,
+	deleted_at    TIMESTAMPTZ,
+	attempt_id    BIGINT NOT NULL REFERENCES mock_exam_attempt (id),
+	question_id   BIGINT NOT NULL REFERENCES mock_exam_question (id),
+	answer        TEXT NOT NULL DEFAULT '',
+	marks_awarded INTEGER NOT NULL DEFAULT 0,
+	feedback      TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX IF NOT EXISTS idx_mock_exam_answer_attempt ON mock_exam_answer (attempt_id);
@@ -0,0 +1,35 @@
+CREATE TABLE IF NOT EXISTS topic_mastery (
+	user_id         BIGINT NOT NULL,
+	topic           topic_enum NOT NULL,
+	probability     DOUBLE PRECISION NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	last_attempt_at TIMESTAMPTZ,
+	updated_at      TIMESTAMPTZ,
+	PRIMARY KEY (user_id, topic)
+);
+
+-- Seed from existing answers with a smoothed accuracy; new answers are traced
+-- incrementally from here on.
+INSERT INTO topic_mastery (user_id, topic, probability, attempts, last_attempt_at, updated_at)
+SELECT
+	user_id,
+	topic,
+	(SUM(correct) + 0.2) / (COUNT(*) + 1),
+	COUNT(*),
+	MAX(created_at),
+	NOW()
+FROM (
+	SELECT ul.user_id, q.topic, CASE WHEN ul.correct_answer THEN 1 ELSE 0 END AS correct, ul.created_at
+	FROM user_log ul
+	JOIN question q ON ul.question_id = q.id
+	WHERE ul.from_quiz = TRUE
+
+	UNION ALL
+
+	SELECT ul.user_id, p.topic, CASE WHEN ul.correct_answer THEN 1 ELSE 0 END AS correct, ul.created_at
+	FROM user_log ul
+	JOIN problem p ON ul.problem_id = p.id
+	WHERE ul.from_quiz = FALSE
+) AS combined
+GROUP BY user_id, topic
+ON CONFLICT (user_id, topic) DO NOTHING;
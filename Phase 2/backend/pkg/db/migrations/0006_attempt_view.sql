@@ -0,0 +1,62 @@
+-- attempt is the single source for answer analytics: one row per graded
+-- answer, whatever it was answered in. Marks are 1 for quiz questions and
+-- problems so accuracy is SUM(marks_awarded) / SUM(marks_available)
+-- everywhere, and mock exam answers count by their marks.
+CREATE OR REPLACE VIEW attempt AS
+SELECT
+	'quiz'::text AS source,
+	ul.user_id,
+	q.topic::text AS topic,
+	qz.level::text AS level,
+	ul.correct_answer AS correct,
+	CASE WHEN ul.correct_answer THEN 1 ELSE 0 END AS marks_awarded,
+	1 AS marks_available,
+	qz.id AS quiz_id,
+	q.id AS question_id,
+	NULL::bigint AS problem_id,
+	NULL::bigint AS mock_exam_attempt_id,
+	ul.created_at
+FROM user_log ul
+JOIN question q ON ul.question_id = q.id
+JOIN quiz qz ON q.quiz_id = qz.id
+WHERE ul.from_quiz = TRUE AND ul.deleted_at IS NULL
+
+UNION ALL
+
+SELECT
+	'problem'::text AS source,
+	ul.user_id,
+	p.topic::text AS topic,
+	p.level::text AS level,
+	ul.correct_answer AS correct,
+	CASE WHEN ul.correct_answer THEN 1 ELSE 0 END AS marks_awarded,
+	1 AS marks_available,
+	NULL::bigint AS quiz_id,
+	NULL::bigint AS question_id,
+	p.id AS problem_id,
+	NULL::bigint AS mock_exam_attempt_id,
+	ul.created_at
+FROM user_log ul
+JOIN problem p ON ul.problem_id = p.id
+WHERE ul.from_quiz = FALSE AND ul.deleted_at IS NULL
+
+UNION ALL
+
+SELECT
+	'mock_exam'::text AS source,
+	mat.user_id,
+	meq.topic::text AS topic,
+	CASE WHEN me.tier = 'higher' THEN 'advanced' ELSE 'intermediate' END AS level,
+	mea.marks_awarded >= meq.marks AS correct,
+	mea.marks_awarded,
+	meq.marks AS marks_available,
+	NULL::bigint AS quiz_id,
+	NULL::bigint AS question_id,
+	NULL::bigint AS problem_id,
+	mat.id AS mock_exam_attempt_id,
+	mat.submitted_at AS created_at
+FROM mock_exam_answer mea
+JOIN mock_exam_attempt mat ON mea.attempt_id = mat.id
+JOIN mock_exam_question meq ON mea.question_id = meq.id
+JOIN mock_exam me ON mat.exam_id = me.id
+WHERE mat.submitted_at IS NOT NULL AND meq.marks > 0 AND mea.deleted_at IS NULL;
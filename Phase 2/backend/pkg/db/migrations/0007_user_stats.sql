@@ -0,0 +1,32 @@
+-- Running totals behind the dashboard, kept up to date in the same
+-- transaction as the answers they count. cmd/rebuild-stats recomputes them
+-- from the attempt view and quiz_log.
+CREATE TABLE IF NOT EXISTS user_topic_stats (
+	user_id         BIGINT NOT NULL,
+	topic           TEXT NOT NULL,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	marks_awarded   INTEGER NOT NULL DEFAULT 0,
+	marks_available INTEGER NOT NULL DEFAULT 0,
+	last_attempt_at TIMESTAMPTZ,
+	updated_at      TIMESTAMPTZ,
+	PRIMARY KEY (user_id, topic)
+);
+
+CREATE TABLE IF NOT EXISTS user_stats (
+	user_id           BIGINT PRIMARY KEY,
+	quizzes_completed INTEGER NOT NULL DEFAULT 0,
+	updated_at        TIMESTAMPTZ
+);
+
+INSERT INTO user_topic_stats (user_id, topic, attempts, marks_awarded, marks_available, last_attempt_at, updated_at)
+SELECT user_id, topic, COUNT(*), SUM(marks_awarded), SUM(marks_available), MAX(created_at), NOW()
+FROM attempt
+GROUP BY user_id, topic
+ON CONFLICT (user_id, topic) DO NOTHING;
+
+INSERT INTO user_stats (user_id, quizzes_completed, updated_at)
+SELECT user_id, COUNT(*), NOW()
+FROM quiz_log
+WHERE deleted_at IS NULL
+GROUP BY user_id
+ON CONFLICT (user_id) DO NOTHING;
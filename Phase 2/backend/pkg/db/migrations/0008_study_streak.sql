@@ -0,0 +1,23 @@
+-- Study goal and timezone live on the profile; a study day is a local day on
+-- which the goal was met.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone TEXT NOT NULL DEFAULT 'UTC';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS daily_goal_type TEXT NOT NULL DEFAULT 'questions';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS daily_goal_target INTEGER NOT NULL DEFAULT 10;
+
+-- Outgoing notifications waiting to be delivered. dedupe_key stops a job
+-- that runs repeatedly from queueing the same reminder twice.
+CREATE TABLE IF NOT EXISTS notification (
+	id         BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	deleted_at TIMESTAMPTZ,
+	user_id    BIGINT NOT NULL,
+	kind       TEXT NOT NULL,
+	title      TEXT NOT NULL,
+	body       TEXT NOT NULL DEFAULT '',
+	dedupe_key TEXT NOT NULL,
+	sent_at    TIMESTAMPTZ,
+	UNIQUE (user_id, kind, dedupe_key)
+);
+
+CREATE INDEX IF NOT EXISTS idx_notification_pending ON notification (created_at) WHERE sent_at IS NULL;
@@ -0,0 +1,34 @@
+-- Badge definitions. A rule awards the badge once metric reaches threshold
+-- after event (empty for any event); topic optionally narrows the metric.
+CREATE TABLE IF NOT EXISTS achievement (
+	id          BIGSERIAL PRIMARY KEY,
+	created_at  TIMESTAMPTZ,
+	updated_at  TIMESTAMPTZ,
+	deleted_at  TIMESTAMPTZ,
+	code        TEXT NOT NULL UNIQUE,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	icon        TEXT NOT NULL DEFAULT '',
+	event       TEXT NOT NULL DEFAULT '',
+	metric      TEXT NOT NULL,
+	threshold   INTEGER NOT NULL,
+	topic       TEXT NOT NULL DEFAULT '',
+	active      BOOLEAN NOT NULL DEFAULT TRUE
+);
+
+CREATE TABLE IF NOT EXISTS user_achievement (
+	id             BIGSERIAL PRIMARY KEY,
+	created_at     TIMESTAMPTZ,
+	updated_at     TIMESTAMPTZ,
+	deleted_at     TIMESTAMPTZ,
+	user_id        BIGINT NOT NULL,
+	achievement_id BIGINT NOT NULL REFERENCES achievement (id),
+	UNIQUE (user_id, achievement_id)
+);
+
+INSERT INTO achievement (created_at, updated_at, code, name, description, icon, event, metric, threshold, topic)
+VALUES
+	(NOW(), NOW(), 'first-perfect-quiz', 'First perfect quiz', 'Score 100% on a quiz', 'star', 'quiz_completed', 'quiz_score', 100, ''),
+	(NOW(), NOW(), 'algebra-run-10', 'Algebra ace', 'Answer 10 Algebra questions right in a row', 'bolt', '', 'correct_streak', 10, 'Algebra'),
+	(NOW(), NOW(), 'study-streak-7', 'Week warrior', 'Reach a 7-day study streak', 'flame', '', 'study_streak', 7, '')
+ON CONFLICT (code) DO NOTHING;
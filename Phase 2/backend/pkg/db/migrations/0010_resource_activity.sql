@@ -0,0 +1,11 @@
+-- Which resources each user has opened, used to avoid recommending the same
+-- material again.
+CREATE TABLE IF NOT EXISTS resource_activity (
+	user_id        BIGINT NOT NULL,
+	resource_id    BIGINT NOT NULL REFERENCES resources (id),
+	open_count     INTEGER NOT NULL DEFAULT 0,
+	last_opened_at TIMESTAMPTZ,
+	PRIMARY KEY (user_id, resource_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_resource_topic ON resources USING GIN (topic);
@@ -0,0 +1,9 @@
+-- Link health from the background checker. A resource is hidden from students
+-- once its link has failed several checks in a row.
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS link_status INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS link_error TEXT NOT NULL DEFAULT '';
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS link_failures INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS link_broken BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS link_checked_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_resources_link_checked ON resources (link_checked_at NULLS FIRST) WHERE deleted_at IS NULL;
@@ -0,0 +1,9 @@
+-- Bookmarks and progress through resources. first_opened_at is backfilled
+-- from the last open, the best record we have for existing rows.
+ALTER TABLE resource_activity ADD COLUMN IF NOT EXISTS first_opened_at TIMESTAMPTZ;
+ALTER TABLE resource_activity ADD COLUMN IF NOT EXISTS saved_at TIMESTAMPTZ;
+ALTER TABLE resource_activity ADD COLUMN IF NOT EXISTS completed_at TIMESTAMPTZ;
+
+UPDATE resource_activity SET first_opened_at = last_opened_at WHERE first_opened_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_resource_activity_saved ON resource_activity (user_id, saved_at DESC) WHERE saved_at IS NOT NULL;
@@ -0,0 +1,27 @@
+-- Weighted full-text search. Titles rank above descriptions, which rank above
+-- body text; the vectors are generated so they never go stale.
+ALTER TABLE quiz ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(description, '')), 'B')
+) STORED;
+
+ALTER TABLE question ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', coalesce(question, '')), 'C') ||
+	setweight(to_tsvector('english', coalesce(solution, '')), 'D')
+) STORED;
+
+ALTER TABLE problem ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(question, '')), 'C')
+) STORED;
+
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('english', coalesce(title, '')), 'A') ||
+	setweight(to_tsvector('english', coalesce(description, '')), 'B') ||
+	setweight(to_tsvector('english', coalesce(link_description, '')), 'C')
+) STORED;
+
+CREATE INDEX IF NOT EXISTS idx_quiz_search ON quiz USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_question_search ON question USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_problem_search ON problem USING GIN (search_vector);
+CREATE INDEX IF NOT EXISTS idx_resources_search ON resources USING GIN (search_vector);
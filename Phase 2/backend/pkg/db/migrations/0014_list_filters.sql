@@ -0,0 +1,11 @@
+-- Who created each piece of content, for the owner filter on lists. Content
+-- that predates this, or was generated by the system, has no owner.
+ALTER TABLE quiz ADD COLUMN IF NOT EXISTS created_by BIGINT REFERENCES users (id);
+ALTER TABLE mock_exam ADD COLUMN IF NOT EXISTS created_by BIGINT REFERENCES users (id);
+ALTER TABLE resources ADD COLUMN IF NOT EXISTS created_by BIGINT REFERENCES users (id);
+
+-- Keyset pagination walks lists by (sort column, id).
+CREATE INDEX IF NOT EXISTS idx_quiz_created ON quiz (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_problem_created ON problem (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_mock_exam_created ON mock_exam (created_at, id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_resources_created ON resources (created_at, id) WHERE deleted_at IS NULL;
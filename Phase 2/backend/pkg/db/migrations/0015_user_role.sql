@@ -0,0 +1,7 @@
+-- Roles gate content creation, resource administration and analytics. Every
+-- existing user starts as a student; promote the first admin with
+-- cmd/bootstrap-admin.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'student'
+	CHECK (role IN ('student', 'teacher', 'admin'));
+
+CREATE INDEX IF NOT EXISTS idx_users_role ON users (role) WHERE deleted_at IS NULL AND role <> 'student';
@@ -0,0 +1,22 @@
+-- Classes group students under one or more teachers. The owner is always
+-- listed as a teacher member too, so membership checks need only one table.
+CREATE TABLE IF NOT EXISTS classroom (
+	id          BIGSERIAL PRIMARY KEY,
+	created_at  TIMESTAMPTZ,
+	updated_at  TIMESTAMPTZ,
+	deleted_at  TIMESTAMPTZ,
+	name        TEXT NOT NULL,
+	description TEXT NOT NULL DEFAULT '',
+	owner_id    BIGINT NOT NULL REFERENCES users (id),
+	join_code   TEXT NOT NULL UNIQUE
+);
+
+CREATE TABLE IF NOT EXISTS classroom_member (
+	classroom_id BIGINT NOT NULL REFERENCES classroom (id),
+	user_id      BIGINT NOT NULL REFERENCES users (id),
+	role         TEXT NOT NULL CHECK (role IN ('teacher', 'student')),
+	joined_at    TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (classroom_id, user_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_classroom_member_user ON classroom_member (user_id, role);
@@ -0,0 +1,20 @@
+-- A quiz set for a class between open_at and due_at. Submissions from
+-- open_at onwards count towards the assignment; those after due_at are late.
+-- A NULL max_attempts allows any number of attempts.
+CREATE TABLE IF NOT EXISTS quiz_assignment (
+	id           BIGSERIAL PRIMARY KEY,
+	created_at   TIMESTAMPTZ,
+	updated_at   TIMESTAMPTZ,
+	deleted_at   TIMESTAMPTZ,
+	classroom_id BIGINT NOT NULL REFERENCES classroom (id),
+	quiz_id      BIGINT NOT NULL REFERENCES quiz (id),
+	assigned_by  BIGINT NOT NULL REFERENCES users (id),
+	open_at      TIMESTAMPTZ NOT NULL,
+	due_at       TIMESTAMPTZ NOT NULL,
+	max_attempts INTEGER CHECK (max_attempts > 0),
+	CHECK (due_at > open_at)
+);
+
+CREATE INDEX IF NOT EXISTS idx_quiz_assignment_class ON quiz_assignment (classroom_id, due_at) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_quiz_assignment_quiz ON quiz_assignment (quiz_id) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_quiz_log_quiz_user ON quiz_log (quiz_id, user_id, created_at);
@@ -0,0 +1,20 @@
+-- One row per signed-in device. Only a hash of the current refresh token is
+-- kept; previous_hash holds the one it replaced, so a stolen token that is
+-- replayed after rotation can be spotted and the session revoked.
+CREATE TABLE IF NOT EXISTS user_session (
+	id            BIGSERIAL PRIMARY KEY,
+	created_at    TIMESTAMPTZ,
+	updated_at    TIMESTAMPTZ,
+	deleted_at    TIMESTAMPTZ,
+	user_id       BIGINT NOT NULL REFERENCES users (id),
+	token_hash    TEXT NOT NULL UNIQUE,
+	previous_hash TEXT,
+	user_agent    TEXT NOT NULL DEFAULT '',
+	ip            TEXT NOT NULL DEFAULT '',
+	last_used_at  TIMESTAMPTZ NOT NULL,
+	expires_at    TIMESTAMPTZ NOT NULL,
+	revoked_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_session_user ON user_session (user_id) WHERE revoked_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_user_session_previous ON user_session (previous_hash) WHERE previous_hash IS NOT NULL;
@@ -0,0 +1,19 @@
+-- Users who signed up before verification existed are treated as verified.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMPTZ;
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- Single-use tokens sent by email, for verifying an address or resetting a
+-- password. Only a hash of each token is stored.
+CREATE TABLE IF NOT EXISTS user_token (
+	id         BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	deleted_at TIMESTAMPTZ,
+	user_id    BIGINT NOT NULL REFERENCES users (id),
+	purpose    TEXT NOT NULL CHECK (purpose IN ('verify_email', 'reset_password')),
+	token_hash TEXT NOT NULL UNIQUE,
+	expires_at TIMESTAMPTZ NOT NULL,
+	used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_token_user ON user_token (user_id, purpose) WHERE used_at IS NULL;
@@ -0,0 +1,17 @@
+-- Audit log of sign-in attempts, keyed by the lower-cased email that was
+-- tried. Consecutive failures lock the account for a growing period; a
+-- successful sign-in or a password reset clears them.
+CREATE TABLE IF NOT EXISTS login_attempt (
+	id         BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	deleted_at TIMESTAMPTZ,
+	email      TEXT NOT NULL,
+	user_id    BIGINT REFERENCES users (id),
+	ip         TEXT NOT NULL DEFAULT '',
+	user_agent TEXT NOT NULL DEFAULT '',
+	outcome    TEXT NOT NULL CHECK (outcome IN ('success', 'failed', 'locked', 'reset'))
+);
+
+CREATE INDEX IF NOT EXISTS idx_login_attempt_email ON login_attempt (email, created_at);
+CREATE INDEX IF NOT EXISTS idx_login_attempt_created ON login_attempt (created_at);
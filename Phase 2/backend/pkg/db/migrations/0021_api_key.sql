@@ -0,0 +1,19 @@
+-- Personal API keys for scripts and other non-browser clients. Only a hash
+-- of each key is stored; prefix keeps its first few characters so users can
+-- tell their keys apart.
+CREATE TABLE IF NOT EXISTS api_key (
+	id           BIGSERIAL PRIMARY KEY,
+	created_at   TIMESTAMPTZ,
+	updated_at   TIMESTAMPTZ,
+	deleted_at   TIMESTAMPTZ,
+	user_id      BIGINT NOT NULL REFERENCES users (id),
+	name         TEXT NOT NULL,
+	prefix       TEXT NOT NULL,
+	key_hash     TEXT NOT NULL UNIQUE,
+	scopes       TEXT[] NOT NULL CHECK (scopes <@ ARRAY['read', 'write'] AND cardinality(scopes) > 0),
+	expires_at   TIMESTAMPTZ,
+	last_used_at TIMESTAMPTZ,
+	revoked_at   TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_key_user ON api_key (user_id) WHERE revoked_at IS NULL;
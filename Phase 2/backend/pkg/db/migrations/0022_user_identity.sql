@@ -0,0 +1,18 @@
+-- Accounts at single sign-on providers linked to users. An identity is
+-- keyed by the provider's issuer and its subject, which never changes, even
+-- if the email at the provider does.
+CREATE TABLE IF NOT EXISTS user_identity (
+	id            BIGSERIAL PRIMARY KEY,
+	created_at    TIMESTAMPTZ,
+	updated_at    TIMESTAMPTZ,
+	deleted_at    TIMESTAMPTZ,
+	user_id       BIGINT NOT NULL REFERENCES users (id),
+	provider      TEXT NOT NULL,
+	issuer        TEXT NOT NULL,
+	subject       TEXT NOT NULL,
+	email         TEXT NOT NULL DEFAULT '',
+	last_login_at TIMESTAMPTZ,
+	UNIQUE (issuer, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identity_user ON user_identity (user_id);
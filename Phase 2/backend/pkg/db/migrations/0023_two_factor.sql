@@ -0,0 +1,47 @@
+-- TOTP two-factor authentication. A user has at most one authenticator; its
+-- secret is stored encrypted and it only counts once confirmed with a code.
+-- last_used_step is the latest time step a code was accepted for, so a code
+-- cannot be used twice.
+CREATE TABLE IF NOT EXISTS user_totp (
+	user_id        BIGINT PRIMARY KEY REFERENCES users (id),
+	secret         TEXT NOT NULL,
+	confirmed_at   TIMESTAMPTZ,
+	last_used_step BIGINT NOT NULL DEFAULT 0,
+	created_at     TIMESTAMPTZ,
+	updated_at     TIMESTAMPTZ
+);
+
+-- One-time codes for signing in without the authenticator. Only a keyed
+-- hash of each code is stored.
+CREATE TABLE IF NOT EXISTS recovery_code (
+	id         BIGSERIAL PRIMARY KEY,
+	created_at TIMESTAMPTZ,
+	updated_at TIMESTAMPTZ,
+	deleted_at TIMESTAMPTZ,
+	user_id    BIGINT NOT NULL REFERENCES users (id),
+	code_hash  TEXT NOT NULL UNIQUE,
+	used_at    TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_recovery_code_user ON recovery_code (user_id) WHERE used_at IS NULL;
+
+-- Roles whose users must use two-factor authentication, set by admins.
+-- Teachers and admins see other people's data, so they start out required.
+CREATE TABLE IF NOT EXISTS two_factor_policy (
+	role       TEXT PRIMARY KEY CHECK (role IN ('student', 'teacher', 'admin')),
+	required   BOOLEAN NOT NULL DEFAULT FALSE,
+	updated_at TIMESTAMPTZ,
+	updated_by BIGINT REFERENCES users (id)
+);
+
+INSERT INTO two_factor_policy (role, required, updated_at) VALUES
+	('student', FALSE, NOW()),
+	('teacher', TRUE, NOW()),
+	('admin', TRUE, NOW())
+ON CONFLICT (role) DO NOTHING;
+
+-- A sign-in that passed the password but still owes a second factor. It is
+-- not a success, so it does not clear earlier failures.
+ALTER TABLE login_attempt DROP CONSTRAINT IF EXISTS login_attempt_outcome_check;
+ALTER TABLE login_attempt ADD CONSTRAINT login_attempt_outcome_check
+	CHECK (outcome IN ('success', 'failed', 'locked', 'reset', 'challenged'));
@@ -0,0 +1,8 @@
+-- Problems a user gave up on. Giving up reveals the answer, so the problem
+-- takes no further submissions from that user.
+CREATE TABLE IF NOT EXISTS problem_give_up (
+	user_id    BIGINT NOT NULL REFERENCES users (id),
+	problem_id BIGINT NOT NULL REFERENCES problem (id),
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	PRIMARY KEY (user_id, problem_id)
+);
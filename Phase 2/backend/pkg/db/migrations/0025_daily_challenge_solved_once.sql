@@ -0,0 +1,18 @@
+-- A user solves each daily challenge once. Concurrent correct answers used to
+-- record more than one solve; keep the first and retire the rest.
+UPDATE daily_challenge_attempt a
+SET deleted_at = NOW()
+WHERE a.correct
+	AND a.deleted_at IS NULL
+	AND EXISTS (
+		SELECT 1 FROM daily_challenge_attempt b
+		WHERE b.challenge_id = a.challenge_id
+			AND b.user_id = a.user_id
+			AND b.correct
+			AND b.deleted_at IS NULL
+			AND b.id < a.id
+	);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_daily_challenge_attempt_solved
+	ON daily_challenge_attempt (challenge_id, user_id)
+	WHERE correct AND deleted_at IS NULL;
@@ -0,0 +1,441 @@
+// Package latex parses the subset of LaTeX that appears in quiz content
+// ($...$ inline maths, $$...$$ display maths, fractions, roots, scripts and
+// the usual GCSE symbols) into a node tree that can be typeset without a TeX
+// installation.
+package latex
+
+import (
+	"strings"
+	"unicode"
+)
+
+type Node interface{ node() }
+
+// Atom is a run of glyphs drawn in a single font.
+type Atom struct {
+	Text   string
+	Italic bool
+	Bold   bool
+}
+
+// Space is horizontal glue measured in ems. Breakable spaces come from the
+// prose around the maths and are where lines may wrap.
+type Space struct {
+	Em        float64
+	Breakable bool
+}
+
+type LineBreak struct{}
+
+type Frac struct {
+	Num []Node
+	Den []Node
+}
+
+type Sqrt struct {
+	Index []Node
+	Body  []Node
+}
+
+type Scripts struct {
+	Base []Node
+	Sup  []Node
+	Sub  []Node
+}
+
+// Math wraps an inline maths segment so it is kept on one line.
+type Math struct {
+	Body []Node
+}
+
+// Display is a $$...$$ block set on its own centred line.
+type Display struct {
+	Body []Node
+}
+
+func (Atom) node()      {}
+func (Space) node()     {}
+func (LineBreak) node() {}
+func (Frac) node()      {}
+func (Sqrt) node()      {}
+func (Scripts) node()   {}
+func (Math) node()      {}
+func (Display) node()   {}
+
+var symbols = map[string]string{
+	"times": "×", "div": "÷", "pm": "±", "mp": "∓", "cdot": "·", "ast": "∗",
+	"le": "≤", "leq": "≤", "ge": "≥", "geq": "≥", "neq": "≠", "ne": "≠",
+	"lt": "<", "gt": ">", "approx": "≈", "equiv": "≡", "sim": "∼", "propto": "∝",
+	"infty": "∞", "circ": "°", "degree": "°", "angle": "∠", "triangle": "△",
+	"therefore": "∴", "because": "∵", "perp": "⊥", "parallel": "∥",
+	"in": "∈", "notin": "∉", "cap": "∩", "cup": "∪", "subset": "⊂", "emptyset": "∅",
+	"to": "→", "rightarrow": "→", "leftarrow": "←", "Rightarrow": "⇒", "Leftrightarrow": "⇔",
+	"sum": "∑", "prod": "∏", "int": "∫", "prime": "′",
+	"ldots": "…", "dots": "…", "cdots": "⋯", "colon": ":", "mid": "|", "pounds": "£",
+	"%": "%", "$": "$", "{": "{", "}": "}", "&": "&", "#": "#", "_": "_",
+	"lbrace": "{", "rbrace": "}", "langle": "⟨", "rangle": "⟩", "vert": "|",
+}
+
+var greek = map[string]string{
+	"alpha": "α", "beta": "β", "gamma": "γ", "delta": "δ", "epsilon": "ε", "varepsilon": "ε",
+	"zeta": "ζ", "eta": "η", "theta": "θ", "kappa": "κ", "lambda": "λ", "mu": "μ",
+	"nu": "ν", "xi": "ξ", "pi": "π", "rho": "ρ", "sigma": "σ", "tau": "τ",
+	"phi": "φ", "varphi": "φ", "chi": "χ", "psi": "ψ", "omega": "ω",
+	"Gamma": "Γ", "Delta": "Δ", "Theta": "Θ", "Lambda": "Λ", "Xi": "Ξ", "Pi": "Π",
+	"Sigma": "Σ", "Phi": "Φ", "Psi": "Ψ", "Omega": "Ω",
+}
+
+var functions = map[string]bool{
+	"sin": true, "cos": true, "tan": true, "arcsin": true, "arccos": true, "arctan": true,
+	"log": true, "ln": true, "exp": true, "min": true, "max": true, "lim": true,
+}
+
+var spaces = map[string]float64{
+	",": 0.17, ":": 0.22, ">": 0.22, ";": 0.28, " ": 0.25, "quad": 1, "qquad": 2, "!": 0,
+}
+
+var binaryOps = map[string]bool{
+	"+": true, "−": true, "×": true, "÷": true, "±": true, "∓": true, "·": true,
+	"=": true, "<": true, ">": true, "≤": true, "≥": true, "≠": true, "≈": true,
+	"≡": true, "∝": true, "→": true, "⇒": true, "⇔": true, "∈": true, "∉": true,
+	"∩": true, "∪": true, "⊂": true,
+}
+
+// Parse splits prose containing $...$, \(...\), $$...$$ and \[...\] segments
+// into nodes. Words become upright atoms separated by breakable spaces.
+func Parse(src string) []Node {
+	var nodes []Node
+	var word strings.Builder
+
+	flush := func() {
+		if word.Len() > 0 {
+			nodes = append(nodes, Atom{Text: word.String()})
+			word.Reset()
+		}
+	}
+
+	rs := []rune(src)
+	for i := 0; i < len(rs); i++ {
+		c := rs[i]
+		switch {
+		case c == '\\' && i+1 < len(rs) && rs[i+1] == '$':
+			word.WriteRune('$')
+			i++
+		case c == '$' && i+1 < len(rs) && rs[i+1] == '$':
+			if end := indexFrom(rs, i+2, "$$"); end >= 0 {
+				flush()
+				nodes = append(nodes, Display{Body: ParseMath(string(rs[i+2 : end]))})
+				i = end + 1
+				continue
+			}
+			word.WriteString("$$")
+			i++
+		case c == '\\' && i+1 < len(rs) && rs[i+1] == '[':
+			if end := indexFrom(rs, i+2, `\]`); end >= 0 {
+				flush()
+				nodes = append(nodes, Display{Body: ParseMath(string(rs[i+2 : end]))})
+				i = end + 1
+				continue
+			}
+			word.WriteRune(c)
+		case c == '\\' && i+1 < len(rs) && rs[i+1] == '(':
+			if end := indexFrom(rs, i+2, `\)`); end >= 0 {
+				flush()
+				nodes = append(nodes, Math{Body: ParseMath(string(rs[i+2 : end]))})
+				i = end + 1
+				continue
+			}
+			word.WriteRune(c)
+		case c == '$':
+			if end := indexFrom(rs, i+1, "$"); end >= 0 {
+				flush()
+				nodes = append(nodes, Math{Body: ParseMath(string(rs[i+1 : end]))})
+				i = end
+				continue
+			}
+			word.WriteRune(c)
+		case c == '\n':
+			flush()
+			nodes = append(nodes, LineBreak{})
+		case unicode.IsSpace(c):
+			flush()
+			if len(nodes) > 0 {
+				if _, ok := nodes[len(nodes)-1].(Space); !ok {
+					nodes = append(nodes, Space{Em: 0.28, Breakable: true})
+				}
+			}
+		default:
+			word.WriteRune(c)
+		}
+	}
+	flush()
+
+	return nodes
+}
+
+// ParseMath parses the body of a maths segment, without delimiters.
+func ParseMath(src string) []Node {
+	p := &parser{src: []rune(src)}
+	return p.parseList(0)
+}
+
+type parser struct {
+	src []rune
+	pos int
+}
+
+func (p *parser) peek() rune {
+	if p.pos >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *parser) skipSpace() {
+	for p.pos < len(p.src) && unicode.IsSpace(p.src[p.pos]) {
+		p.pos++
+	}
+}
+
+// parseList reads nodes until the closing rune stop (0 for end of input).
+func (p *parser) parseList(stop rune) []Node {
+	var nodes []Node
+	for p.pos < len(p.src) {
+		c := p.src[p.pos]
+		switch {
+		case stop != 0 && c == stop:
+			p.pos++
+			return nodes
+		case c == '}':
+			p.pos++
+		case unicode.IsSpace(c):
+			p.pos++
+		case c == '^' || c == '_':
+			p.pos++
+			arg := p.parseArg()
+			nodes = attachScript(nodes, c == '^', arg)
+		case c == '{':
+			p.pos++
+			nodes = append(nodes, Math{Body: p.parseList('}')})
+		case c == '\\':
+			p.pos++
+			cmd := p.parseCommand()
+			if len(cmd) == 1 {
+				if a, ok := cmd[0].(Atom); ok && binaryOps[a.Text] {
+					cmd = operator(a.Text, nodes)
+				}
+			}
+			nodes = append(nodes, cmd...)
+		case unicode.IsDigit(c) || c == '.':
+			start := p.pos
+			for p.pos < len(p.src) && (unicode.IsDigit(p.src[p.pos]) || p.src[p.pos] == '.') {
+				p.pos++
+			}
+			nodes = append(nodes, Atom{Text: string(p.src[start:p.pos])})
+		case unicode.IsLetter(c):
+			p.pos++
+			nodes = append(nodes, Atom{Text: string(c), Italic: true})
+		case c == '-':
+			p.pos++
+			nodes = append(nodes, operator("−", nodes)...)
+		case c == '*':
+			p.pos++
+			nodes = append(nodes, operator("×", nodes)...)
+		default:
+			p.pos++
+			nodes = append(nodes, operator(string(c), nodes)...)
+		}
+	}
+	return nodes
+}
+
+// parseArg reads a single argument: a braced group, a command or one rune.
+func (p *parser) parseArg() []Node {
+	p.skipSpace()
+	switch c := p.peek(); c {
+	case 0:
+		return nil
+	case '{':
+		p.pos++
+		return p.parseList('}')
+	case '\\':
+		p.pos++
+		return p.parseCommand()
+	default:
+		p.pos++
+		if unicode.IsLetter(c) {
+			return []Node{Atom{Text: string(c), Italic: true}}
+		}
+		return []Node{Atom{Text: string(c)}}
+	}
+}
+
+// parseOptional reads an optional [..] argument if present.
+func (p *parser) parseOptional() []Node {
+	p.skipSpace()
+	if p.peek() != '[' {
+		return nil
+	}
+	p.pos++
+	return p.parseList(']')
+}
+
+// parseRaw reads a braced argument verbatim, as used by \text.
+func (p *parser) parseRaw() string {
+	p.skipSpace()
+	if p.peek() != '{' {
+		return ""
+	}
+	p.pos++
+	depth := 1
+	start := p.pos
+	for p.pos < len(p.src) {
+		switch p.src[p.pos] {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				s := string(p.src[start:p.pos])
+				p.pos++
+				return s
+			}
+		}
+		p.pos++
+	}
+	return string(p.src[start:])
+}
+
+func (p *parser) parseCommand() []Node {
+	if p.pos >= len(p.src) {
+		return nil
+	}
+
+	start := p.pos
+	if unicode.IsLetter(p.src[p.pos]) {
+		for p.pos < len(p.src) && unicode.IsLetter(p.src[p.pos]) {
+			p.pos++
+		}
+	} else {
+		p.pos++
+	}
+	name := string(p.src[start:p.pos])
+
+	if em, ok := spaces[name]; ok {
+		if em == 0 {
+			return nil
+		}
+		return []Node{Space{Em: em}}
+	}
+	if s, ok := greek[name]; ok {
+		return []Node{Atom{Text: s, Italic: unicode.IsLower([]rune(s)[0])}}
+	}
+	if s, ok := symbols[name]; ok {
+		return []Node{Atom{Text: s}}
+	}
+	if functions[name] {
+		return []Node{Atom{Text: name}, Space{Em: 0.17}}
+	}
+
+	switch name {
+	case "frac", "dfrac", "tfrac":
+		num := p.parseArg()
+		den := p.parseArg()
+		return []Node{Frac{Num: num, Den: den}}
+	case "sqrt":
+		index := p.parseOptional()
+		body := p.parseArg()
+		return []Node{Sqrt{Index: index, Body: body}}
+	case "text", "textrm", "mathrm", "mbox", "operatorname", "textit", "mathit":
+		return []Node{Atom{Text: p.parseRaw(), Italic: name == "textit" || name == "mathit"}}
+	case "textbf", "mathbf", "boldsymbol":
+		return []Node{Atom{Text: p.parseRaw(), Bold: true}}
+	case "left", "right", "big", "Big", "bigl", "bigr", "Bigl", "Bigr":
+		p.skipSpace()
+		if p.peek() == '\\' {
+			p.pos++
+			return p.parseCommand()
+		}
+		if c := p.peek(); c != 0 {
+			p.pos++
+			if c != '.' {
+				return []Node{Atom{Text: string(c)}}
+			}
+		}
+		return nil
+	case "begin", "end":
+		p.parseRaw()
+		return nil
+	case "\\":
+		return []Node{LineBreak{}}
+	case "overline", "underline", "bar", "vec", "hat", "mathbb", "mathcal", "displaystyle":
+		if name == "displaystyle" {
+			return nil
+		}
+		return p.parseArg()
+	}
+
+	return []Node{Atom{Text: name}}
+}
+
+// operator emits a symbol, padding binary and relational operators with thin
+// spaces unless they are unary (first in the list or after another operator).
+func operator(sym string, prev []Node) []Node {
+	if !binaryOps[sym] {
+		return []Node{Atom{Text: sym}}
+	}
+	if len(prev) == 0 {
+		return []Node{Atom{Text: sym}}
+	}
+	if a, ok := prev[len(prev)-1].(Atom); ok && binaryOps[a.Text] {
+		return []Node{Atom{Text: sym}}
+	}
+	if _, ok := prev[len(prev)-1].(Space); ok && len(prev) > 1 {
+		if a, ok := prev[len(prev)-2].(Atom); ok && binaryOps[a.Text] {
+			return []Node{Atom{Text: sym}}
+		}
+	}
+	return []Node{Space{Em: 0.22}, Atom{Text: sym}, Space{Em: 0.22}}
+}
+
+func attachScript(nodes []Node, sup bool, arg []Node) []Node {
+	var base []Node
+	if len(nodes) > 0 {
+		last := nodes[len(nodes)-1]
+		if s, ok := last.(Scripts); ok && ((sup && s.Sup == nil) || (!sup && s.Sub == nil)) {
+			if sup {
+				s.Sup = arg
+			} else {
+				s.Sub = arg
+			}
+			nodes[len(nodes)-1] = s
+			return nodes
+		}
+		if _, ok := last.(Space); !ok {
+			base = []Node{last}
+			nodes = nodes[:len(nodes)-1]
+		}
+	}
+
+	s := Scripts{Base: base}
+	if sup {
+		s.Sup = arg
+	} else {
+		s.Sub = arg
+	}
+	return append(nodes, s)
+}
+
+func indexFrom(rs []rune, from int, delim string) int {
+	d := []rune(delim)
+	for i := from; i+len(d) <= len(rs); i++ {
+		if rs[i] == '\\' && string(d) == "$" {
+			i++
+			continue
+		}
+		if string(rs[i:i+len(d)]) == delim {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,68 @@
+// Package linkcheck reports whether external links still resolve. A link is
+// probed with HEAD, falling back to GET for servers that do not support HEAD.
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+type Result struct {
+	Status    int
+	OK        bool
+	Err       string
+	CheckedAt time.Time
+}
+
+type Checker struct {
+	// Client sends the probes. Tests can point it at a local stub server.
+	Client    *http.Client
+	UserAgent string
+}
+
+func New(timeout time.Duration) *Checker {
+	return &Checker{
+		Client:    &http.Client{Timeout: timeout},
+		UserAgent: "M-AI link checker",
+	}
+}
+
+// Check probes url. Any 2xx or 3xx response after redirects counts as OK;
+// 4xx and 5xx responses and network errors do not.
+func (c *Checker) Check(ctx context.Context, url string) Result {
+	result := Result{CheckedAt: time.Now()}
+
+	status, err := c.probe(ctx, http.MethodHead, url)
+	if err == nil && (status == http.StatusMethodNotAllowed || status == http.StatusNotImplemented) {
+		status, err = c.probe(ctx, http.MethodGet, url)
+	}
+	if err != nil {
+		result.Err = err.Error()
+		return result
+	}
+
+	result.Status = status
+	result.OK = status >= 200 && status < 400
+	if !result.OK {
+		result.Err = http.StatusText(status)
+	}
+	return result
+}
+
+func (c *Checker) probe(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	if c.UserAgent != "" {
+		req.Header.Set("User-Agent", c.UserAgent)
+	}
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,127 @@
+package linkcheck
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestCheckStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		ok     bool
+	}{
+		{"ok", http.StatusOK, true},
+		{"redirect not followed", http.StatusNotModified, true},
+		{"not found", http.StatusNotFound, false},
+		{"gone", http.StatusGone, false},
+		{"server error", http.StatusInternalServerError, false},
+		{"bad gateway", http.StatusBadGateway, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer srv.Close()
+
+			result := New(time.Second).Check(context.Background(), srv.URL)
+			if result.Status != tt.status || result.OK != tt.ok {
+				t.Fatalf("got status %d ok %v, want %d ok %v", result.Status, result.OK, tt.status, tt.ok)
+			}
+			if !tt.ok && result.Err != http.StatusText(tt.status) {
+				t.Errorf("got error %q, want %q", result.Err, http.StatusText(tt.status))
+			}
+		})
+	}
+}
+
+func TestCheckFallsBackToGet(t *testing.T) {
+	for _, headStatus := range []int{http.StatusMethodNotAllowed, http.StatusNotImplemented} {
+		var methods []string
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			methods = append(methods, r.Method)
+			if r.Method == http.MethodHead {
+				w.WriteHeader(headStatus)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+
+		result := New(time.Second).Check(context.Background(), srv.URL)
+		srv.Close()
+		if !result.OK || result.Status != http.StatusOK {
+			t.Errorf("HEAD %d: got status %d ok %v, want 200 ok", headStatus, result.Status, result.OK)
+		}
+		if len(methods) != 2 || methods[0] != http.MethodHead || methods[1] != http.MethodGet {
+			t.Errorf("HEAD %d: got methods %v, want [HEAD GET]", headStatus, methods)
+		}
+	}
+}
+
+func TestCheckDoesNotFallBackOnOtherErrors(t *testing.T) {
+	var gets int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			gets++
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	if result := New(time.Second).Check(context.Background(), srv.URL); result.OK {
+		t.Fatal("got ok for a 404")
+	}
+	if gets != 0 {
+		t.Errorf("got %d GET requests, want none", gets)
+	}
+}
+
+func TestCheckSendsUserAgent(t *testing.T) {
+	var agent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent = r.UserAgent()
+	}))
+	defer srv.Close()
+
+	c := New(time.Second)
+	c.Check(context.Background(), srv.URL)
+	if agent != c.UserAgent {
+		t.Errorf("got user agent %q, want %q", agent, c.UserAgent)
+	}
+}
+
+func TestCheckTimeout(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	start := time.Now()
+	result := New(50*time.Millisecond).Check(context.Background(), srv.URL)
+	if result.OK || result.Err == "" || result.Status != 0 {
+		t.Fatalf("got status %d ok %v error %q, want a timeout error", result.Status, result.OK, result.Err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("check took %v, want it cut off by the timeout", elapsed)
+	}
+}
+
+func TestCheckCancelled(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if result := New(time.Second).Check(ctx, srv.URL); result.OK || result.Err == "" {
+		t.Fatalf("got ok %v error %q, want a cancellation error", result.OK, result.Err)
+	}
+}
@@ -0,0 +1,200 @@
+package mailer
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the fake server saw during one delivery.
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// fakeSMTP serves one SMTP conversation on a local port and reports it on the
+// returned channel. With stall set it greets the client and then says nothing.
+func fakeSMTP(t *testing.T, stall bool) (string, int, <-chan smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if stall {
+			time.Sleep(5 * time.Second)
+			return
+		}
+
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var s smtpSession
+		reply("220 localhost ESMTP")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			cmd := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(cmd, "EHLO"):
+				reply("250-localhost")
+				reply("250 AUTH PLAIN")
+			case strings.HasPrefix(cmd, "AUTH PLAIN"):
+				s.auth = strings.TrimSpace(line[len("AUTH PLAIN"):])
+				reply("235 OK")
+			case strings.HasPrefix(cmd, "MAIL FROM:"):
+				s.from = line[len("MAIL FROM:"):]
+				reply("250 OK")
+			case strings.HasPrefix(cmd, "RCPT TO:"):
+				s.to = append(s.to, line[len("RCPT TO:"):])
+				reply("250 OK")
+			case cmd == "DATA":
+				reply("354 Go ahead")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				s.data = data.String()
+				reply("250 OK")
+			case cmd == "QUIT":
+				reply("221 Bye")
+				sessions <- s
+				return
+			default:
+				reply("502 Unknown command")
+			}
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return host, p, sessions
+}
+
+func TestSMTPMailerSend(t *testing.T) {
+	host, port, sessions := fakeSMTP(t, false)
+	m := &SMTPMailer{Host: host, Port: port, Username: "user", Password: "secret", From: "noreply@example.com"}
+
+	err := m.Send(context.Background(), Message{To: "student@example.com", Subject: "Welcome", Body: "Hello\nthere"})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+
+	s := <-sessions
+	auth, err := base64.StdEncoding.DecodeString(s.auth)
+	if err != nil || string(auth) != "\x00user\x00secret" {
+		t.Errorf("got auth %q, want user and secret", auth)
+	}
+	if s.from != "<noreply@example.com>" {
+		t.Errorf("got sender %s", s.from)
+	}
+	if len(s.to) != 1 || s.to[0] != "<student@example.com>" {
+		t.Errorf("got recipients %v", s.to)
+	}
+	for _, want := range []string{
+		"From: noreply@example.com\r\n",
+		"To: student@example.com\r\n",
+		"Subject: Welcome\r\n",
+		"\r\n\r\nHello\r\nthere\r\n",
+	} {
+		if !strings.Contains(s.data, want) {
+			t.Errorf("message is missing %q:\n%s", want, s.data)
+		}
+	}
+}
+
+func TestSMTPMailerWithoutUsernameSkipsAuth(t *testing.T) {
+	host, port, sessions := fakeSMTP(t, false)
+	m := &SMTPMailer{Host: host, Port: port, From: "noreply@example.com"}
+
+	if err := m.Send(context.Background(), Message{To: "student@example.com", Subject: "Hi", Body: "Hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if s := <-sessions; s.auth != "" {
+		t.Errorf("got auth %q, want none", s.auth)
+	}
+}
+
+func TestSMTPMailerHonoursContext(t *testing.T) {
+	host, port, _ := fakeSMTP(t, true)
+	m := &SMTPMailer{Host: host, Port: port, From: "noreply@example.com"}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := m.Send(ctx, Message{To: "student@example.com", Subject: "Hi", Body: "Hi"})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("got %v, want the context's deadline error", err)
+	}
+}
+
+func TestLogMailerWritesMessage(t *testing.T) {
+	var buf bytes.Buffer
+	m := &LogMailer{Out: &buf, From: "noreply@example.com"}
+
+	if err := m.Send(context.Background(), Message{To: "student@example.com", Subject: "Reset", Body: "Link"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"To: student@example.com\r\n", "Subject: Reset\r\n", "\r\n\r\nLink\r\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output is missing %q:\n%s", want, out)
+		}
+	}
+}
+
+func TestNew(t *testing.T) {
+	if _, err := New(Config{Driver: "smtp"}); err == nil {
+		t.Error("smtp driver without a host: got no error")
+	}
+	if m, err := New(Config{Driver: "smtp", Host: "localhost"}); err != nil {
+		t.Errorf("smtp driver: %v", err)
+	} else if _, ok := m.(*SMTPMailer); !ok {
+		t.Errorf("smtp driver: got %T", m)
+	}
+	if m, err := New(Config{}); err != nil {
+		t.Errorf("default driver: %v", err)
+	} else if _, ok := m.(*LogMailer); !ok {
+		t.Errorf("default driver: got %T", m)
+	}
+
+	path := filepath.Join(t.TempDir(), "mail.log")
+	m, err := New(Config{Driver: "file", File: path, From: "noreply@example.com"})
+	if err != nil {
+		t.Fatalf("file driver: %v", err)
+	}
+	if err := m.Send(context.Background(), Message{To: "student@example.com", Subject: "Hi", Body: "Hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || !strings.Contains(string(data), "Subject: Hi\r\n") {
+		t.Errorf("file driver wrote %q, %v", data, err)
+	}
+}
@@ -0,0 +1,57 @@
+// Package mastery implements Bayesian knowledge tracing: the probability that
+// a student has mastered a skill is updated after every answer from the
+// chance of slipping on a known skill, guessing an unknown one and learning
+// from the attempt. Mastery not practised decays back towards the prior.
+package mastery
+
+import (
+	"math"
+	"time"
+)
+
+// Initial is the probability a skill is mastered before any attempt.
+const Initial = 0.2
+
+// HalfLife is how long it takes the gap between a student's mastery and
+// Initial to halve without practice.
+const HalfLife = 60 * 24 * time.Hour
+
+type Params struct {
+	Transit float64 // chance of learning the skill from one attempt
+	Slip    float64 // chance of answering wrongly despite mastery
+	Guess   float64 // chance of answering correctly without mastery
+}
+
+var (
+	// MultipleChoice suits four-option quiz questions.
+	MultipleChoice = Params{Transit: 0.1, Slip: 0.1, Guess: 0.25}
+	// FreeResponse suits problems answered in the student's own words, which
+	// are much harder to guess.
+	FreeResponse = Params{Transit: 0.1, Slip: 0.1, Guess: 0.05}
+)
+
+// Update returns the mastery after one attempt.
+func Update(p float64, correct bool, params Params) float64 {
+	var posterior float64
+	if correct {
+		known := p * (1 - params.Slip)
+		posterior = known / (known + (1-p)*params.Guess)
+	} else {
+		known := p * params.Slip
+		posterior = known / (known + (1-p)*(1-params.Guess))
+	}
+	return clamp(posterior + (1-posterior)*params.Transit)
+}
+
+// Decay returns the mastery after elapsed time without practice.
+func Decay(p float64, elapsed time.Duration) float64 {
+	if elapsed <= 0 {
+		return p
+	}
+	return clamp(Initial + (p-Initial)*math.Pow(0.5, float64(elapsed)/float64(HalfLife)))
+}
+
+// clamp keeps mastery away from 0 and 1 so a single answer can always move it.
+func clamp(p float64) float64 {
+	return math.Min(0.999, math.Max(0.001, p))
+}
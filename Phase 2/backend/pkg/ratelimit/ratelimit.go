@@ -0,0 +1,124 @@
+// Package ratelimit throttles requests with in-memory token buckets keyed on
+// parts of the request, such as the client IP, the user or the route.
+package ratelimit
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// idleTTL is how long an untouched bucket is kept. A bucket idle for longer
+// has refilled anyway, so dropping it loses nothing.
+const idleTTL = 10 * time.Minute
+
+// KeyFunc names the part of a request a bucket is keyed on, such as the
+// client's IP or the route.
+type KeyFunc func(c *gin.Context) string
+
+// IP keys requests by client address.
+func IP(c *gin.Context) string {
+	return "ip:" + c.ClientIP()
+}
+
+// Route keys requests by the matched route, so each endpoint has its own
+// bucket.
+func Route(c *gin.Context) string {
+	return "route:" + c.Request.Method + " " + c.FullPath()
+}
+
+// User keys requests by the signed-in user, as reported by identify, falling
+// back to the client's IP for anonymous requests.
+func User(identify func(c *gin.Context) (uint, bool)) KeyFunc {
+	return func(c *gin.Context) string {
+		if userID, ok := identify(c); ok {
+			return "user:" + strconv.FormatUint(uint64(userID), 10)
+		}
+		return IP(c)
+	}
+}
+
+type bucket struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// Limiter is a set of in-memory token buckets sharing one rate. Each bucket
+// holds up to burst tokens and refills at n tokens per period.
+type Limiter struct {
+	limit rate.Limit
+	burst int
+
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+func New(n int, per time.Duration, burst int) *Limiter {
+	return &Limiter{
+		limit:   rate.Limit(float64(n) / per.Seconds()),
+		burst:   burst,
+		buckets: make(map[string]*bucket),
+	}
+}
+
+// Allow takes a token from key's bucket. If the bucket is empty it reports
+// how long until the next token is available.
+func (l *Limiter) Allow(key string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) > idleTTL {
+		for k, b := range l.buckets {
+			if now.Sub(b.lastSeen) > idleTTL {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &bucket{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.buckets[key] = b
+	}
+	b.lastSeen = now
+
+	r := b.limiter.ReserveN(now, 1)
+	if delay := r.DelayFrom(now); delay > 0 {
+		r.CancelAt(now)
+		return false, delay
+	}
+	return true, 0
+}
+
+// Middleware limits requests per bucket, keyed on the combination of keys.
+// Blocked requests get a 429 with a Retry-After header in seconds.
+func (l *Limiter) Middleware(keys ...KeyFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := make([]string, len(keys))
+		for i, key := range keys {
+			parts[i] = key(c)
+		}
+
+		ok, retryAfter := l.Allow(strings.Join(parts, "|"), time.Now())
+		if !ok {
+			SetRetryAfter(c, retryAfter)
+			c.JSON(http.StatusTooManyRequests, gin.H{"error": "Too many requests, please try again later"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// SetRetryAfter sets the Retry-After header, rounding up to whole seconds.
+func SetRetryAfter(c *gin.Context, d time.Duration) {
+	seconds := int(math.Ceil(d.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(max(seconds, 1)))
+}
@@ -0,0 +1,86 @@
+// Package scheduler runs background jobs on fixed intervals or once per UTC
+// day. Each job runs once when the scheduler starts so missed runs are caught
+// up after a restart.
+package scheduler
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+type job struct {
+	name string
+	next func(now time.Time) time.Time
+	fn   func() error
+}
+
+type Scheduler struct {
+	jobs []job
+	stop chan struct{}
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{stop: make(chan struct{})}
+}
+
+// Every runs fn at a fixed interval.
+func (s *Scheduler) Every(name string, interval time.Duration, fn func() error) {
+	s.jobs = append(s.jobs, job{
+		name: name,
+		next: func(now time.Time) time.Time { return now.Add(interval) },
+		fn:   fn,
+	})
+}
+
+// Daily runs fn just after every UTC midnight.
+func (s *Scheduler) Daily(name string, fn func() error) {
+	s.jobs = append(s.jobs, job{
+		name: name,
+		next: func(now time.Time) time.Time {
+			y, m, d := now.UTC().Date()
+			return time.Date(y, m, d+1, 0, 0, 5, 0, time.UTC)
+		},
+		fn: fn,
+	})
+}
+
+func (s *Scheduler) Start() {
+	for _, j := range s.jobs {
+		s.wg.Add(1)
+		go s.loop(j)
+	}
+}
+
+func (s *Scheduler) Stop() {
+	close(s.stop)
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(j job) {
+	defer s.wg.Done()
+	for {
+		run(j)
+
+		timer := time.NewTimer(time.Until(j.next(time.Now())))
+		select {
+		case <-s.stop:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+}
+
+func run(j job) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Scheduled job %s panicked: %v", j.name, r)
+		}
+	}()
+
+	if err := j.fn(); err != nil {
+		log.Printf("Scheduled job %s failed: %v", j.name, err)
+	}
+}
@@ -0,0 +1,293 @@
+package sso
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"github.com/golang-jwt/jwt/v5"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const testSecret = "test-secret"
+
+func TestStateSealOpen(t *testing.T) {
+	state, err := NewState("school", "/dashboard")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.State == "" || state.Nonce == "" || state.Verifier == "" || state.State == state.Nonce {
+		t.Fatalf("got weak state %+v", state)
+	}
+
+	sealed, err := state.Seal(testSecret, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	opened, err := OpenState(sealed, testSecret)
+	if err != nil {
+		t.Fatalf("OpenState: %v", err)
+	}
+	if opened != state {
+		t.Errorf("got %+v, want %+v", opened, state)
+	}
+}
+
+func TestOpenStateRejects(t *testing.T) {
+	state, err := NewState("school", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sealed, err := state.Seal(testSecret, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expired, err := state.Seal(testSecret, -time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, payload, _ := strings.Cut(sealed, ".")
+	payload, signature, _ := strings.Cut(payload, ".")
+	forged, _ := json.Marshal(map[string]any{"provider": "school", "state": "mine", "exp": time.Now().Add(time.Hour).Unix()})
+	tampered := header + "." + base64.RawURLEncoding.EncodeToString(forged) + "." + signature
+	unsigned, err := jwt.NewWithClaims(jwt.SigningMethodNone, jwt.MapClaims{"state": state.State}).SignedString(jwt.UnsafeAllowNoneSignatureType)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]string{
+		"wrong key": sealed,
+		"expired":   expired,
+		"tampered":  tampered,
+		"unsigned":  unsigned,
+		"garbage":   "not-a-token",
+	}
+	for name, token := range tests {
+		key := testSecret
+		if name == "wrong key" {
+			key = "other-secret"
+		}
+		if _, err := OpenState(token, key); err == nil {
+			t.Errorf("%s: got no error", name)
+		}
+	}
+}
+
+// mockProvider is an OpenID Connect provider that issues ID tokens for one
+// user, and only for the PKCE verifier matching the last sign-in URL.
+type mockProvider struct {
+	*httptest.Server
+	key *rsa.PrivateKey
+
+	mu        sync.Mutex
+	challenge string
+	nonce     string
+	claims    jwt.MapClaims
+}
+
+func newMockProvider(t *testing.T) *mockProvider {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := &mockProvider{key: key}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                m.URL,
+			"authorization_endpoint":                m.URL + "/authorize",
+			"token_endpoint":                        m.URL + "/token",
+			"jwks_uri":                              m.URL + "/jwks",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]string{{
+			"kty": "RSA",
+			"alg": "RS256",
+			"use": "sig",
+			"kid": "test",
+			"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if r.PostFormValue("code") != "good-code" || base64.RawURLEncoding.EncodeToString(sum[:]) != m.challenge {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+
+		claims := jwt.MapClaims{
+			"iss":   m.URL,
+			"sub":   "user-1",
+			"aud":   "client",
+			"exp":   time.Now().Add(time.Minute).Unix(),
+			"iat":   time.Now().Unix(),
+			"nonce": m.nonce,
+		}
+		for k, v := range m.claims {
+			claims[k] = v
+		}
+		token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+		token.Header["kid"] = "test"
+		idToken, err := token.SignedString(key)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(map[string]any{
+			"access_token": "access",
+			"token_type":   "Bearer",
+			"expires_in":   60,
+			"id_token":     idToken,
+		})
+	})
+	m.Server = httptest.NewServer(mux)
+	t.Cleanup(m.Close)
+	return m
+}
+
+// signIn follows the provider's sign-in URL as a browser would, recording
+// the PKCE challenge and nonce the token endpoint will check.
+func (m *mockProvider) signIn(t *testing.T, p *Provider, state State) {
+	t.Helper()
+	authURL, err := p.AuthURL(context.Background(), state)
+	if err != nil {
+		t.Fatalf("AuthURL: %v", err)
+	}
+	u, err := url.Parse(authURL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("state") != state.State || q.Get("code_challenge_method") != "S256" || q.Get("client_id") != "client" {
+		t.Fatalf("unexpected sign-in URL %s", authURL)
+	}
+	if !strings.Contains(q.Get("scope"), "openid") {
+		t.Errorf("sign-in URL does not ask for openid: %s", authURL)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.challenge = q.Get("code_challenge")
+	m.nonce = q.Get("nonce")
+}
+
+func newTestProvider(m *mockProvider) *Provider {
+	return NewProvider(Config{
+		Issuer:       m.URL,
+		ClientID:     "client",
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost/callback",
+	})
+}
+
+func TestExchange(t *testing.T) {
+	m := newMockProvider(t)
+	m.claims = jwt.MapClaims{"email": "student@example.com", "email_verified": "true", "name": "Student"}
+	p := newTestProvider(m)
+	state, err := NewState("school", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.signIn(t, p, state)
+
+	identity, err := p.Exchange(context.Background(), state, "good-code")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	want := Identity{Issuer: m.URL, Subject: "user-1", Email: "student@example.com", EmailVerified: true, Name: "Student"}
+	if identity != want {
+		t.Errorf("got %+v, want %+v", identity, want)
+	}
+}
+
+func TestExchangeRejectsNonceMismatch(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+	state, err := NewState("school", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.signIn(t, p, state)
+	m.mu.Lock()
+	m.nonce = "someone-elses-nonce"
+	m.mu.Unlock()
+
+	if _, err := p.Exchange(context.Background(), state, "good-code"); err == nil || !strings.Contains(err.Error(), "nonce") {
+		t.Fatalf("got %v, want a nonce error", err)
+	}
+}
+
+func TestExchangeRejectsWrongVerifier(t *testing.T) {
+	m := newMockProvider(t)
+	p := newTestProvider(m)
+	state, err := NewState("school", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.signIn(t, p, state)
+
+	// A code intercepted from the redirect is useless without the verifier
+	// sealed in the original login's state.
+	other, err := NewState("school", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.Nonce = state.Nonce
+	if _, err := p.Exchange(context.Background(), other, "good-code"); err == nil {
+		t.Fatal("got no error for a mismatched PKCE verifier")
+	}
+}
+
+func TestExchangeRejectsForeignAudience(t *testing.T) {
+	m := newMockProvider(t)
+	m.claims = jwt.MapClaims{"aud": "another-client"}
+	p := newTestProvider(m)
+	state, err := NewState("school", "/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.signIn(t, p, state)
+
+	if _, err := p.Exchange(context.Background(), state, "good-code"); err == nil {
+		t.Fatal("got no error for an ID token issued to another client")
+	}
+}
+
+func TestIsTrue(t *testing.T) {
+	tests := []struct {
+		claim any
+		want  bool
+	}{
+		{true, true},
+		{false, false},
+		{"true", true},
+		{"false", false},
+		{"yes", false},
+		{nil, false},
+		{1.0, false},
+	}
+	for _, tt := range tests {
+		if got := isTrue(tt.claim); got != tt.want {
+			t.Errorf("isTrue(%#v) = %v, want %v", tt.claim, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,80 @@
+package sso
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/oauth2"
+	"time"
+)
+
+// State is what a login carries from sending the user to the provider to
+// their return: the state and nonce that tie the two together, the PKCE
+// verifier, and where to send the user afterwards. It is kept on the client,
+// sealed, so the server stores nothing for logins that are never finished.
+type State struct {
+	Provider string `json:"provider"`
+	State    string `json:"state"`
+	Nonce    string `json:"nonce"`
+	Verifier string `json:"verifier"`
+	ReturnTo string `json:"return_to"`
+}
+
+func NewState(provider, returnTo string) (State, error) {
+	state, err := random()
+	if err != nil {
+		return State{}, err
+	}
+	nonce, err := random()
+	if err != nil {
+		return State{}, err
+	}
+	return State{
+		Provider: provider,
+		State:    state,
+		Nonce:    nonce,
+		Verifier: oauth2.GenerateVerifier(),
+		ReturnTo: returnTo,
+	}, nil
+}
+
+type stateClaims struct {
+	State
+	jwt.RegisteredClaims
+}
+
+// Seal signs the state so it can be handed to the client and trusted when it
+// comes back, until ttl passes.
+func (s State) Seal(secretKey string, ttl time.Duration) (string, error) {
+	claims := stateClaims{
+		State: s,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(ttl)),
+		},
+	}
+	return jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(secretKey))
+}
+
+// OpenState checks a sealed state and returns it.
+func OpenState(sealed, secretKey string) (State, error) {
+	var claims stateClaims
+	_, err := jwt.ParseWithClaims(sealed, &claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, errors.New("invalid signing method")
+		}
+		return []byte(secretKey), nil
+	})
+	if err != nil {
+		return State{}, err
+	}
+	return claims.State, nil
+}
+
+func random() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
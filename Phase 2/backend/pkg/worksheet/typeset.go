@@ -0,0 +1,292 @@
+package worksheet
+
+import (
+	"M-AI/pkg/latex"
+	"github.com/jung-kurt/gofpdf"
+)
+
+const (
+	fontFamily = "dejavu"
+	ptToMM     = 25.4 / 72
+)
+
+// box is a typeset item. Dimensions are in millimetres; asc is the height
+// above the baseline and desc the depth below it.
+type box struct {
+	w, asc, desc float64
+	breakable    bool
+	newline      bool
+	display      bool
+	draw         func(x, baseline float64)
+}
+
+type typesetter struct {
+	pdf *gofpdf.Fpdf
+}
+
+func (t *typesetter) setFont(a latex.Atom, size float64) {
+	style := ""
+	if a.Bold {
+		style = "B"
+	} else if a.Italic {
+		style = "I"
+	}
+	t.pdf.SetFont(fontFamily, style, size)
+}
+
+// boxes converts nodes into a flat list of boxes at the given point size.
+// Maths segments come back as a single unbreakable box.
+func (t *typesetter) boxes(nodes []latex.Node, size float64) []box {
+	var out []box
+	em := size * ptToMM
+
+	for _, n := range nodes {
+		switch n := n.(type) {
+		case latex.Atom:
+			out = append(out, t.atom(n, size))
+		case latex.Space:
+			out = append(out, box{w: n.Em * em, breakable: n.Breakable})
+		case latex.LineBreak:
+			out = append(out, box{newline: true})
+		case latex.Math:
+			out = append(out, t.hbox(t.boxes(n.Body, size)))
+		case latex.Display:
+			b := t.hbox(t.boxes(n.Body, size))
+			b.display = true
+			out = append(out, b)
+		case latex.Frac:
+			out = append(out, t.frac(n, size))
+		case latex.Sqrt:
+			out = append(out, t.sqrt(n, size))
+		case latex.Scripts:
+			out = append(out, t.scripts(n, size))
+		}
+	}
+
+	return out
+}
+
+func (t *typesetter) atom(a latex.Atom, size float64) box {
+	t.setFont(a, size)
+	em := size * ptToMM
+	return box{
+		w:    t.pdf.GetStringWidth(a.Text),
+		asc:  0.76 * em,
+		desc: 0.24 * em,
+		draw: func(x, baseline float64) {
+			t.setFont(a, size)
+			t.pdf.Text(x, baseline, a.Text)
+		},
+	}
+}
+
+// hbox glues boxes side by side into one unbreakable box.
+func (t *typesetter) hbox(items []box) box {
+	var b box
+	for _, it := range items {
+		b.w += it.w
+		b.asc = max(b.asc, it.asc)
+		b.desc = max(b.desc, it.desc)
+	}
+	b.draw = func(x, baseline float64) {
+		for _, it := range items {
+			if it.draw != nil {
+				it.draw(x, baseline)
+			}
+			x += it.w
+		}
+	}
+	return b
+}
+
+func (t *typesetter) frac(f latex.Frac, size float64) box {
+	em := size * ptToMM
+	inner := size * 0.8
+	num := t.hbox(t.boxes(f.Num, inner))
+	den := t.hbox(t.boxes(f.Den, inner))
+
+	axis := 0.27 * em
+	gap := 0.12 * em
+	pad := 0.1 * em
+	w := max(num.w, den.w) + 2*pad
+
+	return box{
+		w:    w,
+		asc:  axis + gap + num.desc + num.asc,
+		desc: gap + den.asc + den.desc - axis,
+		draw: func(x, baseline float64) {
+			line := baseline - axis
+			num.draw(x+(w-num.w)/2, line-gap-num.desc)
+			den.draw(x+(w-den.w)/2, line+gap+den.asc)
+			t.pdf.SetLineWidth(0.05 * em)
+			t.pdf.Line(x+pad/2, line, x+w-pad/2, line)
+		},
+	}
+}
+
+func (t *typesetter) sqrt(s latex.Sqrt, size float64) box {
+	em := size * ptToMM
+	body := t.hbox(t.boxes(s.Body, size))
+	var index *box
+	if len(s.Index) > 0 {
+		b := t.hbox(t.boxes(s.Index, size*0.6))
+		index = &b
+	}
+
+	gap := 0.12 * em
+	lead := 0.0
+	if index != nil {
+		lead = max(0, index.w-0.2*em)
+	}
+	sign := 0.55 * em
+	top := body.asc + gap
+
+	return box{
+		w:    lead + sign + body.w + 0.1*em,
+		asc:  top + 0.05*em,
+		desc: body.desc,
+		draw: func(x, baseline float64) {
+			x0 := x + lead
+			t.pdf.SetLineWidth(0.05 * em)
+			t.pdf.Line(x0, baseline-0.25*em, x0+0.12*em, baseline-0.32*em)
+			t.pdf.Line(x0+0.12*em, baseline-0.32*em, x0+0.28*em, baseline+body.desc)
+			t.pdf.Line(x0+0.28*em, baseline+body.desc, x0+sign-0.05*em, baseline-top)
+			t.pdf.Line(x0+sign-0.05*em, baseline-top, x0+sign+body.w+0.1*em, baseline-top)
+			if index != nil {
+				index.draw(x, baseline-0.4*em)
+			}
+			body.draw(x0+sign, baseline)
+		},
+	}
+}
+
+func (t *typesetter) scripts(s latex.Scripts, size float64) box {
+	em := size * ptToMM
+	base := t.hbox(t.boxes(s.Base, size))
+	sup := t.hbox(t.boxes(s.Sup, size*0.7))
+	sub := t.hbox(t.boxes(s.Sub, size*0.7))
+
+	raise := max(0.42*em, base.asc-0.35*em)
+	lower := 0.2 * em
+
+	b := box{
+		w:    base.w + max(sup.w, sub.w) + 0.03*em,
+		asc:  base.asc,
+		desc: base.desc,
+	}
+	if len(s.Sup) > 0 {
+		b.asc = max(b.asc, raise+sup.asc)
+	}
+	if len(s.Sub) > 0 {
+		b.desc = max(b.desc, lower+sub.desc)
+	}
+	b.draw = func(x, baseline float64) {
+		base.draw(x, baseline)
+		if len(s.Sup) > 0 {
+			sup.draw(x+base.w+0.03*em, baseline-raise)
+		}
+		if len(s.Sub) > 0 {
+			sub.draw(x+base.w+0.03*em, baseline+lower)
+		}
+	}
+	return b
+}
+
+// paragraph sets boxes into lines no wider than width starting at x and the
+// current Y position, adding pages as needed. Lines after the first are
+// indented by hang; display maths is centred.
+func (t *typesetter) paragraph(items []box, x, width, hang, leading float64) {
+	var line []box
+	first := true
+
+	emit := func(centred bool) {
+		for len(line) > 0 && line[len(line)-1].breakable {
+			line = line[:len(line)-1]
+		}
+		if len(line) == 0 {
+			return
+		}
+		var asc, desc, w float64
+		for _, b := range line {
+			asc = max(asc, b.asc)
+			desc = max(desc, b.desc)
+			w += b.w
+		}
+		t.ensureSpace(asc + desc + leading)
+		baseline := t.pdf.GetY() + asc
+		cx := x
+		if !first {
+			cx += hang
+		}
+		if centred {
+			cx = x + (width-w)/2
+		}
+		for _, b := range line {
+			if b.draw != nil {
+				b.draw(cx, baseline)
+			}
+			cx += b.w
+		}
+		t.pdf.SetY(baseline + desc + leading)
+		line = nil
+		first = false
+	}
+
+	for _, b := range items {
+		switch {
+		case b.newline:
+			emit(false)
+			continue
+		case b.display:
+			emit(false)
+			line = []box{b}
+			emit(true)
+			continue
+		case b.breakable && len(line) == 0:
+			continue
+		}
+
+		avail := width
+		if !first {
+			avail -= hang
+		}
+		if !b.breakable && lineWidth(line)+b.w > avail && len(line) > 0 {
+			if brk := lastBreak(line); brk >= 0 {
+				rest := append([]box(nil), line[brk+1:]...)
+				line = line[:brk]
+				emit(false)
+				line = rest
+			} else {
+				emit(false)
+			}
+		}
+		line = append(line, b)
+	}
+	emit(false)
+}
+
+func lineWidth(line []box) float64 {
+	var w float64
+	for _, b := range line {
+		w += b.w
+	}
+	return w
+}
+
+func lastBreak(line []box) int {
+	for i := len(line) - 1; i >= 0; i-- {
+		if line[i].breakable {
+			return i
+		}
+	}
+	return -1
+}
+
+// ensureSpace starts a new page if h millimetres will not fit on this one.
+func (t *typesetter) ensureSpace(h float64) {
+	_, pageH := t.pdf.GetPageSize()
+	_, _, _, bottom := t.pdf.GetMargins()
+	if t.pdf.GetY()+h > pageH-bottom {
+		t.pdf.AddPage()
+	}
+}
@@ -0,0 +1,166 @@
+// Package worksheet renders printable quiz worksheets and answer keys as PDF.
+// Question text may contain LaTeX maths, which is typeset in-process with the
+// bundled DejaVu Sans fonts so no TeX installation or network access is needed.
+package worksheet
+
+import (
+	"M-AI/pkg/latex"
+	_ "embed"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// DejaVu Sans Condensed, redistributed under the Bitstream Vera / DejaVu
+// font licence.
+var (
+	//go:embed fonts/DejaVuSansCondensed.ttf
+	fontRegular []byte
+	//go:embed fonts/DejaVuSansCondensed-Bold.ttf
+	fontBold []byte
+	//go:embed fonts/DejaVuSansCondensed-Oblique.ttf
+	fontItalic []byte
+)
+
+type Option struct {
+	Label string
+	Text  string
+}
+
+type Item struct {
+	Prompt  string
+	Options []Option
+}
+
+type KeyEntry struct {
+	Answer   string
+	Solution string
+}
+
+type Document struct {
+	Title       string
+	Description string
+	Level       string
+	Version     string
+	CreatedAt   time.Time
+	Items       []Item
+	// Key is printed on its own page when non-empty, one entry per item.
+	Key []KeyEntry
+}
+
+const (
+	bodySize    = 11.0
+	marginMM    = 18.0
+	leadingMM   = 1.2
+	optionShift = 8.0
+)
+
+func Render(doc Document, w io.Writer) error {
+	pdf := gofpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(fontFamily, "", fontRegular)
+	pdf.AddUTF8FontFromBytes(fontFamily, "B", fontBold)
+	pdf.AddUTF8FontFromBytes(fontFamily, "I", fontItalic)
+	pdf.SetMargins(marginMM, marginMM, marginMM)
+	pdf.SetAutoPageBreak(false, marginMM)
+	pdf.SetTitle(doc.Title, true)
+	pdf.SetCreator("M-AI", true)
+	pdf.SetCatalogSort(true)
+	pdf.SetCreationDate(doc.CreatedAt)
+	pdf.SetModificationDate(doc.CreatedAt)
+
+	t := &typesetter{pdf: pdf}
+	pageW, _ := pdf.GetPageSize()
+	width := pageW - 2*marginMM
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetFont(fontFamily, "", 8)
+		footer := fmt.Sprintf("Page %d", pdf.PageNo())
+		if doc.Version != "" {
+			footer = fmt.Sprintf("Version %s · %s", doc.Version, footer)
+		}
+		pdf.SetXY(marginMM, -12)
+		pdf.CellFormat(width, 5, footer, "", 0, "C", false, 0, "")
+	})
+
+	pdf.AddPage()
+	t.header(doc, width, true)
+
+	for i, item := range doc.Items {
+		t.ensureSpace(20)
+		t.numbered(i+1, latex.Parse(item.Prompt), width)
+		for _, opt := range item.Options {
+			label := t.atom(latex.Atom{Text: opt.Label + ")", Bold: true}, bodySize)
+			label.w = optionShift
+			items := append([]box{label}, t.boxes(latex.Parse(opt.Text), bodySize)...)
+			t.paragraph(items, marginMM+optionShift, width-optionShift, optionShift, leadingMM)
+		}
+		pdf.SetY(pdf.GetY() + 4)
+	}
+
+	if len(doc.Key) > 0 {
+		pdf.AddPage()
+		t.header(doc, width, false)
+		for i, entry := range doc.Key {
+			t.ensureSpace(10)
+			t.numbered(i+1, []latex.Node{latex.Atom{Text: entry.Answer, Bold: true}}, width)
+			if entry.Solution != "" {
+				t.paragraph(t.boxes(latex.Parse(entry.Solution), bodySize-1), marginMM+optionShift, width-optionShift, 0, leadingMM)
+			}
+			pdf.SetY(pdf.GetY() + 2)
+		}
+	}
+
+	if err := pdf.Error(); err != nil {
+		return err
+	}
+	return pdf.Output(w)
+}
+
+func (t *typesetter) header(doc Document, width float64, worksheet bool) {
+	pdf := t.pdf
+	title := doc.Title
+	if !worksheet {
+		title += " — Answer key"
+	}
+	t.paragraph(t.boxes([]latex.Node{latex.Atom{Text: title, Bold: true}}, 16), marginMM, width, 0, 2)
+
+	var meta []string
+	if doc.Level != "" {
+		meta = append(meta, "Level: "+strings.ToUpper(doc.Level[:1])+doc.Level[1:])
+	}
+	if doc.Version != "" {
+		meta = append(meta, "Version: "+doc.Version)
+	}
+	if len(meta) > 0 {
+		pdf.SetFont(fontFamily, "", 9)
+		pdf.SetTextColor(90, 90, 90)
+		pdf.CellFormat(width, 5, strings.Join(meta, "   "), "", 1, "L", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	}
+
+	if worksheet {
+		if doc.Description != "" {
+			t.paragraph(t.boxes(latex.Parse(doc.Description), bodySize-1), marginMM, width, 0, leadingMM)
+		}
+		pdf.SetY(pdf.GetY() + 3)
+		pdf.SetFont(fontFamily, "", 10)
+		pdf.CellFormat(width/2, 6, "Name: ______________________________", "", 0, "L", false, 0, "")
+		pdf.CellFormat(width/2, 6, "Date: ________________", "", 1, "R", false, 0, "")
+	}
+
+	pdf.SetY(pdf.GetY() + 2)
+	pdf.SetLineWidth(0.3)
+	pdf.Line(marginMM, pdf.GetY(), marginMM+width, pdf.GetY())
+	pdf.SetY(pdf.GetY() + 5)
+}
+
+// numbered sets a paragraph with a hanging question number.
+func (t *typesetter) numbered(n int, nodes []latex.Node, width float64) {
+	label := t.atom(latex.Atom{Text: fmt.Sprintf("%d.", n), Bold: true}, bodySize)
+	label.w = optionShift
+	items := append([]box{label}, t.boxes(nodes, bodySize)...)
+	t.paragraph(items, marginMM, width, optionShift, leadingMM)
+}
@@ -30,6 +30,7 @@ export default function ResourcesPage() {
   const [selectedLevel, setSelectedLevel] = useState<string | null>(null);
   const [selectedType, setSelectedType] = useState<string | null>(null);
   const [resources, setResources] = useState<Resource[]>([]);
+  const [nextCursor, setNextCursor] = useState<string | undefined>();
 
   const levels = ["Beginner", "Intermediate", "Advanced"];
   const types = ["Course", "Interactive", "Video Series", "Practice"];
@@ -42,6 +43,7 @@ export default function ResourcesPage() {
           level: selectedLevel || undefined,
         });
         setResources(res.data.data);
+        setNextCursor(res.data.next_cursor);
       } catch (error) {
         console.error("Failed to load resources:", error);
       }
@@ -50,6 +52,21 @@ export default function ResourcesPage() {
     fetchResources();
   }, [searchQuery, selectedLevel, selectedType]);
 
+  const loadMore = async () => {
+    if (!nextCursor) return;
+    try {
+      const res = await ResourceAPI.getResources({
+        search: searchQuery,
+        level: selectedLevel || undefined,
+        cursor: nextCursor,
+      });
+      setResources((prev) => [...prev, ...res.data.data]);
+      setNextCursor(res.data.next_cursor);
+    } catch (error) {
+      console.error("Failed to load resources:", error);
+    }
+  };
+
   const filteredResources = resources.filter((resource) => {
     const matchesSearch =
       searchQuery === "" ||
@@ -182,6 +199,14 @@ export default function ResourcesPage() {
             </Card>
           ))}
         </div>
+
+        {nextCursor && (
+          <div className="flex justify-center">
+            <Button variant="outline" onClick={loadMore}>
+              Load more
+            </Button>
+          </div>
+        )}
       </div>
     </div>
   );
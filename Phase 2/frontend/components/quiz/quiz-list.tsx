@@ -21,6 +21,8 @@ function QuizList({
 }) {
   const [isCreateModalOpen, setIsCreateModalOpen] = useState(false);
   const [quizzes, setQuizzes] = useState<QuizSummary[]>([]);
+  const [nextCursor, setNextCursor] = useState<string | undefined>();
+  const [loadingMore, setLoadingMore] = useState(false);
   const [filter, setFilter] = useState<"all" | "completed">("all");
   const colors = [
     "bg-purple-500",
@@ -34,8 +36,8 @@ function QuizList({
     const fetchQuizzes = async () => {
       try {
         const res = await QuizAPI.listQuizzes({ filter });
-        // @ts-ignore
         setQuizzes(res.data.data);
+        setNextCursor(res.data.next_cursor);
       } catch (err) {
         console.error("Failed to load quizzes", err);
       } finally {
@@ -45,6 +47,30 @@ function QuizList({
     fetchQuizzes();
   }, [filter]);
 
+  const loadMore = async () => {
+    if (!nextCursor) return;
+    setLoadingMore(true);
+    try {
+      const res = await QuizAPI.listQuizzes({ filter, cursor: nextCursor });
+      setQuizzes((prev) => [...prev, ...res.data.data]);
+      setNextCursor(res.data.next_cursor);
+    } catch (err) {
+      console.error("Failed to load quizzes", err);
+    } finally {
+      setLoadingMore(false);
+    }
+  };
+
+  // The list leaves questions out, so fetch the whole quiz before starting.
+  const startQuiz = async (quiz: QuizSummary) => {
+    try {
+      const res = await QuizAPI.getQuiz(quiz.id);
+      setSelectedQuiz(res.data.data);
+    } catch (err) {
+      console.error("Failed to load quiz", err);
+    }
+  };
+
   const openCreateModal = () => {
     setIsCreateModalOpen(true);
   };
@@ -169,7 +195,7 @@ function QuizList({
                   <Button
                     className={`w-full hover:${colors[i % colors.length]} ${colors[i % colors.length]
                       } hover:opacity-90 transition-opacity`}
-                    onClick={() => setSelectedQuiz(quiz)}
+                    onClick={() => startQuiz(quiz)}
                     disabled={!!quiz.completed_at}
                   >
                     {quiz.completed_at ? "Quiz Solved" : "Start Quiz"}
@@ -179,6 +205,13 @@ function QuizList({
             </div>
           ))}
         </div>
+        {nextCursor && (
+          <div className="flex justify-center">
+            <Button variant="outline" onClick={loadMore} disabled={loadingMore}>
+              {loadingMore ? "Loading..." : "Load more"}
+            </Button>
+          </div>
+        )}
       </div>
       {isCreateModalOpen && (
         <QuizModal
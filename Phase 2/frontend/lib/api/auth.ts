@@ -4,6 +4,12 @@ export interface ApiResponse<T> {
     data: T;
 }
 
+// PageResponse is one page of a list. Pass next_cursor back as the cursor
+// parameter to fetch the next page; it is absent on the last page.
+export interface PageResponse<T> extends ApiResponse<T[]> {
+    next_cursor?: string;
+}
+
 export interface SignupRequest {
     name: string;
     email: string;
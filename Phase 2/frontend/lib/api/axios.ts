@@ -1,4 +1,4 @@
-import axios from 'axios';
+import axios, { AxiosError, InternalAxiosRequestConfig } from 'axios';
 
 const instance = axios.create({
     baseURL: 'http://localhost:8080/api/v1', // your backend base URL
@@ -8,4 +8,38 @@ const instance = axios.create({
     },
 });
 
-export default instance;
\ No newline at end of file
+// Access tokens are short-lived. When one expires, renew it with the refresh
+// cookie once and retry the request; concurrent requests share the refresh.
+let refreshing: Promise<unknown> | null = null;
+const noRefresh = ['/auth/login', '/auth/signup', '/auth/refresh', '/auth/logout'];
+
+instance.interceptors.response.use(
+    (response) => response,
+    async (error: AxiosError) => {
+        const request = error.config as (InternalAxiosRequestConfig & { _retried?: boolean }) | undefined;
+        const url = request?.url ?? '';
+        if (error.response?.status !== 401 || !request || request._retried || noRefresh.includes(url)) {
+            return Promise.reject(error);
+        }
+
+        request._retried = true;
+        // A 409 means another tab refreshed at the same moment; its new
+        // cookies are already set, so the request can simply be retried.
+        refreshing ??= instance
+            .post('/auth/refresh')
+            .catch((refreshError: AxiosError) => {
+                if (refreshError.response?.status !== 409) throw refreshError;
+            })
+            .finally(() => {
+                refreshing = null;
+            });
+        try {
+            await refreshing;
+        } catch {
+            return Promise.reject(error);
+        }
+        return instance(request);
+    },
+);
+
+export default instance;
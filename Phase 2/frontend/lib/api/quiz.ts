@@ -1,3 +1,4 @@
+import { ApiResponse, PageResponse } from "./auth";
 import axios from "./axios";
 
 export interface CreateQuizRequest {
@@ -20,7 +21,8 @@ export interface QuizSummary {
   question_count: number;
   score: number;
   completed_at: string | null;
-  questions: Question[];
+  // Only filled in by getQuiz; lists leave the questions out.
+  questions?: Question[];
 }
 
 export interface Question {
@@ -43,13 +45,24 @@ const QuizAPI = {
     return axios.post("/quizzes", data, { withCredentials: true });
   },
 
-  listQuizzes: (params?: { search?: string; filter?: "all" | "completed" }) => {
+  listQuizzes: (params?: {
+    search?: string;
+    filter?: "all" | "completed";
+    cursor?: string;
+  }) => {
     const query = new URLSearchParams();
     if (params?.search) query.append("search", params.search);
     if (params?.filter) query.append("filter", params.filter);
+    if (params?.cursor) query.append("cursor", params.cursor);
 
     const url = `/quizzes${query.toString() ? "?" + query.toString() : ""}`;
-    return axios.get<QuizSummary[]>(url, { withCredentials: true });
+    return axios.get<PageResponse<QuizSummary>>(url, { withCredentials: true });
+  },
+
+  getQuiz: (id: number) => {
+    return axios.get<ApiResponse<QuizSummary>>(`/quizzes/${id}`, {
+      withCredentials: true,
+    });
   },
 
   completeQuiz: (data: {
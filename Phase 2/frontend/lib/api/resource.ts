@@ -1,4 +1,4 @@
-import { ApiResponse } from './auth';
+import { PageResponse } from './auth';
 import axios from './axios';
 
 export interface Resource {
@@ -12,15 +12,17 @@ export interface Resource {
 }
 
 const ResourceAPI = {
-    getResources: (params?: { search?: string; level?: string }) => {
+    getResources: (params?: { search?: string; level?: string; cursor?: string }) => {
         const queryParams = new URLSearchParams();
 
         if (params?.search) queryParams.append('search', params.search);
-        if (params?.level) queryParams.append('level', params.level);
+        // The API only accepts lower-case levels.
+        if (params?.level) queryParams.append('level', params.level.toLowerCase());
+        if (params?.cursor) queryParams.append('cursor', params.cursor);
 
         const queryString = queryParams.toString() ? `?${queryParams.toString()}` : '';
 
-        return axios.get<ApiResponse<Resource[]>>(`/resources${queryString}`, {
+        return axios.get<PageResponse<Resource>>(`/resources${queryString}`, {
             withCredentials: true,
         });
     },